@@ -142,6 +142,888 @@ func getMigrations() []Migration {
 			)
 			`,
 		},
+		{
+			Name: "004_add_target_moisture_to_compost_history",
+			SQL: `
+			ALTER TABLE compost_history
+				ADD COLUMN target_moisture DOUBLE DEFAULT 60
+			`,
+		},
+		{
+			Name: "005_create_compost_materials_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS compost_materials (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				user_id INT NULL,
+				name VARCHAR(255) NOT NULL,
+				category VARCHAR(20) NOT NULL,
+				c_content DOUBLE NOT NULL DEFAULT 0,
+				n_content DOUBLE NOT NULL DEFAULT 0,
+				moisture_content DOUBLE NOT NULL DEFAULT 0,
+				density DOUBLE NOT NULL DEFAULT 0,
+				description TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				INDEX idx_user_id (user_id),
+				INDEX idx_name (name),
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			)
+			`,
+		},
+		{
+			Name: "006_seed_compost_materials",
+			SQL: `
+			INSERT INTO compost_materials (user_id, name, category, c_content, n_content, moisture_content, density, description) VALUES
+				(NULL, '鸡粪', 'nitrogen', 35.0, 3.2, 55, 700, '系统默认物料，碳氮含量为干基'),
+				(NULL, '猪粪', 'nitrogen', 40.0, 2.8, 70, 800, '系统默认物料，碳氮含量为干基'),
+				(NULL, '牛粪', 'nitrogen', 38.0, 1.8, 80, 800, '系统默认物料，碳氮含量为干基'),
+				(NULL, '羊粪', 'nitrogen', 40.0, 2.3, 60, 700, '系统默认物料，碳氮含量为干基'),
+				(NULL, '豆粕', 'nitrogen', 45.0, 7.0, 12, 600, '系统默认物料，碳氮含量为干基'),
+				(NULL, '稻草', 'carbon', 42.0, 0.63, 12, 150, '系统默认物料，碳氮含量为干基'),
+				(NULL, '小麦秸秆', 'carbon', 46.0, 0.53, 10, 120, '系统默认物料，碳氮含量为干基'),
+				(NULL, '玉米秸秆', 'carbon', 44.0, 0.75, 12, 150, '系统默认物料，碳氮含量为干基'),
+				(NULL, '稻壳', 'carbon', 41.0, 0.6, 10, 130, '系统默认物料，碳氮含量为干基'),
+				(NULL, '锯末', 'carbon', 50.0, 0.2, 20, 250, '系统默认物料，碳氮含量为干基'),
+				(NULL, '菇渣', 'carbon', 38.0, 1.5, 55, 400, '系统默认物料，碳氮含量为干基')
+			`,
+		},
+		{
+			Name: "007_add_material_id_to_compost_history_sources",
+			SQL: `
+			ALTER TABLE compost_history_sources
+				ADD COLUMN material_id INT NULL,
+				ADD INDEX idx_material_id (material_id)
+			`,
+		},
+		{
+			Name: "008_add_deleted_at_to_compost_history",
+			SQL: `
+			ALTER TABLE compost_history
+				ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+				ADD INDEX idx_user_deleted (user_id, deleted_at)
+			`,
+		},
+		{
+			Name: "009_create_compost_piles_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS compost_piles (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				user_id INT NOT NULL,
+				compost_history_id INT NOT NULL,
+				name VARCHAR(255),
+				start_date DATE NOT NULL,
+				status VARCHAR(20) NOT NULL DEFAULT 'active',
+				notes TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				INDEX idx_user_id (user_id),
+				INDEX idx_compost_history_id (compost_history_id)
+			)
+			`,
+		},
+		{
+			Name: "010_create_compost_pile_logs_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS compost_pile_logs (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				pile_id INT NOT NULL,
+				log_type VARCHAR(20) NOT NULL,
+				logged_at DATETIME NOT NULL,
+				temperature DOUBLE NULL,
+				moisture DOUBLE NULL,
+				note TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				INDEX idx_pile_logged_at (pile_id, logged_at),
+				FOREIGN KEY (pile_id) REFERENCES compost_piles(id) ON DELETE CASCADE
+			)
+			`,
+		},
+		{
+			Name: "011_create_compost_maturity_standards_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS compost_maturity_standards (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				code VARCHAR(50) NOT NULL UNIQUE,
+				name VARCHAR(255) NOT NULL,
+				max_cn_ratio DOUBLE NULL,
+				min_ph DOUBLE NULL,
+				max_ph DOUBLE NULL,
+				max_ec DOUBLE NULL,
+				min_germination_index DOUBLE NULL,
+				max_moisture DOUBLE NULL,
+				min_organic_matter DOUBLE NULL,
+				min_total_nutrient DOUBLE NULL,
+				require_temperature_stable BOOLEAN DEFAULT FALSE,
+				description TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+			)
+			`,
+		},
+		{
+			Name: "012_seed_compost_maturity_standards",
+			SQL: `
+			INSERT INTO compost_maturity_standards (code, name, max_cn_ratio, min_ph, max_ph, max_ec, min_germination_index,
+				max_moisture, min_organic_matter, min_total_nutrient, require_temperature_stable, description) VALUES
+				('NY/T 525-2021', '有机肥料', NULL, 5.5, 8.5, NULL, 70, 30, 30, 4.0, FALSE,
+					'有机质≥30%，总养分≥4.0%，水分≤30%，pH 5.5~8.5，种子发芽指数≥70%'),
+				('MATURITY', '堆肥腐熟度（通用）', 20, 6.5, 8.5, 4.0, 80, NULL, NULL, NULL, TRUE,
+					'碳氮比≤20，pH 6.5~8.5，EC≤4 mS/cm，种子发芽指数≥80%，堆温稳定')
+			`,
+		},
+		{
+			Name: "013_create_compost_assessments_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS compost_assessments (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				compost_history_id INT NOT NULL,
+				user_id INT NOT NULL,
+				standard_code VARCHAR(50) NOT NULL,
+				final_cn_ratio DOUBLE NULL,
+				ph DOUBLE NULL,
+				ec DOUBLE NULL,
+				germination_index DOUBLE NULL,
+				temperature_stable BOOLEAN NULL,
+				moisture DOUBLE NULL,
+				organic_matter DOUBLE NULL,
+				total_nutrient DOUBLE NULL,
+				grade VARCHAR(20) NOT NULL,
+				passed BOOLEAN NOT NULL DEFAULT FALSE,
+				checks JSON,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				INDEX idx_compost_history_id (compost_history_id),
+				INDEX idx_user_id (user_id)
+			)
+			`,
+		},
+		{
+			Name: "014_add_phosphorus_potassium_to_compost_materials",
+			SQL: `
+			ALTER TABLE compost_materials
+				ADD COLUMN p2o5_content DOUBLE NOT NULL DEFAULT 0 AFTER n_content,
+				ADD COLUMN k2o_content DOUBLE NOT NULL DEFAULT 0 AFTER p2o5_content
+			`,
+		},
+		{
+			Name: "015_seed_compost_materials_phosphorus_potassium",
+			SQL: `
+			UPDATE compost_materials SET
+				p2o5_content = CASE name
+					WHEN '鸡粪' THEN 3.2 WHEN '猪粪' THEN 2.6 WHEN '牛粪' THEN 1.2 WHEN '羊粪' THEN 1.1
+					WHEN '豆粕' THEN 1.4 WHEN '稻草' THEN 0.25 WHEN '小麦秸秆' THEN 0.18 WHEN '玉米秸秆' THEN 0.3
+					WHEN '稻壳' THEN 0.15 WHEN '锯末' THEN 0.05 WHEN '菇渣' THEN 0.8 END,
+				k2o_content = CASE name
+					WHEN '鸡粪' THEN 2.2 WHEN '猪粪' THEN 1.6 WHEN '牛粪' THEN 1.4 WHEN '羊粪' THEN 1.8
+					WHEN '豆粕' THEN 2.3 WHEN '稻草' THEN 2.0 WHEN '小麦秸秆' THEN 1.3 WHEN '玉米秸秆' THEN 1.6
+					WHEN '稻壳' THEN 0.6 WHEN '锯末' THEN 0.2 WHEN '菇渣' THEN 1.0 END,
+				description = '系统默认物料，碳、氮、磷、钾含量为干基'
+			WHERE user_id IS NULL
+				AND name IN ('鸡粪', '猪粪', '牛粪', '羊粪', '豆粕', '稻草', '小麦秸秆', '玉米秸秆', '稻壳', '锯末', '菇渣')
+			`,
+		},
+		{
+			Name: "016_add_phosphorus_potassium_to_compost_history_sources",
+			SQL: `
+			ALTER TABLE compost_history_sources
+				ADD COLUMN p2o5_content DOUBLE NOT NULL DEFAULT 0,
+				ADD COLUMN k2o_content DOUBLE NOT NULL DEFAULT 0
+			`,
+		},
+		{
+			Name: "017_add_organic_credit_to_records",
+			SQL: `
+			ALTER TABLE records
+				ADD COLUMN organic_compost_id INT NULL,
+				ADD COLUMN organic_credit_n DOUBLE NOT NULL DEFAULT 0,
+				ADD COLUMN organic_credit_p2o5 DOUBLE NOT NULL DEFAULT 0,
+				ADD COLUMN organic_credit_k2o DOUBLE NOT NULL DEFAULT 0
+			`,
+		},
+		{
+			Name: "018_create_weather_daily_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS weather_daily (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				user_id INT NOT NULL,
+				station VARCHAR(100) NOT NULL,
+				date DATE NOT NULL,
+				latitude DOUBLE NOT NULL DEFAULT 0,
+				altitude DOUBLE NOT NULL DEFAULT 0,
+				t_max DOUBLE NOT NULL,
+				t_min DOUBLE NOT NULL,
+				rh_max DOUBLE NULL,
+				rh_min DOUBLE NULL,
+				rh_mean DOUBLE NULL,
+				wind_speed DOUBLE NULL,
+				radiation DOUBLE NULL,
+				sunshine_hours DOUBLE NULL,
+				rainfall DOUBLE NOT NULL DEFAULT 0,
+				et0 DOUBLE NOT NULL DEFAULT 0,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				UNIQUE KEY uk_user_station_date (user_id, station, date)
+			)
+			`,
+		},
+		{
+			Name: "019_create_soil_textures_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS soil_textures (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				code VARCHAR(50) NOT NULL UNIQUE,
+				name VARCHAR(100) NOT NULL,
+				field_capacity DOUBLE NOT NULL,
+				wilting_point DOUBLE NOT NULL,
+				bulk_density DOUBLE NOT NULL,
+				infiltration_rate DOUBLE NOT NULL,
+				description TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				INDEX idx_name (name)
+			)
+			`,
+		},
+		{
+			Name: "020_seed_soil_textures",
+			SQL: `
+			INSERT INTO soil_textures (code, name, field_capacity, wilting_point, bulk_density, infiltration_rate, description) VALUES
+				('sand', '砂土', 8, 3, 1.60, 30, '含水量为质量含水量'),
+				('loamy_sand', '壤质砂土', 11, 5, 1.55, 25, '含水量为质量含水量'),
+				('sandy_loam', '砂壤土', 16, 8, 1.50, 20, '含水量为质量含水量'),
+				('loam', '壤土', 22, 11, 1.40, 13, '含水量为质量含水量'),
+				('silt_loam', '粉壤土', 25, 12, 1.35, 10, '含水量为质量含水量'),
+				('clay_loam', '黏壤土', 27, 15, 1.35, 8, '含水量为质量含水量'),
+				('silty_clay', '粉黏土', 30, 18, 1.30, 5, '含水量为质量含水量'),
+				('clay', '黏土', 32, 20, 1.25, 3, '含水量为质量含水量')
+			`,
+		},
+		{
+			Name: "021_create_crops_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS crops (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				code VARCHAR(50) NOT NULL UNIQUE,
+				name VARCHAR(100) NOT NULL,
+				root_depth_initial DOUBLE NOT NULL,
+				root_depth_development DOUBLE NOT NULL,
+				root_depth_mid DOUBLE NOT NULL,
+				root_depth_late DOUBLE NOT NULL,
+				optimal_moisture_min DOUBLE NOT NULL,
+				optimal_moisture_max DOUBLE NOT NULL,
+				kc_initial DOUBLE NOT NULL DEFAULT 0,
+				kc_mid DOUBLE NOT NULL DEFAULT 0,
+				kc_end DOUBLE NOT NULL DEFAULT 0,
+				stage_initial_days INT NOT NULL DEFAULT 0,
+				stage_development_days INT NOT NULL DEFAULT 0,
+				stage_mid_days INT NOT NULL DEFAULT 0,
+				stage_late_days INT NOT NULL DEFAULT 0,
+				description TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				INDEX idx_name (name)
+			)
+			`,
+		},
+		{
+			Name: "022_seed_crops",
+			SQL: `
+			INSERT INTO crops (code, name, root_depth_initial, root_depth_development, root_depth_mid, root_depth_late,
+				optimal_moisture_min, optimal_moisture_max, kc_initial, kc_mid, kc_end,
+				stage_initial_days, stage_development_days, stage_mid_days, stage_late_days, description) VALUES
+				('wheat', '小麦', 20, 40, 60, 60, 65, 80, 0.7, 1.15, 0.4, 30, 140, 40, 30, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('maize', '玉米', 20, 40, 60, 60, 65, 80, 0.3, 1.2, 0.35, 30, 40, 50, 30, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('rice', '水稻', 20, 30, 40, 40, 80, 95, 1.05, 1.2, 0.9, 30, 30, 60, 30, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('soybean', '大豆', 20, 40, 50, 50, 65, 80, 0.4, 1.15, 0.5, 20, 30, 60, 25, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('cotton', '棉花', 20, 40, 60, 60, 60, 75, 0.35, 1.18, 0.5, 30, 50, 60, 55, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('potato', '马铃薯', 15, 30, 40, 40, 65, 80, 0.5, 1.15, 0.75, 25, 30, 45, 30, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('tomato', '番茄', 20, 30, 40, 40, 70, 85, 0.6, 1.15, 0.8, 30, 40, 40, 25, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('cucumber', '黄瓜', 15, 25, 30, 30, 75, 90, 0.6, 1.0, 0.75, 20, 30, 40, 15, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('pepper', '辣椒', 15, 25, 30, 30, 70, 85, 0.6, 1.05, 0.9, 25, 35, 40, 20, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('cabbage', '白菜', 15, 25, 30, 30, 70, 85, 0.7, 1.05, 0.95, 40, 60, 50, 15, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('citrus', '柑橘', 40, 60, 80, 80, 65, 80, 0.7, 0.65, 0.7, 60, 90, 120, 95, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('apple', '苹果', 40, 60, 80, 80, 65, 80, 0.6, 0.95, 0.75, 20, 70, 90, 30, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('grape', '葡萄', 40, 60, 80, 80, 60, 75, 0.3, 0.85, 0.45, 20, 40, 120, 60, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12'),
+				('tea', '茶', 30, 40, 50, 50, 70, 85, 0.95, 1.0, 1.0, 60, 90, 150, 65, '计划湿润层深度单位为 cm，适宜含水率占田间持水量，作物系数取自 FAO-56 表 11、表 12')
+			`,
+		},
+		{
+			Name: "023_create_water_record_histories_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS water_record_histories (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				record_id INT NOT NULL,
+				user_id INT NOT NULL,
+				action VARCHAR(20) NOT NULL,
+				snapshot JSON NOT NULL,
+				changes JSON,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				INDEX idx_record_id (record_id),
+				INDEX idx_user_id (user_id)
+			)
+			`,
+		},
+		{
+			Name: "024_create_sensor_readings_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS sensor_readings (
+				id BIGINT AUTO_INCREMENT PRIMARY KEY,
+				machine_code_id INT NOT NULL,
+				user_id INT NOT NULL,
+				area_id INT NOT NULL DEFAULT 0,
+				depth DOUBLE NOT NULL DEFAULT 0,
+				moisture DOUBLE NOT NULL,
+				temperature DOUBLE NULL,
+				recorded_at DATETIME NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE KEY uk_device_area_depth_time (machine_code_id, area_id, depth, recorded_at),
+				INDEX idx_user_area_time (user_id, area_id, recorded_at)
+			)
+			`,
+		},
+		{
+			Name: "025_create_sensor_reading_aggregates_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS sensor_reading_aggregates (
+				id BIGINT AUTO_INCREMENT PRIMARY KEY,
+				machine_code_id INT NOT NULL,
+				user_id INT NOT NULL,
+				area_id INT NOT NULL DEFAULT 0,
+				depth DOUBLE NOT NULL DEFAULT 0,
+				granularity VARCHAR(10) NOT NULL,
+				bucket_start DATETIME NOT NULL,
+				avg_moisture DOUBLE NOT NULL,
+				min_moisture DOUBLE NOT NULL,
+				max_moisture DOUBLE NOT NULL,
+				sample_count INT NOT NULL,
+				UNIQUE KEY uk_device_area_depth_bucket (machine_code_id, area_id, depth, granularity, bucket_start),
+				INDEX idx_user_area_bucket (user_id, area_id, granularity, bucket_start)
+			)
+			`,
+		},
+		{
+			Name: "026_create_alert_rules_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS alert_rules (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				user_id INT NOT NULL,
+				name VARCHAR(255),
+				area_id INT NOT NULL DEFAULT 0,
+				rule_type VARCHAR(30) NOT NULL,
+				threshold DOUBLE NOT NULL,
+				channels VARCHAR(100) NOT NULL DEFAULT 'log',
+				webhook_url VARCHAR(500),
+				phone VARCHAR(20),
+				cooldown_minutes INT NOT NULL DEFAULT 0,
+				is_active BOOLEAN DEFAULT TRUE,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				INDEX idx_user_area (user_id, area_id),
+				INDEX idx_rule_type (rule_type, is_active)
+			)
+			`,
+		},
+		{
+			Name: "027_create_alert_events_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS alert_events (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				rule_id INT NOT NULL,
+				user_id INT NOT NULL,
+				area_id INT NOT NULL DEFAULT 0,
+				rule_type VARCHAR(30) NOT NULL,
+				value DOUBLE NULL,
+				message VARCHAR(500) NOT NULL,
+				status VARCHAR(20) NOT NULL DEFAULT 'open',
+				is_read BOOLEAN DEFAULT FALSE,
+				occurrences INT NOT NULL DEFAULT 1,
+				first_triggered_at DATETIME NOT NULL,
+				last_triggered_at DATETIME NOT NULL,
+				last_notified_at DATETIME NULL,
+				resolved_at DATETIME NULL,
+				INDEX idx_rule_status (rule_id, status),
+				INDEX idx_user_status (user_id, status, is_read),
+				FOREIGN KEY (rule_id) REFERENCES alert_rules(id) ON DELETE CASCADE
+			)
+			`,
+		},
+		{
+			Name: "028_add_nutrient_uptake_to_crops",
+			SQL: `
+			ALTER TABLE crops
+				ADD COLUMN n_uptake DOUBLE NOT NULL DEFAULT 0,
+				ADD COLUMN p2o5_uptake DOUBLE NOT NULL DEFAULT 0,
+				ADD COLUMN k2o_uptake DOUBLE NOT NULL DEFAULT 0
+			`,
+		},
+		{
+			Name: "029_seed_crop_nutrient_uptake",
+			SQL: `
+			UPDATE crops SET
+				n_uptake = CASE code
+					WHEN 'wheat' THEN 3.0 WHEN 'maize' THEN 2.57 WHEN 'rice' THEN 2.2 WHEN 'soybean' THEN 7.2
+					WHEN 'cotton' THEN 5.0 WHEN 'potato' THEN 0.5 WHEN 'tomato' THEN 0.33 WHEN 'cucumber' THEN 0.28
+					WHEN 'pepper' THEN 0.52 WHEN 'cabbage' THEN 0.41 WHEN 'citrus' THEN 0.6 WHEN 'apple' THEN 0.3
+					WHEN 'grape' THEN 0.6 WHEN 'tea' THEN 1.2 END,
+				p2o5_uptake = CASE code
+					WHEN 'wheat' THEN 1.25 WHEN 'maize' THEN 0.86 WHEN 'rice' THEN 1.1 WHEN 'soybean' THEN 1.8
+					WHEN 'cotton' THEN 1.8 WHEN 'potato' THEN 0.2 WHEN 'tomato' THEN 0.1 WHEN 'cucumber' THEN 0.09
+					WHEN 'pepper' THEN 0.11 WHEN 'cabbage' THEN 0.05 WHEN 'citrus' THEN 0.11 WHEN 'apple' THEN 0.08
+					WHEN 'grape' THEN 0.3 WHEN 'tea' THEN 0.3 END,
+				k2o_uptake = CASE code
+					WHEN 'wheat' THEN 2.5 WHEN 'maize' THEN 2.14 WHEN 'rice' THEN 2.7 WHEN 'soybean' THEN 4.0
+					WHEN 'cotton' THEN 4.0 WHEN 'potato' THEN 1.06 WHEN 'tomato' THEN 0.53 WHEN 'cucumber' THEN 0.4
+					WHEN 'pepper' THEN 0.65 WHEN 'cabbage' THEN 0.49 WHEN 'citrus' THEN 0.4 WHEN 'apple' THEN 0.32
+					WHEN 'grape' THEN 0.72 WHEN 'tea' THEN 0.5 END
+			WHERE code IN ('wheat', 'maize', 'rice', 'soybean', 'cotton', 'potato', 'tomato', 'cucumber',
+				'pepper', 'cabbage', 'citrus', 'apple', 'grape', 'tea')
+			`,
+		},
+		{
+			Name: "030_add_soil_test_to_records",
+			SQL: `
+			ALTER TABLE records
+				ADD COLUMN soil_alkali_n DOUBLE NULL,
+				ADD COLUMN soil_olsen_p DOUBLE NULL,
+				ADD COLUMN soil_available_k DOUBLE NULL,
+				ADD COLUMN target_yield DOUBLE NOT NULL DEFAULT 0
+			`,
+		},
+		{
+			Name: "031_create_fertilizer_products_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS fertilizer_products (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				user_id INT NULL,
+				name VARCHAR(255) NOT NULL,
+				n_content DOUBLE NOT NULL DEFAULT 0,
+				p2o5_content DOUBLE NOT NULL DEFAULT 0,
+				k2o_content DOUBLE NOT NULL DEFAULT 0,
+				package_size DOUBLE NOT NULL DEFAULT 50,
+				price DOUBLE NOT NULL DEFAULT 0,
+				usage_type VARCHAR(20) NOT NULL DEFAULT 'both',
+				description TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				INDEX idx_user_id (user_id),
+				INDEX idx_name (name),
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			)
+			`,
+		},
+		{
+			Name: "032_seed_fertilizer_products",
+			SQL: `
+			INSERT INTO fertilizer_products (user_id, name, n_content, p2o5_content, k2o_content, package_size, price, usage_type, description) VALUES
+				(NULL, '尿素', 46, 0, 0, 50, 125, 'both', '系统默认产品，养分含量为 N-P2O5-K2O 百分比，价格为每袋参考价'),
+				(NULL, '硫酸铵', 21, 0, 0, 50, 60, 'both', '系统默认产品，养分含量为 N-P2O5-K2O 百分比，价格为每袋参考价'),
+				(NULL, '磷酸二铵', 18, 46, 0, 50, 190, 'basal', '系统默认产品，养分含量为 N-P2O5-K2O 百分比，价格为每袋参考价'),
+				(NULL, '磷酸一铵', 11, 44, 0, 50, 170, 'basal', '系统默认产品，养分含量为 N-P2O5-K2O 百分比，价格为每袋参考价'),
+				(NULL, '过磷酸钙', 0, 12, 0, 50, 40, 'basal', '系统默认产品，养分含量为 N-P2O5-K2O 百分比，价格为每袋参考价'),
+				(NULL, '氯化钾', 0, 0, 60, 50, 160, 'both', '系统默认产品，养分含量为 N-P2O5-K2O 百分比，价格为每袋参考价'),
+				(NULL, '硫酸钾', 0, 0, 50, 50, 200, 'both', '系统默认产品，养分含量为 N-P2O5-K2O 百分比，价格为每袋参考价'),
+				(NULL, '复合肥 15-15-15', 15, 15, 15, 50, 160, 'basal', '系统默认产品，养分含量为 N-P2O5-K2O 百分比，价格为每袋参考价')
+			`,
+		},
+		{
+			Name: "033_create_crop_nutrient_coefficients_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS crop_nutrient_coefficients (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				crop_code VARCHAR(50) NOT NULL,
+				crop_name VARCHAR(50) NOT NULL,
+				version INT NOT NULL,
+				n_uptake DOUBLE NOT NULL,
+				p2o5_uptake DOUBLE NOT NULL,
+				k2o_uptake DOUBLE NOT NULL,
+				n_efficiency DOUBLE NOT NULL,
+				p2o5_efficiency DOUBLE NOT NULL,
+				k2o_efficiency DOUBLE NOT NULL,
+				n_correction DOUBLE NOT NULL,
+				p2o5_correction DOUBLE NOT NULL,
+				k2o_correction DOUBLE NOT NULL,
+				is_current BOOLEAN NOT NULL DEFAULT TRUE,
+				note VARCHAR(500),
+				created_by INT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE KEY uk_crop_version (crop_code, version),
+				INDEX idx_crop_current (crop_code, is_current),
+				INDEX idx_crop_name (crop_name)
+			)
+			`,
+		},
+		{
+			Name: "034_seed_crop_nutrient_coefficients",
+			SQL: `
+			INSERT INTO crop_nutrient_coefficients (crop_code, crop_name, version, n_uptake, p2o5_uptake, k2o_uptake,
+				n_efficiency, p2o5_efficiency, k2o_efficiency, n_correction, p2o5_correction, k2o_correction, note) VALUES
+				('wheat', '小麦', 1, 3.0, 1.25, 2.5, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('maize', '玉米', 1, 2.57, 0.86, 2.14, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('rice', '水稻', 1, 2.2, 1.1, 2.7, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('soybean', '大豆', 1, 7.2, 1.8, 4.0, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('cotton', '棉花', 1, 5.0, 1.8, 4.0, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('potato', '马铃薯', 1, 0.5, 0.2, 1.06, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('tomato', '番茄', 1, 0.33, 0.1, 0.53, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('cucumber', '黄瓜', 1, 0.28, 0.09, 0.4, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('pepper', '辣椒', 1, 0.52, 0.11, 0.65, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('cabbage', '白菜', 1, 0.41, 0.05, 0.49, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('citrus', '柑橘', 1, 0.6, 0.11, 0.4, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('apple', '苹果', 1, 0.3, 0.08, 0.32, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('grape', '葡萄', 1, 0.6, 0.3, 0.72, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数'),
+				('tea', '茶', 1, 1.2, 0.3, 0.5, 35, 20, 45, 0.6, 0.5, 0.5, '系统初始系数')
+			`,
+		},
+		{
+			Name: "035_drop_nutrient_uptake_from_crops",
+			SQL: `
+			ALTER TABLE crops
+				DROP COLUMN n_uptake,
+				DROP COLUMN p2o5_uptake,
+				DROP COLUMN k2o_uptake
+			`,
+		},
+		{
+			Name: "036_add_coefficient_id_to_records",
+			SQL: `
+			ALTER TABLE records
+				ADD COLUMN coefficient_id INT NULL,
+				ADD COLUMN yield_increase DOUBLE NULL
+			`,
+		},
+		{
+			Name: "037_create_soil_samples_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS soil_samples (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				user_id INT NOT NULL,
+				sample_code VARCHAR(100) NOT NULL,
+				location VARCHAR(255),
+				sampled_at DATE NULL,
+				ph DOUBLE NULL,
+				organic_matter DOUBLE NULL,
+				alkali_n DOUBLE NULL,
+				olsen_p DOUBLE NULL,
+				available_k DOUBLE NULL,
+				available_fe DOUBLE NULL,
+				available_mn DOUBLE NULL,
+				available_cu DOUBLE NULL,
+				available_zn DOUBLE NULL,
+				available_b DOUBLE NULL,
+				available_mo DOUBLE NULL,
+				source_file VARCHAR(255),
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE KEY uk_user_sample (user_id, sample_code),
+				INDEX idx_user_location (user_id, location)
+			)
+			`,
+		},
+		{
+			Name: "038_add_sample_id_to_records",
+			SQL: `
+			ALTER TABLE records ADD COLUMN sample_id INT NULL
+			`,
+		},
+		{
+			Name: "039_add_soil_properties_to_records",
+			SQL: `
+			ALTER TABLE records
+				ADD COLUMN soil_region VARCHAR(50) NOT NULL DEFAULT '',
+				ADD COLUMN soil_ph DOUBLE NULL,
+				ADD COLUMN soil_organic_matter DOUBLE NULL,
+				ADD COLUMN soil_ec DOUBLE NULL,
+				ADD COLUMN soil_cec DOUBLE NULL,
+				ADD COLUMN soil_ca DOUBLE NULL,
+				ADD COLUMN soil_mg DOUBLE NULL,
+				ADD COLUMN soil_s DOUBLE NULL,
+				ADD COLUMN soil_zn DOUBLE NULL,
+				ADD COLUMN soil_b DOUBLE NULL,
+				ADD COLUMN soil_fe DOUBLE NULL,
+				ADD COLUMN soil_mn DOUBLE NULL
+			`,
+		},
+		{
+			Name: "040_add_secondary_nutrients_to_soil_samples",
+			SQL: `
+			ALTER TABLE soil_samples
+				ADD COLUMN ec DOUBLE NULL,
+				ADD COLUMN cec DOUBLE NULL,
+				ADD COLUMN exchangeable_ca DOUBLE NULL,
+				ADD COLUMN exchangeable_mg DOUBLE NULL,
+				ADD COLUMN available_s DOUBLE NULL
+			`,
+		},
+		{
+			Name: "041_create_soil_interpretation_bands_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS soil_interpretation_bands (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				region VARCHAR(50) NOT NULL DEFAULT '',
+				property VARCHAR(30) NOT NULL,
+				very_low_max DOUBLE NOT NULL,
+				low_max DOUBLE NOT NULL,
+				medium_max DOUBLE NOT NULL,
+				high_max DOUBLE NOT NULL,
+				unit VARCHAR(20) NOT NULL DEFAULT '',
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				UNIQUE KEY uk_region_property (region, property)
+			)
+			`,
+		},
+		{
+			Name: "042_seed_soil_interpretation_bands",
+			SQL: `
+			INSERT INTO soil_interpretation_bands (region, property, very_low_max, low_max, medium_max, high_max, unit) VALUES
+			('', 'ph', 4.5, 5.5, 7.5, 8.5, ''),
+			('', 'organicMatter', 10, 20, 30, 40, 'g/kg'),
+			('', 'ec', 1, 2, 4, 8, 'dS/m'),
+			('', 'cec', 5, 10, 20, 30, 'cmol(+)/kg'),
+			('', 'ca', 200, 400, 1000, 2000, 'mg/kg'),
+			('', 'mg', 25, 50, 150, 300, 'mg/kg'),
+			('', 's', 8, 16, 30, 50, 'mg/kg'),
+			('', 'zn', 0.3, 0.5, 1, 3, 'mg/kg'),
+			('', 'b', 0.2, 0.5, 1, 2, 'mg/kg'),
+			('', 'fe', 2.5, 4.5, 10, 20, 'mg/kg'),
+			('', 'mn', 1, 5, 15, 30, 'mg/kg')
+			`,
+		},
+		{
+			Name: "043_create_crop_growth_stages_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS crop_growth_stages (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				crop_code VARCHAR(50) NOT NULL,
+				stage_code VARCHAR(30) NOT NULL,
+				stage_name VARCHAR(50) NOT NULL,
+				sequence INT NOT NULL,
+				days_after_sowing INT NOT NULL,
+				is_basal BOOLEAN NOT NULL DEFAULT FALSE,
+				n_share DOUBLE NOT NULL,
+				p2o5_share DOUBLE NOT NULL,
+				k2o_share DOUBLE NOT NULL,
+				UNIQUE KEY uk_crop_stage (crop_code, stage_code)
+			)
+			`,
+		},
+		{
+			Name: "044_seed_crop_growth_stages",
+			SQL: `
+			INSERT INTO crop_growth_stages (crop_code, stage_code, stage_name, sequence, days_after_sowing, is_basal, n_share, p2o5_share, k2o_share) VALUES
+			('wheat', 'basal', '基肥', 1, 0, TRUE, 50, 100, 60),
+			('wheat', 'jointing', '拔节期', 2, 150, FALSE, 40, 0, 40),
+			('wheat', 'heading', '抽穗期', 3, 190, FALSE, 10, 0, 0),
+			('maize', 'basal', '基肥', 1, 0, TRUE, 40, 100, 60),
+			('maize', 'jointing', '拔节期', 2, 30, FALSE, 20, 0, 40),
+			('maize', 'bell', '大喇叭口期', 3, 45, FALSE, 40, 0, 0),
+			('rice', 'basal', '基肥', 1, 0, TRUE, 40, 100, 60),
+			('rice', 'tillering', '分蘖期', 2, 15, FALSE, 30, 0, 0),
+			('rice', 'panicle', '穗分化期', 3, 40, FALSE, 30, 0, 40),
+			('soybean', 'basal', '基肥', 1, 0, TRUE, 70, 100, 100),
+			('soybean', 'flowering', '初花期', 2, 45, FALSE, 30, 0, 0),
+			('cotton', 'basal', '基肥', 1, 0, TRUE, 40, 100, 50),
+			('cotton', 'boll', '花铃期', 2, 60, FALSE, 60, 0, 50),
+			('potato', 'basal', '基肥', 1, 0, TRUE, 60, 100, 50),
+			('potato', 'tuber', '块茎膨大期', 2, 40, FALSE, 40, 0, 50),
+			('tomato', 'basal', '基肥', 1, 0, TRUE, 30, 60, 30),
+			('tomato', 'fruit1', '第一穗果膨大期', 2, 50, FALSE, 35, 20, 35),
+			('tomato', 'fruit2', '第二、三穗果膨大期', 3, 80, FALSE, 35, 20, 35),
+			('cucumber', 'basal', '基肥', 1, 0, TRUE, 30, 60, 30),
+			('cucumber', 'early_fruit', '初瓜期', 2, 35, FALSE, 35, 20, 35),
+			('cucumber', 'peak_fruit', '盛瓜期', 3, 55, FALSE, 35, 20, 35),
+			('pepper', 'basal', '基肥', 1, 0, TRUE, 30, 60, 30),
+			('pepper', 'first_fruit', '门椒膨大期', 2, 45, FALSE, 35, 20, 35),
+			('pepper', 'peak_fruit', '盛果期', 3, 75, FALSE, 35, 20, 35),
+			('cabbage', 'basal', '基肥', 1, 0, TRUE, 40, 100, 50),
+			('cabbage', 'rosette', '莲座期', 2, 25, FALSE, 30, 0, 20),
+			('cabbage', 'heading', '结球期', 3, 45, FALSE, 30, 0, 30)
+			`,
+		},
+		{
+			Name: "045_create_fertilization_plans_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS fertilization_plans (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				user_id INT NOT NULL,
+				record_id INT NULL,
+				name VARCHAR(100) NOT NULL,
+				crop VARCHAR(50) NOT NULL,
+				season VARCHAR(50),
+				sowing_date DATE NOT NULL,
+				plot_size DOUBLE NOT NULL DEFAULT 0,
+				target_n DOUBLE NOT NULL DEFAULT 0,
+				target_p2o5 DOUBLE NOT NULL DEFAULT 0,
+				target_k2o DOUBLE NOT NULL DEFAULT 0,
+				status VARCHAR(20) NOT NULL DEFAULT 'active',
+				notes TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				INDEX idx_user_status (user_id, status)
+			)
+			`,
+		},
+		{
+			Name: "046_create_fertilization_plan_items_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS fertilization_plan_items (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				plan_id INT NOT NULL,
+				stage_code VARCHAR(30) NOT NULL,
+				stage_name VARCHAR(50) NOT NULL,
+				sequence INT NOT NULL,
+				planned_date DATE NOT NULL,
+				product_id INT NULL,
+				product_name VARCHAR(100),
+				dose DOUBLE NOT NULL DEFAULT 0,
+				n DOUBLE NOT NULL DEFAULT 0,
+				p2o5 DOUBLE NOT NULL DEFAULT 0,
+				k2o DOUBLE NOT NULL DEFAULT 0,
+				INDEX idx_plan_date (plan_id, planned_date)
+			)
+			`,
+		},
+		{
+			Name: "047_create_fertilization_applications_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS fertilization_applications (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				plan_id INT NOT NULL,
+				item_id INT NULL,
+				applied_at DATE NOT NULL,
+				product_id INT NULL,
+				product_name VARCHAR(100),
+				dose DOUBLE NOT NULL,
+				n DOUBLE NOT NULL DEFAULT 0,
+				p2o5 DOUBLE NOT NULL DEFAULT 0,
+				k2o DOUBLE NOT NULL DEFAULT 0,
+				note VARCHAR(500),
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				INDEX idx_plan_applied (plan_id, applied_at)
+			)
+			`,
+		},
+		{
+			Name: "048_create_farms_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS farms (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				user_id INT NOT NULL,
+				name VARCHAR(100) NOT NULL,
+				location VARCHAR(255),
+				area DOUBLE NOT NULL DEFAULT 0,
+				notes TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				INDEX idx_user_id (user_id)
+			)
+			`,
+		},
+		{
+			Name: "049_create_plots_table",
+			SQL: `
+			CREATE TABLE IF NOT EXISTS plots (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				user_id INT NOT NULL,
+				farm_id INT NOT NULL,
+				name VARCHAR(100) NOT NULL,
+				area DOUBLE NOT NULL DEFAULT 0,
+				boundary LONGTEXT,
+				soil_type VARCHAR(100),
+				current_crop VARCHAR(50),
+				notes TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				INDEX idx_user_farm (user_id, farm_id)
+			)
+			`,
+		},
+		{
+			Name: "050_add_plot_id_to_records",
+			SQL: `
+			ALTER TABLE records
+				ADD COLUMN plot_id INT NULL,
+				ADD INDEX idx_plot_id (plot_id),
+				ADD CONSTRAINT fk_records_plot FOREIGN KEY (plot_id) REFERENCES plots(id) ON DELETE SET NULL
+			`,
+		},
+		{
+			Name: "051_add_plot_id_to_water_records",
+			SQL: `
+			ALTER TABLE water_records
+				ADD COLUMN plot_id INT NULL,
+				ADD INDEX idx_plot_id (plot_id),
+				ADD CONSTRAINT fk_water_records_plot FOREIGN KEY (plot_id) REFERENCES plots(id) ON DELETE SET NULL
+			`,
+		},
+		{
+			Name: "052_add_plot_id_to_compost_history",
+			SQL: `
+			ALTER TABLE compost_history
+				ADD COLUMN plot_id INT NULL,
+				ADD INDEX idx_plot_id (plot_id),
+				ADD CONSTRAINT fk_compost_history_plot FOREIGN KEY (plot_id) REFERENCES plots(id) ON DELETE SET NULL
+			`,
+		},
+		{
+			Name: "053_add_plot_id_to_fertilization_plans",
+			SQL: `
+			ALTER TABLE fertilization_plans
+				ADD COLUMN plot_id INT NULL,
+				ADD INDEX idx_plot_id (plot_id),
+				ADD CONSTRAINT fk_fertilization_plans_plot FOREIGN KEY (plot_id) REFERENCES plots(id) ON DELETE SET NULL
+			`,
+		},
+		{
+			Name: "054_add_geometry_to_plots",
+			SQL: `
+			ALTER TABLE plots
+				ADD COLUMN centroid_lng DOUBLE NULL,
+				ADD COLUMN centroid_lat DOUBLE NULL,
+				ADD COLUMN min_lng DOUBLE NULL,
+				ADD COLUMN min_lat DOUBLE NULL,
+				ADD COLUMN max_lng DOUBLE NULL,
+				ADD COLUMN max_lat DOUBLE NULL,
+				ADD INDEX idx_user_min_lng (user_id, min_lng)
+			`,
+		},
+		{
+			Name: "055_add_plot_time_index_to_records",
+			SQL: `
+			ALTER TABLE records
+				ADD INDEX idx_user_plot_timestamp (user_id, plot_id, timestamp)
+			`,
+		},
+		{
+			Name: "056_add_plot_time_index_to_water_records",
+			SQL: `
+			ALTER TABLE water_records
+				ADD INDEX idx_user_plot_created (user_id, plot_id, created_at)
+			`,
+		},
+		{
+			Name: "057_add_plot_time_index_to_compost_history",
+			SQL: `
+			ALTER TABLE compost_history
+				ADD INDEX idx_user_plot_created (user_id, plot_id, created_at)
+			`,
+		},
+		{
+			Name: "058_add_user_time_index_to_records",
+			SQL: `
+			ALTER TABLE records
+				ADD INDEX idx_user_timestamp (user_id, timestamp)
+			`,
+		},
+		{
+			Name: "059_add_user_time_index_to_water_records",
+			SQL: `
+			ALTER TABLE water_records
+				ADD INDEX idx_user_created (user_id, created_at)
+			`,
+		},
+		{
+			Name: "060_add_user_time_index_to_compost_history",
+			SQL: `
+			ALTER TABLE compost_history
+				ADD INDEX idx_user_created (user_id, created_at)
+			`,
+		},
 	}
 }
 
@@ -0,0 +1,323 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+)
+
+// AlertController 处理灌溉告警规则与告警收件箱相关的请求
+type AlertController struct {
+	DB *sql.DB
+}
+
+// NewAlertController 创建一个新的AlertController实例
+func NewAlertController(db *sql.DB) *AlertController {
+	return &AlertController{DB: db}
+}
+
+// GetAlertRules 获取当前用户的告警规则
+func (c *AlertController) GetAlertRules(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+
+	where := "user_id = ?"
+	params := []interface{}{userID}
+	if areaID := ctx.Query("areaId"); areaID != "" {
+		where += " AND area_id = ?"
+		params = append(params, areaID)
+	}
+
+	rules, err := queryAlertRules(c.DB, where+" ORDER BY area_id, id", params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for alert rules"})
+		return
+	}
+	if rules == nil {
+		rules = []models.AlertRule{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": rules,
+	})
+}
+
+// CreateAlertRule 创建告警规则
+func (c *AlertController) CreateAlertRule(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var rule models.AlertRule
+	if err := ctx.ShouldBindJSON(&rule); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := normalizeAlertRule(&rule); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule.IsActive = true
+
+	result, err := c.DB.Exec(`
+		INSERT INTO alert_rules (user_id, name, area_id, rule_type, threshold, channels, webhook_url, phone, cooldown_minutes, is_active)
+		VALUES (?,?,?,?,?,?,?,?,?,?)
+	`, userID, rule.Name, rule.AreaID, rule.RuleType, rule.Threshold, strings.Join(rule.Channels, ","),
+		rule.WebhookURL, rule.Phone, rule.CooldownMinutes, rule.IsActive)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	rule.ID = int(id)
+	rule.UserID = userID
+	rule.CreatedAt = time.Now().Format(dateTimeLayout)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": rule,
+	})
+}
+
+// UpdateAlertRule 更新告警规则，可通过 isActive 停用规则，未提供 isActive 时保持原状态
+func (c *AlertController) UpdateAlertRule(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req struct {
+		models.AlertRule
+		IsActive *bool `json:"isActive"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule := req.AlertRule
+	if err := normalizeAlertRule(&rule); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, err := scanAlertRule(c.DB.QueryRow("SELECT "+alertRuleColumns+" FROM alert_rules WHERE id = ? AND user_id = ?", id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	rule.IsActive = current.IsActive
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	_, err = c.DB.Exec(`
+		UPDATE alert_rules
+		SET name = ?, area_id = ?, rule_type = ?, threshold = ?, channels = ?, webhook_url = ?, phone = ?, cooldown_minutes = ?, is_active = ?
+		WHERE id = ? AND user_id = ?
+	`, rule.Name, rule.AreaID, rule.RuleType, rule.Threshold, strings.Join(rule.Channels, ","), rule.WebhookURL, rule.Phone,
+		rule.CooldownMinutes, rule.IsActive, id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 告警条件或启用状态变更后，已有的未恢复事件不再适用；只修改名称、通知渠道等时保留
+	if rule.Threshold != current.Threshold || rule.RuleType != current.RuleType || rule.AreaID != current.AreaID ||
+		rule.IsActive != current.IsActive {
+		if err := resolveAlert(c.DB, id, time.Now()); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	updated, err := scanAlertRule(c.DB.QueryRow("SELECT "+alertRuleColumns+" FROM alert_rules WHERE id = ?", id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": updated,
+	})
+}
+
+// DeleteAlertRule 删除告警规则及其告警事件
+func (c *AlertController) DeleteAlertRule(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	result, err := c.DB.Exec("DELETE FROM alert_rules WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// GetAlertInbox 告警收件箱，按最近触发时间倒序，可按状态与未读筛选
+func (c *AlertController) GetAlertInbox(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "20"))
+
+	where := " WHERE e.user_id = ?"
+	params := []interface{}{userID}
+	if status := ctx.Query("status"); status != "" {
+		where += " AND e.status = ?"
+		params = append(params, status)
+	}
+	if ctx.Query("unread") == "true" {
+		where += " AND e.is_read = FALSE"
+	}
+
+	var totalCount, unreadCount int
+	if err := c.DB.QueryRow("SELECT COUNT(*) FROM alert_events e"+where, params...).Scan(&totalCount); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting total count"})
+		return
+	}
+	if err := c.DB.QueryRow("SELECT COUNT(*) FROM alert_events WHERE user_id = ? AND is_read = FALSE", userID).Scan(&unreadCount); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting unread count"})
+		return
+	}
+
+	query := `
+		SELECT e.id, e.rule_id, r.name, e.area_id, e.rule_type, e.value, e.message, e.status, e.is_read, e.occurrences,
+			e.first_triggered_at, e.last_triggered_at, e.resolved_at
+		FROM alert_events e JOIN alert_rules r ON e.rule_id = r.id` + where + `
+		ORDER BY e.last_triggered_at DESC, e.id DESC LIMIT ? OFFSET ?`
+	params = append(params, pageSize, (page-1)*pageSize)
+
+	rows, err := c.DB.Query(query, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for alert events"})
+		return
+	}
+	defer rows.Close()
+
+	events := []models.AlertEvent{}
+	for rows.Next() {
+		var event models.AlertEvent
+		var ruleName sql.NullString
+		var value sql.NullFloat64
+		var firstTriggeredAt, lastTriggeredAt time.Time
+		var resolvedAt sql.NullTime
+		err := rows.Scan(&event.ID, &event.RuleID, &ruleName, &event.AreaID, &event.RuleType, &value, &event.Message,
+			&event.Status, &event.IsRead, &event.Occurrences, &firstTriggeredAt, &lastTriggeredAt, &resolvedAt)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning alert event row"})
+			return
+		}
+		event.RuleName = ruleName.String
+		event.Value = nullFloatPtr(value)
+		event.FirstTriggeredAt = firstTriggeredAt.Format(dateTimeLayout)
+		event.LastTriggeredAt = lastTriggeredAt.Format(dateTimeLayout)
+		if resolvedAt.Valid {
+			event.ResolvedAt = resolvedAt.Time.Format(dateTimeLayout)
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating alert event rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":        200,
+		"msg":         "ok",
+		"data":        events,
+		"unreadCount": unreadCount,
+		"totalCount":  totalCount,
+		"currentPage": page,
+		"pageSize":    pageSize,
+	})
+}
+
+// MarkAlertsRead 将告警事件标记为已读，ids 为空时标记全部
+func (c *AlertController) MarkAlertsRead(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := "UPDATE alert_events SET is_read = TRUE WHERE user_id = ?"
+	params := []interface{}{userID}
+	if len(req.IDs) > 0 {
+		query += " AND id IN (?" + strings.Repeat(",?", len(req.IDs)-1) + ")"
+		for _, id := range req.IDs {
+			params = append(params, id)
+		}
+	}
+
+	if _, err := c.DB.Exec(query, params...); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// normalizeAlertRule 校验告警规则并补全默认通知渠道
+func normalizeAlertRule(rule *models.AlertRule) error {
+	if len(rule.Channels) == 0 {
+		rule.Channels = []string{models.AlertChannelLog}
+	}
+	for _, channel := range rule.Channels {
+		switch channel {
+		case models.AlertChannelWebhook:
+			if err := validateWebhookURL(rule.WebhookURL); err != nil {
+				return err
+			}
+		case models.AlertChannelSMS:
+			if !isValidPhone(rule.Phone) {
+				return errors.New("phone is invalid")
+			}
+		}
+	}
+	if rule.RuleType != models.AlertNoData && rule.Threshold > 100 {
+		return errors.New("moisture threshold must be between 0 and 100")
+	}
+	if rule.CooldownMinutes < 0 {
+		return errors.New("cooldownMinutes must not be negative")
+	}
+	return nil
+}
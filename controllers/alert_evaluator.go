@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go-mengtuobang/models"
+)
+
+const alertRuleColumns = "id, user_id, name, area_id, rule_type, threshold, channels, webhook_url, phone, cooldown_minutes, is_active, created_at"
+
+// scanAlertRule 扫描一行告警规则
+func scanAlertRule(scanner interface{ Scan(...interface{}) error }) (models.AlertRule, error) {
+	var rule models.AlertRule
+	var name, webhookURL, phone sql.NullString
+	var channels string
+	var createdAt time.Time
+	err := scanner.Scan(&rule.ID, &rule.UserID, &name, &rule.AreaID, &rule.RuleType, &rule.Threshold, &channels,
+		&webhookURL, &phone, &rule.CooldownMinutes, &rule.IsActive, &createdAt)
+	if err != nil {
+		return rule, err
+	}
+	rule.Name = name.String
+	rule.WebhookURL = webhookURL.String
+	rule.Phone = phone.String
+	rule.Channels = strings.Split(channels, ",")
+	rule.CreatedAt = createdAt.Format(dateTimeLayout)
+	return rule, nil
+}
+
+// queryAlertRules 查询告警规则
+func queryAlertRules(db *sql.DB, where string, params ...interface{}) ([]models.AlertRule, error) {
+	rows, err := db.Query("SELECT "+alertRuleColumns+" FROM alert_rules WHERE "+where, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.AlertRule
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// evaluateMoistureAlerts 按区域最新含水量评估用户的含水量告警规则，latest 为区域 ID 到含水量（%）的映射
+func evaluateMoistureAlerts(db *sql.DB, userID int, latest map[int]float64) {
+	rules, err := queryAlertRules(db, "user_id = ? AND is_active = TRUE AND rule_type IN (?, ?)",
+		userID, models.AlertBelowRefill, models.AlertAboveFieldCapacity)
+	if err != nil {
+		log.Printf("Failed to query alert rules of user %d: %v", userID, err)
+		return
+	}
+
+	now := time.Now()
+	for _, rule := range rules {
+		moisture, ok := latest[rule.AreaID]
+		if !ok {
+			continue
+		}
+
+		var triggered bool
+		var message string
+		switch rule.RuleType {
+		case models.AlertBelowRefill:
+			triggered = moisture < rule.Threshold
+			message = fmt.Sprintf("区域 %d 土壤含水量 %.1f%% 低于补水点 %.1f%%，请及时灌溉", rule.AreaID, moisture, rule.Threshold)
+		case models.AlertAboveFieldCapacity:
+			triggered = moisture > rule.Threshold
+			message = fmt.Sprintf("区域 %d 土壤含水量 %.1f%% 高于田间持水量 %.1f%%，请停止灌溉并注意排水", rule.AreaID, moisture, rule.Threshold)
+		}
+
+		if triggered {
+			err = triggerAlert(db, rule, &moisture, message, now)
+		} else {
+			err = resolveAlert(db, rule.ID, now)
+		}
+		if err != nil {
+			log.Printf("Failed to evaluate alert rule %d: %v", rule.ID, err)
+		}
+	}
+}
+
+// checkNoDataAlerts 检查所有无数据告警规则，区域超过阈值小时数没有新读数时触发告警
+func checkNoDataAlerts(db *sql.DB, now time.Time) {
+	rules, err := queryAlertRules(db, "is_active = TRUE AND rule_type = ?", models.AlertNoData)
+	if err != nil {
+		log.Printf("Failed to query no-data alert rules: %v", err)
+		return
+	}
+
+	for _, rule := range rules {
+		since := now.Add(-time.Duration(rule.Threshold * float64(time.Hour)))
+		var count int
+		err := db.QueryRow(
+			"SELECT COUNT(*) FROM sensor_readings WHERE user_id = ? AND area_id = ? AND recorded_at >= ?",
+			rule.UserID, rule.AreaID, since.Format(dateTimeLayout),
+		).Scan(&count)
+		if err != nil {
+			log.Printf("Failed to evaluate alert rule %d: %v", rule.ID, err)
+			continue
+		}
+
+		if count == 0 {
+			message := fmt.Sprintf("区域 %d 已超过 %.0f 小时没有收到传感器数据，请检查设备", rule.AreaID, rule.Threshold)
+			err = triggerAlert(db, rule, nil, message, now)
+		} else {
+			err = resolveAlert(db, rule.ID, now)
+		}
+		if err != nil {
+			log.Printf("Failed to evaluate alert rule %d: %v", rule.ID, err)
+		}
+	}
+}
+
+// StartNoDataAlertChecker 启动后台任务，按 interval 周期检查无数据告警
+func StartNoDataAlertChecker(db *sql.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			checkNoDataAlerts(db, now)
+		}
+	}()
+}
+
+// triggerAlert 触发告警：规则没有未恢复的事件时新建事件并通知，否则合并到已有事件，
+// 仅当设置了冷却时间且距上次通知已超过冷却时间时再次通知
+func triggerAlert(db *sql.DB, rule models.AlertRule, value *float64, message string, now time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	nowStr := now.Format(dateTimeLayout)
+	event := models.AlertEvent{
+		RuleID:          rule.ID,
+		RuleName:        rule.Name,
+		AreaID:          rule.AreaID,
+		RuleType:        rule.RuleType,
+		Value:           value,
+		Message:         message,
+		Status:          models.AlertStatusOpen,
+		LastTriggeredAt: nowStr,
+	}
+
+	var firstTriggeredAt time.Time
+	err = tx.QueryRow(
+		"SELECT id, occurrences, first_triggered_at FROM alert_events WHERE rule_id = ? AND status = ? FOR UPDATE",
+		rule.ID, models.AlertStatusOpen,
+	).Scan(&event.ID, &event.Occurrences, &firstTriggeredAt)
+
+	notify := false
+	switch {
+	case err == sql.ErrNoRows:
+		event.Occurrences = 1
+		event.FirstTriggeredAt = nowStr
+		result, err := tx.Exec(`
+			INSERT INTO alert_events (rule_id, user_id, area_id, rule_type, value, message, status,
+				first_triggered_at, last_triggered_at, last_notified_at)
+			VALUES (?,?,?,?,?,?,?,?,?,?)
+		`, rule.ID, rule.UserID, rule.AreaID, rule.RuleType, value, message, event.Status, nowStr, nowStr, nowStr)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		event.ID = int(id)
+		notify = true
+	case err != nil:
+		tx.Rollback()
+		return err
+	default:
+		event.Occurrences++
+		event.FirstTriggeredAt = firstTriggeredAt.Format(dateTimeLayout)
+		if rule.CooldownMinutes > 0 {
+			cooldownSince := now.Add(-time.Duration(rule.CooldownMinutes) * time.Minute).Format(dateTimeLayout)
+			result, err := tx.Exec(
+				"UPDATE alert_events SET last_notified_at = ? WHERE id = ? AND (last_notified_at IS NULL OR last_notified_at <= ?)",
+				nowStr, event.ID, cooldownSince,
+			)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			affected, _ := result.RowsAffected()
+			notify = affected > 0
+		}
+		_, err = tx.Exec(
+			"UPDATE alert_events SET occurrences = occurrences + 1, value = ?, message = ?, last_triggered_at = ?, is_read = FALSE WHERE id = ?",
+			value, message, nowStr, event.ID,
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	if notify {
+		notifyAlert(rule, event)
+	}
+	return nil
+}
+
+// resolveAlert 条件恢复后关闭规则未恢复的告警事件
+func resolveAlert(db *sql.DB, ruleID int, now time.Time) error {
+	_, err := db.Exec(
+		"UPDATE alert_events SET status = ?, resolved_at = ? WHERE rule_id = ? AND status = ?",
+		models.AlertStatusResolved, now.Format(dateTimeLayout), ruleID, models.AlertStatusOpen,
+	)
+	return err
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"go-mengtuobang/models"
+)
+
+// AlertNotifier 告警通知渠道
+type AlertNotifier interface {
+	Notify(rule models.AlertRule, event models.AlertEvent) error
+}
+
+// alertNotifiers 按渠道名称注册的通知实现，本地开发时可通过 RegisterAlertNotifier 替换为桩实现
+var alertNotifiers = map[string]AlertNotifier{
+	models.AlertChannelLog:     logNotifier{},
+	models.AlertChannelWebhook: webhookNotifier{Client: newWebhookClient()},
+	models.AlertChannelSMS:     smsNotifier{},
+}
+
+// RegisterAlertNotifier 注册或替换告警通知渠道
+func RegisterAlertNotifier(channel string, notifier AlertNotifier) {
+	alertNotifiers[channel] = notifier
+}
+
+// logNotifier 将告警写入日志
+type logNotifier struct{}
+
+// Notify 写入日志
+func (logNotifier) Notify(rule models.AlertRule, event models.AlertEvent) error {
+	log.Printf("Alert rule %d (user %d, area %d): %s", rule.ID, rule.UserID, event.AreaID, event.Message)
+	return nil
+}
+
+// nonPublicNetworks 不属于 net.IP 内置分类、但同样不应由 webhook 访问的地址段
+var nonPublicNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),     // 本网络
+	mustParseCIDR("100.64.0.0/10"), // 运营商级 NAT
+	mustParseCIDR("192.0.0.0/24"),  // IETF 协议分配
+	mustParseCIDR("198.18.0.0/15"), // 基准测试
+	mustParseCIDR("240.0.0.0/4"),   // 保留地址
+	mustParseCIDR("64:ff9b::/96"),  // NAT64，可映射到内网 IPv4
+}
+
+// mustParseCIDR 解析常量地址段
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// isPublicIP 判断是否为公网地址，回环、内网、链路本地（含云平台元数据地址 169.254.169.254）、组播等均不是
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateWebhookURL 保存规则时校验 webhook 地址：须为 http(s)，主机解析出的地址须全部为公网地址
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("webhookUrl must be an http or https url")
+	}
+	if u.User != nil {
+		return errors.New("webhookUrl must not contain credentials")
+	}
+
+	host := u.Hostname()
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil || len(addrs) == 0 {
+			return fmt.Errorf("webhookUrl host %s cannot be resolved", host)
+		}
+		ips = ips[:0]
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	for _, ip := range ips {
+		if !isPublicIP(ip) {
+			return errors.New("webhookUrl must point to a public host")
+		}
+	}
+	return nil
+}
+
+// newWebhookClient 创建调用 webhook 的 HTTP 客户端
+//
+// 保存时的校验无法防止域名之后改为解析到内网（DNS 重绑定），因此在建立连接时再次检查实际连接的地址；
+// 不使用环境变量中的代理，不跟随重定向
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("webhook address %s is not a public address", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// webhookNotifier 以 JSON 形式将告警事件 POST 到规则配置的地址
+type webhookNotifier struct {
+	Client *http.Client
+}
+
+// Notify 调用 webhook
+func (n webhookNotifier) Notify(rule models.AlertRule, event models.AlertEvent) error {
+	if rule.WebhookURL == "" {
+		return errors.New("webhook url is not configured")
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := n.Client.Post(rule.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// smsNotifier 通过短信通道发送告警
+type smsNotifier struct{}
+
+// Notify 发送短信
+func (smsNotifier) Notify(rule models.AlertRule, event models.AlertEvent) error {
+	if rule.Phone == "" {
+		return errors.New("phone is not configured")
+	}
+	return sendSMS(rule.Phone, event.Message)
+}
+
+// notifyAlert 通过规则配置的所有渠道发送告警，单个渠道失败只记录日志
+func notifyAlert(rule models.AlertRule, event models.AlertEvent) {
+	for _, channel := range rule.Channels {
+		notifier, ok := alertNotifiers[channel]
+		if !ok {
+			log.Printf("Alert rule %d: unknown channel %q", rule.ID, channel)
+			continue
+		}
+		if err := notifier.Notify(rule, event); err != nil {
+			log.Printf("Alert rule %d: failed to notify via %s: %v", rule.ID, channel, err)
+		}
+	}
+}
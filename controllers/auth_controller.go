@@ -3,6 +3,7 @@ package controllers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go-mengtuobang/models"
 	"io"
@@ -381,9 +382,10 @@ func (c *AuthController) SendSMS(ctx *gin.Context) {
 	// 生成验证码
 	code := generateVerifyCode()
 
-	// 这里应该调用短信服务发送验证码，简化处理直接返回验证码
-	// 实际生产环境中应该调用短信API
-	fmt.Printf("短信验证码: %s (手机号: %s, 类型: %s)\n", code, req.Phone, req.Type)
+	if err := sendSMS(req.Phone, fmt.Sprintf("您的验证码为 %s（类型: %s）", code, req.Type)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "验证码发送失败"})
+		return
+	}
 
 	// 存储验证码（实际应该存储到Redis或数据库，这里简化处理）
 	c.storeVerifyCode(req.Phone, code, req.Type)
@@ -633,6 +635,27 @@ func (c *AuthController) findOrCreateWechatUser(openID, unionID, nickname, avata
 
 // 工具函数
 
+// isAdminUser 检查用户是否为管理员
+func isAdminUser(db *sql.DB, userID int) (bool, error) {
+	var role int
+	if err := db.QueryRow("SELECT role FROM users WHERE id = ?", userID).Scan(&role); err != nil {
+		return false, err
+	}
+	return role == models.RoleAdmin, nil
+}
+
+// checkAdmin 检查当前用户是否为管理员，action 描述被拒绝的操作，返回失败时对应的 HTTP 状态码
+func checkAdmin(db *sql.DB, userID int, action string) (int, error) {
+	isAdmin, err := isAdminUser(db, userID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !isAdmin {
+		return http.StatusForbidden, errors.New("only administrators can " + action)
+	}
+	return 0, nil
+}
+
 // isValidPhone 验证手机号格式
 func isValidPhone(phone string) bool {
 	pattern := `^1[3-9]\d{9}$`
@@ -645,6 +668,14 @@ func generateVerifyCode() string {
 	return fmt.Sprintf("%06d", time.Now().Unix()%1000000)
 }
 
+// sendSMS 发送短信，验证码与告警通知共用
+func sendSMS(phone, content string) error {
+	// 这里应该调用短信服务发送短信，简化处理直接打印
+	// 实际生产环境中应该调用短信API
+	fmt.Printf("发送短信: 手机号=%s, 内容=%s\n", phone, content)
+	return nil
+}
+
 // storeVerifyCode 存储验证码（简化实现，实际应使用Redis）
 func (c *AuthController) storeVerifyCode(phone, code, codeType string) {
 	// 这里应该存储到Redis，设置5分钟过期时间
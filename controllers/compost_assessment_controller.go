@@ -0,0 +1,310 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+)
+
+// CompostAssessmentController 处理堆肥腐熟度评价相关的请求
+type CompostAssessmentController struct {
+	DB *sql.DB
+}
+
+// NewCompostAssessmentController 创建一个新的CompostAssessmentController实例
+func NewCompostAssessmentController(db *sql.DB) *CompostAssessmentController {
+	return &CompostAssessmentController{DB: db}
+}
+
+const maturityStandardColumns = "id, code, name, max_cn_ratio, min_ph, max_ph, max_ec, min_germination_index, max_moisture, min_organic_matter, min_total_nutrient, require_temperature_stable, description"
+
+// 基本腐熟要求种子发芽指数不低于 50%
+const semiMatureGerminationIndex = 50.0
+
+// GetMaturityStandards 获取腐熟度评价标准列表
+func (c *CompostAssessmentController) GetMaturityStandards(ctx *gin.Context) {
+	rows, err := c.DB.Query("SELECT " + maturityStandardColumns + " FROM compost_maturity_standards ORDER BY id")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for maturity standards"})
+		return
+	}
+	defer rows.Close()
+
+	standards := []models.MaturityStandard{}
+	for rows.Next() {
+		standard, err := scanMaturityStandard(rows)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning maturity standard row"})
+			return
+		}
+		standards = append(standards, standard)
+	}
+
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating maturity standard rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": standards,
+	})
+}
+
+// SaveMaturityStandard 新增或按编码更新腐熟度评价标准（管理员功能）
+func (c *CompostAssessmentController) SaveMaturityStandard(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	if status, err := checkAdmin(c.DB, userID, "change maturity standards"); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var standard models.MaturityStandard
+	if err := ctx.ShouldBindJSON(&standard); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := c.DB.Exec(`
+		INSERT INTO compost_maturity_standards (code, name, max_cn_ratio, min_ph, max_ph, max_ec, min_germination_index,
+			max_moisture, min_organic_matter, min_total_nutrient, require_temperature_stable, description)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?)
+		ON DUPLICATE KEY UPDATE name = VALUES(name), max_cn_ratio = VALUES(max_cn_ratio), min_ph = VALUES(min_ph),
+			max_ph = VALUES(max_ph), max_ec = VALUES(max_ec), min_germination_index = VALUES(min_germination_index),
+			max_moisture = VALUES(max_moisture), min_organic_matter = VALUES(min_organic_matter),
+			min_total_nutrient = VALUES(min_total_nutrient), require_temperature_stable = VALUES(require_temperature_stable),
+			description = VALUES(description)
+	`, standard.Code, standard.Name, standard.MaxCNRatio, standard.MinPH, standard.MaxPH, standard.MaxEC, standard.MinGerminationIndex,
+		standard.MaxMoisture, standard.MinOrganicMatter, standard.MinTotalNutrient, standard.RequireTemperatureStable, standard.Description)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	saved, err := scanMaturityStandard(c.DB.QueryRow("SELECT "+maturityStandardColumns+" FROM compost_maturity_standards WHERE code = ?", standard.Code))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": saved,
+	})
+}
+
+// CreateCompostAssessment 录入实验室或田间测定值，按所选标准评价腐熟度并保存
+func (c *CompostAssessmentController) CreateCompostAssessment(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var assessment models.CompostAssessment
+	if err := ctx.ShouldBindJSON(&assessment); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 校验堆肥记录归属
+	var historyID int
+	err := c.DB.QueryRow("SELECT id FROM compost_history WHERE id = ? AND user_id = ? AND deleted_at IS NULL", assessment.CompostHistoryID, userID).Scan(&historyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Compost history not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	standard, err := scanMaturityStandard(c.DB.QueryRow("SELECT "+maturityStandardColumns+" FROM compost_maturity_standards WHERE code = ?", assessment.StandardCode))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown maturity standard"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	evaluateMaturity(standard, &assessment)
+
+	checksJSON, err := json.Marshal(assessment.Checks)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.DB.Exec(`
+		INSERT INTO compost_assessments (compost_history_id, user_id, standard_code, final_cn_ratio, ph, ec, germination_index,
+			temperature_stable, moisture, organic_matter, total_nutrient, grade, passed, checks)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+	`, assessment.CompostHistoryID, userID, assessment.StandardCode, assessment.FinalCNRatio, assessment.PH, assessment.EC,
+		assessment.GerminationIndex, assessment.TemperatureStable, assessment.Moisture, assessment.OrganicMatter,
+		assessment.TotalNutrient, assessment.Grade, assessment.Passed, checksJSON)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	assessment.ID = int(id)
+	assessment.CreatedAt = time.Now().Format(dateTimeLayout)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": assessment,
+	})
+}
+
+// evaluateMaturity 按标准逐项评价并给出腐熟度等级
+func evaluateMaturity(standard models.MaturityStandard, assessment *models.CompostAssessment) {
+	var checks []models.MaturityCheck
+	addCheck := func(item string, value *float64, requirement string, ok func(float64) bool) {
+		check := models.MaturityCheck{Item: item, Value: value, Requirement: requirement, Status: "missing"}
+		if value != nil {
+			check.Status = "fail"
+			if ok(*value) {
+				check.Status = "pass"
+			}
+		}
+		checks = append(checks, check)
+	}
+	addMax := func(item string, value, max *float64) {
+		if max != nil {
+			addCheck(item, value, fmt.Sprintf("<= %g", *max), func(v float64) bool { return v <= *max })
+		}
+	}
+	addMin := func(item string, value, min *float64) {
+		if min != nil {
+			addCheck(item, value, fmt.Sprintf(">= %g", *min), func(v float64) bool { return v >= *min })
+		}
+	}
+
+	addMax("finalCNRatio", assessment.FinalCNRatio, standard.MaxCNRatio)
+	if standard.MinPH != nil && standard.MaxPH != nil {
+		minPH, maxPH := *standard.MinPH, *standard.MaxPH
+		addCheck("ph", assessment.PH, fmt.Sprintf("%g - %g", minPH, maxPH), func(v float64) bool { return v >= minPH && v <= maxPH })
+	} else {
+		addMin("ph", assessment.PH, standard.MinPH)
+		addMax("ph", assessment.PH, standard.MaxPH)
+	}
+	addMax("ec", assessment.EC, standard.MaxEC)
+	addMin("germinationIndex", assessment.GerminationIndex, standard.MinGerminationIndex)
+	addMax("moisture", assessment.Moisture, standard.MaxMoisture)
+	addMin("organicMatter", assessment.OrganicMatter, standard.MinOrganicMatter)
+	addMin("totalNutrient", assessment.TotalNutrient, standard.MinTotalNutrient)
+	if standard.RequireTemperatureStable {
+		check := models.MaturityCheck{Item: "temperatureStable", Requirement: "stable", Status: "missing"}
+		if assessment.TemperatureStable != nil {
+			check.Status = "fail"
+			if *assessment.TemperatureStable {
+				check.Status = "pass"
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	failed, missing := 0, 0
+	for _, check := range checks {
+		switch check.Status {
+		case "fail":
+			failed++
+		case "missing":
+			missing++
+		}
+	}
+
+	switch {
+	case failed == 0 && missing == 0:
+		assessment.Grade = models.GradeMature
+	case failed == 0:
+		assessment.Grade = models.GradeUndetermined
+	case failed == 1 && assessment.GerminationIndex != nil && *assessment.GerminationIndex >= semiMatureGerminationIndex:
+		assessment.Grade = models.GradeSemiMature
+	default:
+		assessment.Grade = models.GradeImmature
+	}
+	assessment.Passed = assessment.Grade == models.GradeMature
+	assessment.Checks = checks
+}
+
+// loadLatestCompostAssessment 查询堆肥记录最近一次腐熟度评价，没有评价时返回 nil
+func loadLatestCompostAssessment(db *sql.DB, historyID int) (*models.CompostAssessment, error) {
+	var assessment models.CompostAssessment
+	var finalCN, ph, ec, gi, moisture, organicMatter, totalNutrient sql.NullFloat64
+	var temperatureStable sql.NullBool
+	var checksJSON []byte
+	var createdAt time.Time
+	err := db.QueryRow(`
+		SELECT id, compost_history_id, standard_code, final_cn_ratio, ph, ec, germination_index, temperature_stable,
+			moisture, organic_matter, total_nutrient, grade, passed, checks, created_at
+		FROM compost_assessments WHERE compost_history_id = ? ORDER BY created_at DESC, id DESC LIMIT 1
+	`, historyID).Scan(&assessment.ID, &assessment.CompostHistoryID, &assessment.StandardCode, &finalCN, &ph, &ec, &gi,
+		&temperatureStable, &moisture, &organicMatter, &totalNutrient, &assessment.Grade, &assessment.Passed, &checksJSON, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	assessment.FinalCNRatio = nullFloatPtr(finalCN)
+	assessment.PH = nullFloatPtr(ph)
+	assessment.EC = nullFloatPtr(ec)
+	assessment.GerminationIndex = nullFloatPtr(gi)
+	assessment.Moisture = nullFloatPtr(moisture)
+	assessment.OrganicMatter = nullFloatPtr(organicMatter)
+	assessment.TotalNutrient = nullFloatPtr(totalNutrient)
+	if temperatureStable.Valid {
+		assessment.TemperatureStable = &temperatureStable.Bool
+	}
+	if len(checksJSON) > 0 {
+		if err := json.Unmarshal(checksJSON, &assessment.Checks); err != nil {
+			return nil, err
+		}
+	}
+	assessment.CreatedAt = createdAt.Format(dateTimeLayout)
+	return &assessment, nil
+}
+
+// scanMaturityStandard 扫描一行腐熟度标准数据
+func scanMaturityStandard(scanner interface{ Scan(...interface{}) error }) (models.MaturityStandard, error) {
+	var standard models.MaturityStandard
+	var maxCN, minPH, maxPH, maxEC, minGI, maxMoisture, minOM, minNutrient sql.NullFloat64
+	var description sql.NullString
+	err := scanner.Scan(&standard.ID, &standard.Code, &standard.Name, &maxCN, &minPH, &maxPH, &maxEC, &minGI,
+		&maxMoisture, &minOM, &minNutrient, &standard.RequireTemperatureStable, &description)
+	if err != nil {
+		return standard, err
+	}
+	standard.MaxCNRatio = nullFloatPtr(maxCN)
+	standard.MinPH = nullFloatPtr(minPH)
+	standard.MaxPH = nullFloatPtr(maxPH)
+	standard.MaxEC = nullFloatPtr(maxEC)
+	standard.MinGerminationIndex = nullFloatPtr(minGI)
+	standard.MaxMoisture = nullFloatPtr(maxMoisture)
+	standard.MinOrganicMatter = nullFloatPtr(minOM)
+	standard.MinTotalNutrient = nullFloatPtr(minNutrient)
+	standard.Description = description.String
+	return standard, nil
+}
+
+// nullFloatPtr 将可空浮点数转换为指针，NULL 返回 nil
+func nullFloatPtr(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	f := v.Float64
+	return &f
+}
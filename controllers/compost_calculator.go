@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"go-mengtuobang/models"
+)
+
+// defaultTargetMoisture 默认目标含水率（%），好氧堆肥适宜含水率为 55%~65%
+const defaultTargetMoisture = 60.0
+
+// compostMix 混合物料的汇总数值
+type compostMix struct {
+	TotalWeight float64 // 物料总鲜重（kg）
+	TotalWater  float64 // 物料总含水量（kg）
+	TotalC      float64 // 总碳量（kg）
+	TotalN      float64 // 总氮量（kg）
+}
+
+// CNRatio 混合物料的碳氮比
+func (m compostMix) CNRatio() float64 {
+	if m.TotalN == 0 {
+		return 0
+	}
+	return m.TotalC / m.TotalN
+}
+
+// Moisture 混合物料的含水率（%）
+func (m compostMix) Moisture() float64 {
+	if m.TotalWeight == 0 {
+		return 0
+	}
+	return m.TotalWater / m.TotalWeight * 100
+}
+
+// WaterToAdd 达到目标含水率需要补充的水量（kg），物料过湿时返回 0
+func (m compostMix) WaterToAdd(targetMoisture float64) float64 {
+	if m.Moisture() >= targetMoisture {
+		return 0
+	}
+	return (targetMoisture*m.TotalWeight - 100*m.TotalWater) / (100 - targetMoisture)
+}
+
+// mixCompostSources 汇总物料，碳、氮含量按干基计算
+func mixCompostSources(sources []models.Fertilizer) (compostMix, error) {
+	var mix compostMix
+	for _, source := range sources {
+		if source.Weight < 0 || source.C < 0 || source.N < 0 {
+			return mix, fmt.Errorf("invalid values for source %q", source.Name)
+		}
+		if source.Moisture < 0 || source.Moisture >= 100 {
+			return mix, fmt.Errorf("moisture of source %q must be between 0 and 100", source.Name)
+		}
+		dryWeight := source.Weight * (100 - source.Moisture) / 100
+		mix.TotalWeight += source.Weight
+		mix.TotalWater += source.Weight * source.Moisture / 100
+		mix.TotalC += dryWeight * source.C / 100
+		mix.TotalN += dryWeight * source.N / 100
+	}
+	return mix, nil
+}
+
+// fillSourceRatios 根据碳、氮含量重新计算每种物料的碳氮比
+func fillSourceRatios(sources []models.Fertilizer) {
+	for i := range sources {
+		if sources[i].N > 0 {
+			sources[i].C_N = sources[i].C / sources[i].N
+		}
+	}
+}
+
+// calculateCompost 计算混合后的碳氮比、容重、含水率及达到目标含水率的补水量
+func calculateCompost(nitrogenSources, carbonSources []models.Fertilizer, allVolume, targetMoisture float64) (models.Result, error) {
+	if targetMoisture == 0 {
+		targetMoisture = defaultTargetMoisture
+	}
+	if targetMoisture < 0 || targetMoisture >= 100 {
+		return models.Result{}, errors.New("targetMoisture must be between 0 and 100")
+	}
+	if allVolume < 0 {
+		return models.Result{}, errors.New("allVolume must not be negative")
+	}
+
+	sources := append(append([]models.Fertilizer{}, nitrogenSources...), carbonSources...)
+	if len(sources) == 0 {
+		return models.Result{}, errors.New("at least one nitrogen or carbon source is required")
+	}
+
+	mix, err := mixCompostSources(sources)
+	if err != nil {
+		return models.Result{}, err
+	}
+	if mix.TotalWeight == 0 {
+		return models.Result{}, errors.New("total weight of sources must be greater than 0")
+	}
+	if mix.TotalN == 0 {
+		return models.Result{}, errors.New("total nitrogen of sources must be greater than 0")
+	}
+
+	waterAdd := mix.WaterToAdd(targetMoisture)
+
+	// 容重按补水后的总重量与堆体体积计算（kg/m³）
+	density := 0.0
+	if allVolume > 0 {
+		density = (mix.TotalWeight + waterAdd) / allVolume
+	}
+
+	return models.Result{
+		CNRatio:         formatFloat(mix.CNRatio()),
+		Density:         formatFloat(density),
+		MoistureContent: formatFloat(mix.Moisture()),
+		WaterAdd:        formatFloat(waterAdd),
+	}, nil
+}
+
+const (
+	// compostCarbonLoss 堆肥过程中碳的损失比例，以 CO₂ 形式释放
+	compostCarbonLoss = 0.45
+	// compostNitrogenLoss 堆肥过程中氮的损失比例，主要为氨挥发
+	compostNitrogenLoss = 0.25
+	// organicMatterFactor 有机碳换算为有机质的系数
+	organicMatterFactor = 1.724
+	// finishedCompostMoisture 成品堆肥含水率（%），NY/T 525 要求不高于 30%
+	finishedCompostMoisture = 30.0
+)
+
+// estimateCompostNutrients 根据原料估算成品堆肥的养分含量（鲜基）与当季氮素矿化率，
+// 磷、钾在堆肥过程中基本不损失；measuredFinalCN 为实测的成品碳氮比，为空时按碳氮损失估算
+func estimateCompostNutrients(sources []models.Fertilizer, measuredFinalCN *float64) *models.CompostNutrientEstimate {
+	var dryWeight, totalC, totalN, totalP, totalK float64
+	for _, source := range sources {
+		dry := source.Weight * (100 - source.Moisture) / 100
+		dryWeight += dry
+		totalC += dry * source.C / 100
+		totalN += dry * source.N / 100
+		totalP += dry * source.P2O5 / 100
+		totalK += dry * source.K2O / 100
+	}
+	if dryWeight <= 0 {
+		return nil
+	}
+
+	// 干物质损失按分解的有机质计算
+	carbonLost := totalC * compostCarbonLoss
+	finalDry := math.Max(dryWeight-carbonLost*organicMatterFactor, dryWeight*0.1)
+	finalC := totalC - carbonLost
+	finalN := totalN * (1 - compostNitrogenLoss)
+	productWeight := finalDry / (1 - finishedCompostMoisture/100)
+
+	finalCN := 0.0
+	if finalN > 0 {
+		finalCN = finalC / finalN
+	}
+	if measuredFinalCN != nil && *measuredFinalCN > 0 {
+		finalCN = *measuredFinalCN
+	}
+
+	n := finalN / productWeight * 100
+	p := totalP / productWeight * 100
+	k := totalK / productWeight * 100
+	return &models.CompostNutrientEstimate{
+		ProductWeight:      round2(productWeight),
+		Moisture:           finishedCompostMoisture,
+		N:                  round2(n),
+		P2O5:               round2(p),
+		K2O:                round2(k),
+		TotalNutrient:      round2(n + p + k),
+		FinalCNRatio:       round2(finalCN),
+		MineralizationRate: nitrogenMineralizationRate(finalCN),
+	}
+}
+
+// nitrogenMineralizationRate 成品堆肥施用当季的氮素矿化率（%），碳氮比越低矿化越快
+func nitrogenMineralizationRate(finalCN float64) float64 {
+	switch {
+	case finalCN <= 0:
+		return 0
+	case finalCN <= 10:
+		return 30
+	case finalCN <= 15:
+		return 20
+	case finalCN <= 20:
+		return 10
+	default:
+		return 5
+	}
+}
+
+// round2 数值保留两位小数
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// formatFloat 保留两位小数
+func formatFloat(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"testing"
+
+	"go-mengtuobang/models"
+)
+
+func TestCalculateCompost(t *testing.T) {
+	manure := models.Fertilizer{Name: "鸡粪", Weight: 1000, C: 30, N: 2, Moisture: 60}
+	straw := models.Fertilizer{Name: "玉米秸秆", Weight: 400, C: 45, N: 0.9, Moisture: 10}
+	tests := []struct {
+		name           string
+		nitrogen       []models.Fertilizer
+		carbon         []models.Fertilizer
+		allVolume      float64
+		targetMoisture float64
+		want           models.Result
+	}{
+		{
+			// 干基碳 120 + 162 kg、氮 8 + 3.24 kg；含水 640 kg / 1400 kg，补水 500 kg 达到 60%
+			"manure and straw with default target moisture",
+			[]models.Fertilizer{manure}, []models.Fertilizer{straw}, 10, 0,
+			models.Result{CNRatio: "25.09", Density: "190.00", MoistureContent: "45.71", WaterAdd: "500.00"},
+		},
+		{
+			"wetter than target needs no water",
+			[]models.Fertilizer{manure}, nil, 0, 55,
+			models.Result{CNRatio: "15.00", Density: "0.00", MoistureContent: "60.00", WaterAdd: "0.00"},
+		},
+		{
+			"carbon source only",
+			nil, []models.Fertilizer{straw}, 2, 50,
+			models.Result{CNRatio: "50.00", Density: "360.00", MoistureContent: "10.00", WaterAdd: "320.00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calculateCompost(tt.nitrogen, tt.carbon, tt.allVolume, tt.targetMoisture)
+			if err != nil {
+				t.Fatalf("calculateCompost() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("calculateCompost() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateCompostInvalid(t *testing.T) {
+	manure := []models.Fertilizer{{Name: "鸡粪", Weight: 1000, C: 30, N: 2, Moisture: 60}}
+	tests := []struct {
+		name           string
+		sources        []models.Fertilizer
+		allVolume      float64
+		targetMoisture float64
+	}{
+		{"target moisture 100", manure, 10, 100},
+		{"negative target moisture", manure, 10, -5},
+		{"negative volume", manure, -1, 60},
+		{"no sources", nil, 10, 60},
+		{"zero weight", []models.Fertilizer{{Name: "鸡粪", C: 30, N: 2, Moisture: 60}}, 10, 60},
+		{"no nitrogen", []models.Fertilizer{{Name: "木屑", Weight: 100, C: 50, Moisture: 20}}, 10, 60},
+		{"source moisture 100", []models.Fertilizer{{Name: "污水", Weight: 100, C: 1, N: 1, Moisture: 100}}, 10, 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := calculateCompost(tt.sources, nil, tt.allVolume, tt.targetMoisture); err == nil {
+				t.Errorf("calculateCompost() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestEstimateCompostNutrients(t *testing.T) {
+	float := func(v float64) *float64 { return &v }
+	sources := []models.Fertilizer{
+		{Name: "鸡粪", Weight: 1000, C: 30, N: 2, P2O5: 3, K2O: 2, Moisture: 60},
+		{Name: "玉米秸秆", Weight: 400, C: 45, N: 0.9, P2O5: 0.3, K2O: 1.5, Moisture: 10},
+	}
+	// 干物质 760 kg，碳 282 kg、氮 11.24 kg；损失 45% 碳折合有机质 218.78 kg，
+	// 成品干重 541.22 kg、含水 30% 时鲜重 773.18 kg，成品碳 155.1 kg、氮 8.43 kg
+	tests := []struct {
+		name       string
+		sources    []models.Fertilizer
+		measuredCN *float64
+		want       *models.CompostNutrientEstimate
+	}{
+		{
+			"estimated final C/N", sources, nil,
+			&models.CompostNutrientEstimate{
+				ProductWeight: 773.18, Moisture: 30, N: 1.09, P2O5: 1.69, K2O: 1.73,
+				TotalNutrient: 4.52, FinalCNRatio: 18.4, MineralizationRate: 10,
+			},
+		},
+		{
+			"measured final C/N overrides the estimate", sources, float(9),
+			&models.CompostNutrientEstimate{
+				ProductWeight: 773.18, Moisture: 30, N: 1.09, P2O5: 1.69, K2O: 1.73,
+				TotalNutrient: 4.52, FinalCNRatio: 9, MineralizationRate: 30,
+			},
+		},
+		{"no dry matter", []models.Fertilizer{{Name: "水", Weight: 100, Moisture: 100}}, nil, nil},
+		{"no sources", nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := estimateCompostNutrients(tt.sources, tt.measuredCN)
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("estimateCompostNutrients() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNitrogenMineralizationRate(t *testing.T) {
+	tests := []struct {
+		finalCN float64
+		want    float64
+	}{
+		{0, 0},
+		{-1, 0},
+		{8, 30},
+		{10, 30},
+		{12, 20},
+		{15, 20},
+		{18, 10},
+		{20, 10},
+		{25, 5},
+	}
+	for _, tt := range tests {
+		if got := nitrogenMineralizationRate(tt.finalCN); got != tt.want {
+			t.Errorf("nitrogenMineralizationRate(%v) = %v, want %v", tt.finalCN, got, tt.want)
+		}
+	}
+}
@@ -2,9 +2,10 @@ package controllers
 
 import (
 	"database/sql"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -30,6 +31,11 @@ func (c *CompostController) SaveCompostRecord(ctx *gin.Context) {
 		return
 	}
 
+	if err := c.prepareCompostHistory(userID, &history); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 开始事务
 	tx, err := c.DB.Begin()
 	if err != nil {
@@ -37,11 +43,17 @@ func (c *CompostController) SaveCompostRecord(ctx *gin.Context) {
 		return
 	}
 
+	if status, err := lockPlot(tx, userID, history.PlotID); err != nil {
+		tx.Rollback()
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
 	insertHistorySQL := `
-        INSERT INTO compost_history (all_volume, cn_ratio, density, water_add, user_id)
-        VALUES (?,?,?,?,?)
+        INSERT INTO compost_history (all_volume, cn_ratio, density, water_add, target_moisture, user_id, plot_id)
+        VALUES (?,?,?,?,?,?,?)
     `
-	result, err := tx.Exec(insertHistorySQL, history.AllVolume, history.CNRatio, history.Density, history.WaterAdd, userID)
+	result, err := tx.Exec(insertHistorySQL, history.AllVolume, history.CNRatio, history.Density, history.WaterAdd, history.TargetMoisture, userID, nullableID(history.PlotID))
 	if err != nil {
 		tx.Rollback()
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -56,44 +68,379 @@ func (c *CompostController) SaveCompostRecord(ctx *gin.Context) {
 	}
 	history.ID = int(id)
 
-	insertSourceSQL := `
-        INSERT INTO compost_history_sources (compost_history_id, source_type, source_name, c_content, n_content, moisture_content, c_n_ratio, weight)
-        VALUES (?,?,?,?,?,?,?,?)
+	if err := insertCompostSources(tx, history); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 提交事务
+	if err := tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated,
+		gin.H{
+			"code": 200,
+			"msg":  "ok",
+			"data": history,
+		})
+}
+
+// UpdateCompostRecord 更新堆肥记录，氮源、碳源整体替换
+func (c *CompostController) UpdateCompostRecord(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Query("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var history models.CompostHistory
+	if err := ctx.ShouldBindJSON(&history); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.prepareCompostHistory(userID, &history); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 开启事务
+	tx, err := c.DB.Begin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if status, err := lockPlot(tx, userID, history.PlotID); err != nil {
+		tx.Rollback()
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 锁定记录并校验归属
+	var createdAt string
+	err = tx.QueryRow("SELECT created_at FROM compost_history WHERE id = ? AND user_id = ? AND deleted_at IS NULL FOR UPDATE", id, userID).Scan(&createdAt)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Compost history not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	updateSQL := `
+        UPDATE compost_history
+        SET all_volume = ?, cn_ratio = ?, density = ?, water_add = ?, target_moisture = ?, plot_id = ?
+        WHERE id = ? AND user_id = ?
     `
+	_, err = tx.Exec(updateSQL, history.AllVolume, history.CNRatio, history.Density, history.WaterAdd, history.TargetMoisture, nullableID(history.PlotID), id, userID)
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	// 插入氮源信息
-	for _, source := range history.NitrogenSourcesList {
-		fmt.Print(source)
-		_, err := tx.Exec(insertSourceSQL, history.ID, "nitrogen", source.Name, source.C, source.N, source.Moisture, source.C_N, source.Weight)
+	// 先删除原有的氮源、碳源，再插入新的
+	if _, err = tx.Exec("DELETE FROM compost_history_sources WHERE compost_history_id = ?", id); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	history.ID = id
+	if err := insertCompostSources(tx, history); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 提交事务
+	if err := tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	history.UserID = userID
+	history.CreatedAt = createdAt
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": history,
+	})
+}
+
+// DeleteCompostRecord 删除堆肥记录，默认软删除可恢复，permanent=true 时连同氮源、碳源、堆体与腐熟度评价彻底删除
+func (c *CompostController) DeleteCompostRecord(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Query("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if ctx.Query("permanent") != "true" {
+		result, err := c.DB.Exec("UPDATE compost_history SET deleted_at = NOW() WHERE id = ? AND user_id = ? AND deleted_at IS NULL", id, userID)
 		if err != nil {
-			tx.Rollback()
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Compost history not found"})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": 200,
+			"msg":  "ok",
+		})
+		return
 	}
 
-	// 插入碳源信息
-	for _, source := range history.CarbonSourcesList {
-		_, err := tx.Exec(insertSourceSQL, history.ID, "carbon", source.Name, source.C, source.N, source.Moisture, source.C_N, source.Weight)
-		if err != nil {
+	// 开启事务
+	tx, err := c.DB.Begin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var exists int
+	err = tx.QueryRow("SELECT id FROM compost_history WHERE id = ? AND user_id = ? FOR UPDATE", id, userID).Scan(&exists)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Compost history not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// 先删除关联的源信息、堆体及其日志与腐熟度评价，避免留下指向已删除记录的数据
+	for _, query := range []string{
+		"DELETE FROM compost_history_sources WHERE compost_history_id = ?",
+		"DELETE FROM compost_pile_logs WHERE pile_id IN (SELECT id FROM compost_piles WHERE compost_history_id = ?)",
+		"DELETE FROM compost_piles WHERE compost_history_id = ?",
+		"DELETE FROM compost_assessments WHERE compost_history_id = ?",
+	} {
+		if _, err = tx.Exec(query, id); err != nil {
 			tx.Rollback()
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 	}
 
+	// 删除主表记录
+	if _, err = tx.Exec("DELETE FROM compost_history WHERE id = ? AND user_id = ?", id, userID); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 提交事务
 	if err := tx.Commit(); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusCreated,
-		gin.H{
-			"code": 200,
-			"msg":  "ok",
-			"data": history,
-		})
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// RestoreCompostRecord 恢复被软删除的堆肥记录
+func (c *CompostController) RestoreCompostRecord(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Query("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	result, err := c.DB.Exec("UPDATE compost_history SET deleted_at = NULL WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Deleted compost history not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// prepareCompostHistory 解析物料库引用并以服务端计算结果覆盖碳氮比、容重与补水量，保证各端数值一致
+func (c *CompostController) prepareCompostHistory(userID int, history *models.CompostHistory) error {
+	if history.PlotID > 0 {
+		if _, err := loadPlot(c.DB, userID, history.PlotID); err != nil {
+			if err == sql.ErrNoRows {
+				return errors.New("plot not found")
+			}
+			return err
+		}
+	}
+	if err := resolveCompostMaterials(c.DB, userID, history.NitrogenSourcesList); err != nil {
+		return err
+	}
+	if err := resolveCompostMaterials(c.DB, userID, history.CarbonSourcesList); err != nil {
+		return err
+	}
+
+	if history.TargetMoisture == 0 {
+		history.TargetMoisture = defaultTargetMoisture
+	}
+	fillSourceRatios(history.NitrogenSourcesList)
+	fillSourceRatios(history.CarbonSourcesList)
+	result, err := calculateCompost(history.NitrogenSourcesList, history.CarbonSourcesList, history.AllVolume, history.TargetMoisture)
+	if err != nil {
+		return err
+	}
+	history.CNRatio = result.CNRatio
+	history.Density = result.Density
+	history.WaterAdd = result.WaterAdd
+	return nil
+}
+
+// insertCompostSources 插入堆肥记录的氮源与碳源
+func insertCompostSources(tx *sql.Tx, history models.CompostHistory) error {
+	insertSourceSQL := `
+        INSERT INTO compost_history_sources (compost_history_id, source_type, source_name, c_content, n_content, p2o5_content, k2o_content, moisture_content, c_n_ratio, weight, material_id)
+        VALUES (?,?,?,?,?,?,?,?,?,?,?)
+    `
+
+	// 插入氮源信息
+	for _, source := range history.NitrogenSourcesList {
+		_, err := tx.Exec(insertSourceSQL, history.ID, "nitrogen", source.Name, source.C, source.N, source.P2O5, source.K2O, source.Moisture, source.C_N, source.Weight, nullableID(source.MaterialID))
+		if err != nil {
+			return err
+		}
+	}
+
+	// 插入碳源信息
+	for _, source := range history.CarbonSourcesList {
+		_, err := tx.Exec(insertSourceSQL, history.ID, "carbon", source.Name, source.C, source.N, source.P2O5, source.K2O, source.Moisture, source.C_N, source.Weight, nullableID(source.MaterialID))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadCompostSources 查询堆肥记录的氮源与碳源
+func loadCompostSources(db *sql.DB, history *models.CompostHistory) error {
+	return attachCompostSources(db, map[int]*models.CompostHistory{history.ID: history})
+}
+
+// attachCompostSources 批量查询多个堆肥记录的氮源与碳源，histories 按记录 ID 索引
+func attachCompostSources(db *sql.DB, histories map[int]*models.CompostHistory) error {
+	if len(histories) == 0 {
+		return nil
+	}
+	ids := make([]interface{}, 0, len(histories))
+	for id := range histories {
+		ids = append(ids, id)
+	}
+	rows, err := db.Query(`
+		SELECT compost_history_id, source_type, source_name, c_content, n_content, p2o5_content, k2o_content,
+			moisture_content, c_n_ratio, weight, material_id
+		FROM compost_history_sources
+		WHERE compost_history_id IN (?`+strings.Repeat(",?", len(ids)-1)+`)
+	`, ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var historyID int
+		var sourceType string
+		var source models.Fertilizer
+		var materialID sql.NullInt64
+		err := rows.Scan(&historyID, &sourceType, &source.Name, &source.C, &source.N, &source.P2O5, &source.K2O,
+			&source.Moisture, &source.C_N, &source.Weight, &materialID)
+		if err != nil {
+			return err
+		}
+		source.MaterialID = int(materialID.Int64)
+		if history, ok := histories[historyID]; ok {
+			appendCompostSource(history, sourceType, source)
+		}
+	}
+	return rows.Err()
+}
+
+// appendCompostSource 按来源类型将原料加入氮源或碳源列表
+func appendCompostSource(history *models.CompostHistory, sourceType string, source models.Fertilizer) {
+	if sourceType == "nitrogen" {
+		history.NitrogenSourcesList = append(history.NitrogenSourcesList, source)
+	} else if sourceType == "carbon" {
+		history.CarbonSourcesList = append(history.CarbonSourcesList, source)
+	}
+}
+
+// CalculateCompost 计算堆肥配比（碳氮比、容重、含水率、补水量）
+func (c *CompostController) CalculateCompost(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var req models.CompostCalculateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := resolveCompostMaterials(c.DB, userID, req.NitrogenSourcesList); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := resolveCompostMaterials(c.DB, userID, req.CarbonSourcesList); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := calculateCompost(req.NitrogenSourcesList, req.CarbonSourcesList, req.AllVolume, req.TargetMoisture)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": result,
+	})
+}
+
+// SolveCompostRecipe 根据可用物料与目标碳氮比求解各物料用量
+func (c *CompostController) SolveCompostRecipe(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var req models.CompostSolveRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := resolveCompostSolveSources(c.DB, userID, req.Sources); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := solveCompostRecipe(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": result,
+	})
 }
 
 // GetAllCompostHistories 获取所有堆肥历史记录
@@ -106,27 +453,41 @@ func (c *CompostController) GetCompostRecords(ctx *gin.Context) {
 	startDate := ctx.Query("startDate")
 	endDate := ctx.Query("endDate")
 	sourceQuery := ctx.Query("sourceQuery")
+	plotID := ctx.Query("plotId")
+	// deleted=true 时查询回收站中的记录
+	deletedCondition := " AND ch.deleted_at IS NULL"
+	if ctx.Query("deleted") == "true" {
+		deletedCondition = " AND ch.deleted_at IS NOT NULL"
+	}
 
-	// 构建基础查询
-	query := "SELECT DISTINCT ch.id, ch.all_volume, ch.cn_ratio, ch.density, ch.water_add, ch.created_at FROM compost_history ch " +
-		"LEFT JOIN compost_history_sources chs ON ch.id = chs.compost_history_id " +
-		"WHERE ch.user_id = ?"
-
-	queryParams := []interface{}{userID}
+	// 列表与总数使用相同的筛选条件
+	from := " FROM compost_history ch LEFT JOIN compost_history_sources chs ON ch.id = chs.compost_history_id"
+	conditions := " WHERE ch.user_id = ?" + deletedCondition
+	conditionParams := []interface{}{userID}
 
 	// 添加时间区间筛选
 	if startDate != "" && endDate != "" {
-		query += " AND ch.created_at BETWEEN ? AND ?"
-		queryParams = append(queryParams, startDate, endDate)
+		conditions += " AND ch.created_at BETWEEN ? AND ?"
+		conditionParams = append(conditionParams, startDate, endDate)
 	}
 
 	// 添加氮源和碳源养料模糊查询
 	if sourceQuery != "" {
-		query += " AND (chs.source_name LIKE ? OR chs.source_type LIKE ?)"
+		conditions += " AND (chs.source_name LIKE ? OR chs.source_type LIKE ?)"
 		sourceQueryLike := "%" + sourceQuery + "%"
-		queryParams = append(queryParams, sourceQueryLike, sourceQueryLike)
+		conditionParams = append(conditionParams, sourceQueryLike, sourceQueryLike)
 	}
 
+	// 按地块筛选
+	if plotID != "" {
+		conditions += " AND ch.plot_id = ?"
+		conditionParams = append(conditionParams, plotID)
+	}
+
+	query := "SELECT DISTINCT ch.id, ch.all_volume, ch.cn_ratio, ch.density, ch.water_add, ch.target_moisture, ch.plot_id, ch.created_at" +
+		from + conditions
+	queryParams := append([]interface{}{}, conditionParams...)
+
 	// 添加分页
 	query += " ORDER BY ch.created_at DESC LIMIT ? OFFSET ?"
 	queryParams = append(queryParams, pageSize, (page-1)*pageSize)
@@ -142,40 +503,13 @@ func (c *CompostController) GetCompostRecords(ctx *gin.Context) {
 	var histories []models.CompostHistory
 	for historyRows.Next() {
 		var history models.CompostHistory
-		err := historyRows.Scan(&history.ID, &history.AllVolume, &history.CNRatio, &history.Density, &history.WaterAdd, &history.CreatedAt)
+		var historyPlotID sql.NullInt64
+		err := historyRows.Scan(&history.ID, &history.AllVolume, &history.CNRatio, &history.Density, &history.WaterAdd, &history.TargetMoisture, &historyPlotID, &history.CreatedAt)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning compost history row"})
 			return
 		}
-
-		// 查询该堆肥历史记录对应的氮源和碳源信息
-		sourceRows, err := c.DB.Query("SELECT source_type, source_name, c_content, n_content, moisture_content, c_n_ratio, weight FROM compost_history_sources WHERE compost_history_id = ?", history.ID)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for compost history sources"})
-			return
-		}
-		defer sourceRows.Close()
-
-		for sourceRows.Next() {
-			var sourceType string
-			var source models.Fertilizer
-			err := sourceRows.Scan(&sourceType, &source.Name, &source.C, &source.N, &source.Moisture, &source.C_N, &source.Weight)
-			if err != nil {
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning compost history source row"})
-				return
-			}
-			if sourceType == "nitrogen" {
-				history.NitrogenSourcesList = append(history.NitrogenSourcesList, source)
-			} else if sourceType == "carbon" {
-				history.CarbonSourcesList = append(history.CarbonSourcesList, source)
-			}
-		}
-
-		if err = sourceRows.Err(); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating compost history source rows"})
-			return
-		}
-
+		history.PlotID = int(historyPlotID.Int64)
 		histories = append(histories, history)
 	}
 
@@ -184,9 +518,19 @@ func (c *CompostController) GetCompostRecords(ctx *gin.Context) {
 		return
 	}
 
+	// 批量查询氮源和碳源信息
+	byID := make(map[int]*models.CompostHistory, len(histories))
+	for i := range histories {
+		byID[histories[i].ID] = &histories[i]
+	}
+	if err := attachCompostSources(c.DB, byID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for compost history sources"})
+		return
+	}
+
 	// 获取总记录数
 	var totalCount int
-	err = c.DB.QueryRow("SELECT COUNT(DISTINCT ch.id) FROM compost_history ch LEFT JOIN compost_history_sources chs ON ch.id = chs.compost_history_id WHERE ch.user_id = ?", userID).Scan(&totalCount)
+	err = c.DB.QueryRow("SELECT COUNT(DISTINCT ch.id)"+from+conditions, conditionParams...).Scan(&totalCount)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting total count"})
 		return
@@ -202,10 +546,104 @@ func (c *CompostController) GetCompostRecords(ctx *gin.Context) {
 	})
 }
 
+// ExportCompostRecords 导出堆肥记录，筛选条件与 GetCompostRecords 相同，不分页，氮源与碳源导出为子表
+func (c *CompostController) ExportCompostRecords(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	startDate := ctx.Query("startDate")
+	endDate := ctx.Query("endDate")
+	sourceQuery := ctx.Query("sourceQuery")
+	plotID := ctx.Query("plotId")
+
+	// 主表与原料子表使用相同的筛选条件，原料模糊查询筛选的是包含匹配原料的堆肥记录
+	conditions := " WHERE ch.user_id = ?"
+	params := []interface{}{userID}
+	if ctx.Query("deleted") == "true" {
+		conditions += " AND ch.deleted_at IS NOT NULL"
+	} else {
+		conditions += " AND ch.deleted_at IS NULL"
+	}
+	if startDate != "" && endDate != "" {
+		conditions += " AND ch.created_at BETWEEN ? AND ?"
+		params = append(params, startDate, endDate)
+	}
+	if sourceQuery != "" {
+		conditions += " AND EXISTS (SELECT 1 FROM compost_history_sources s WHERE s.compost_history_id = ch.id AND (s.source_name LIKE ? OR s.source_type LIKE ?))"
+		sourceQueryLike := "%" + sourceQuery + "%"
+		params = append(params, sourceQueryLike, sourceQueryLike)
+	}
+	if plotID != "" {
+		conditions += " AND ch.plot_id = ?"
+		params = append(params, plotID)
+	}
+
+	writeRecordExport(ctx, c.DB, "compost-records", []exportSheet{
+		{
+			Key:    "records",
+			Title:  "堆肥记录",
+			Header: []string{"记录ID", "时间", "地块ID", "总体积", "碳氮比", "容重", "补水量", "目标含水率"},
+			Query: `
+				SELECT ch.id, ch.created_at, ch.plot_id, ch.all_volume, ch.cn_ratio, ch.density, ch.water_add, ch.target_moisture
+				FROM compost_history ch` + conditions + `
+				ORDER BY ch.created_at DESC, ch.id DESC
+			`,
+			Params: params,
+			Row: func(rows *sql.Rows) ([]interface{}, error) {
+				var history models.CompostHistory
+				var plotID sql.NullInt64
+				err := rows.Scan(&history.ID, &history.CreatedAt, &plotID, &history.AllVolume, &history.CNRatio,
+					&history.Density, &history.WaterAdd, &history.TargetMoisture)
+				if err != nil {
+					return nil, err
+				}
+				return []interface{}{
+					history.ID, exportTime(history.CreatedAt), exportID(int(plotID.Int64)), history.AllVolume,
+					history.CNRatio, history.Density, history.WaterAdd, history.TargetMoisture,
+				}, nil
+			},
+		},
+		{
+			Key:   "sources",
+			Title: "堆肥原料",
+			Header: []string{
+				"记录ID", "原料类型", "原料名称", "物料ID", "重量", "碳（%）", "氮（%）", "P2O5（%）", "K2O（%）", "含水率（%）", "碳氮比",
+			},
+			Query: `
+				SELECT chs.compost_history_id, chs.source_type, chs.source_name, chs.material_id, chs.weight, chs.c_content,
+					chs.n_content, chs.p2o5_content, chs.k2o_content, chs.moisture_content, chs.c_n_ratio
+				FROM compost_history_sources chs
+				JOIN compost_history ch ON ch.id = chs.compost_history_id` + conditions + `
+				ORDER BY ch.created_at DESC, ch.id DESC
+			`,
+			Params: params,
+			Row: func(rows *sql.Rows) ([]interface{}, error) {
+				var historyID int
+				var sourceType string
+				var source models.Fertilizer
+				var materialID sql.NullInt64
+				err := rows.Scan(&historyID, &sourceType, &source.Name, &materialID, &source.Weight, &source.C,
+					&source.N, &source.P2O5, &source.K2O, &source.Moisture, &source.C_N)
+				if err != nil {
+					return nil, err
+				}
+				switch sourceType {
+				case "nitrogen":
+					sourceType = "氮源"
+				case "carbon":
+					sourceType = "碳源"
+				}
+				return []interface{}{
+					historyID, sourceType, source.Name, exportID(int(materialID.Int64)), source.Weight, source.C,
+					source.N, source.P2O5, source.K2O, source.Moisture, source.C_N,
+				}, nil
+			},
+		},
+	})
+}
+
 // GetCompostHistory 获取单个堆肥历史记录
 func (c *CompostController) GetCompostRecord(ctx *gin.Context) {
 	userID := ctx.GetInt("userID")
-	idStr := ctx.Param("id")
+	idStr := ctx.Query("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
@@ -213,8 +651,9 @@ func (c *CompostController) GetCompostRecord(ctx *gin.Context) {
 	}
 
 	var history models.CompostHistory
-	query := "SELECT id, cn_ratio, density, water_add, created_at FROM compost_history WHERE id = ? AND user_id = ?"
-	err = c.DB.QueryRow(query, id, userID).Scan(&history.ID, &history.CNRatio, &history.Density, &history.WaterAdd, &history.CreatedAt)
+	var plotID sql.NullInt64
+	query := "SELECT id, all_volume, cn_ratio, density, water_add, target_moisture, plot_id, created_at FROM compost_history WHERE id = ? AND user_id = ? AND deleted_at IS NULL"
+	err = c.DB.QueryRow(query, id, userID).Scan(&history.ID, &history.AllVolume, &history.CNRatio, &history.Density, &history.WaterAdd, &history.TargetMoisture, &plotID, &history.CreatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "Compost history not found"})
@@ -223,34 +662,27 @@ func (c *CompostController) GetCompostRecord(ctx *gin.Context) {
 		}
 		return
 	}
+	history.PlotID = int(plotID.Int64)
 
 	// 查询该堆肥历史记录对应的氮源和碳源信息
-	sourceRows, err := c.DB.Query("SELECT source_type, source_name, c_content, n_content, moisture_content, c_n_ratio FROM compost_history_sources WHERE compost_history_id = ?", history.ID)
-	if err != nil {
+	if err := loadCompostSources(c.DB, &history); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for compost history sources"})
 		return
 	}
-	defer sourceRows.Close()
 
-	for sourceRows.Next() {
-		var sourceType string
-		var source models.Fertilizer
-		err := sourceRows.Scan(&sourceType, &source.Name, &source.C, &source.N, &source.Moisture, &source.C_N)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning compost history source row"})
-			return
-		}
-		if sourceType == "nitrogen" {
-			history.NitrogenSourcesList = append(history.NitrogenSourcesList, source)
-		} else if sourceType == "carbon" {
-			history.CarbonSourcesList = append(history.CarbonSourcesList, source)
-		}
+	// 查询最近一次腐熟度评价
+	history.Assessment, err = loadLatestCompostAssessment(c.DB, history.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for compost assessment"})
+		return
 	}
 
-	if err = sourceRows.Err(); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating compost history source rows"})
-		return
+	// 估算成品养分，已有腐熟度评价时以实测碳氮比为准
+	var measuredCN *float64
+	if history.Assessment != nil {
+		measuredCN = history.Assessment.FinalCNRatio
 	}
+	history.NutrientEstimate = estimateCompostNutrients(append(append([]models.Fertilizer{}, history.NitrogenSourcesList...), history.CarbonSourcesList...), measuredCN)
 
 	ctx.JSON(http.StatusOK,
 
@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+)
+
+// CompostMaterialController 处理堆肥物料库相关的请求
+type CompostMaterialController struct {
+	DB *sql.DB
+}
+
+// NewCompostMaterialController 创建一个新的CompostMaterialController实例
+func NewCompostMaterialController(db *sql.DB) *CompostMaterialController {
+	return &CompostMaterialController{DB: db}
+}
+
+const compostMaterialColumns = "id, user_id, name, category, c_content, n_content, p2o5_content, k2o_content, moisture_content, density, description, created_at"
+
+// scanCompostMaterial 扫描一行物料数据
+func scanCompostMaterial(scanner interface{ Scan(...interface{}) error }) (models.CompostMaterial, error) {
+	var material models.CompostMaterial
+	var userID sql.NullInt64
+	var description sql.NullString
+	err := scanner.Scan(&material.ID, &userID, &material.Name, &material.Category, &material.C, &material.N,
+		&material.P2O5, &material.K2O, &material.Moisture, &material.Density, &description, &material.CreatedAt)
+	if err != nil {
+		return material, err
+	}
+	if userID.Valid {
+		id := int(userID.Int64)
+		material.UserID = &id
+	}
+	material.IsSystem = !userID.Valid
+	material.Description = description.String
+	return material, nil
+}
+
+// GetCompostMaterials 搜索物料库（系统默认物料与当前用户的自定义物料）
+func (c *CompostMaterialController) GetCompostMaterials(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "50"))
+	keyword := ctx.Query("keyword")
+	category := ctx.Query("category")
+
+	where := " WHERE (user_id IS NULL OR user_id = ?)"
+	params := []interface{}{userID}
+
+	if keyword != "" {
+		where += " AND (name LIKE ? OR description LIKE ?)"
+		keywordLike := "%" + keyword + "%"
+		params = append(params, keywordLike, keywordLike)
+	}
+
+	if category != "" {
+		where += " AND category = ?"
+		params = append(params, category)
+	}
+
+	var totalCount int
+	if err := c.DB.QueryRow("SELECT COUNT(*) FROM compost_materials"+where, params...).Scan(&totalCount); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting total count"})
+		return
+	}
+
+	// 自定义物料排在系统物料之前
+	query := "SELECT " + compostMaterialColumns + " FROM compost_materials" + where +
+		" ORDER BY user_id IS NULL, name LIMIT ? OFFSET ?"
+	params = append(params, pageSize, (page-1)*pageSize)
+
+	rows, err := c.DB.Query(query, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for compost materials"})
+		return
+	}
+	defer rows.Close()
+
+	materials := []models.CompostMaterial{}
+	for rows.Next() {
+		material, err := scanCompostMaterial(rows)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning compost material row"})
+			return
+		}
+		materials = append(materials, material)
+	}
+
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating compost material rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":        200,
+		"msg":         "ok",
+		"data":        materials,
+		"totalCount":  totalCount,
+		"currentPage": page,
+		"pageSize":    pageSize,
+	})
+}
+
+// GetCompostMaterial 获取单个物料
+func (c *CompostMaterialController) GetCompostMaterial(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id := ctx.Param("id")
+
+	query := "SELECT " + compostMaterialColumns + " FROM compost_materials WHERE id = ? AND (user_id IS NULL OR user_id = ?)"
+	material, err := scanCompostMaterial(c.DB.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Compost material not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": material,
+	})
+}
+
+// CreateCompostMaterial 创建自定义物料，管理员可通过 isSystem 创建系统默认物料
+func (c *CompostMaterialController) CreateCompostMaterial(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var material models.CompostMaterial
+	if err := ctx.ShouldBindJSON(&material); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateCompostMaterial(material); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var owner interface{} = userID
+	if material.IsSystem {
+		if status, err := checkAdmin(c.DB, userID, "create system materials"); err != nil {
+			ctx.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		owner = nil
+	}
+
+	result, err := c.DB.Exec(`
+		INSERT INTO compost_materials (user_id, name, category, c_content, n_content, p2o5_content, k2o_content, moisture_content, density, description)
+		VALUES (?,?,?,?,?,?,?,?,?,?)
+	`, owner, material.Name, material.Category, material.C, material.N, material.P2O5, material.K2O, material.Moisture, material.Density, material.Description)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	material.ID = int(id)
+	if !material.IsSystem {
+		material.UserID = &userID
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": material,
+	})
+}
+
+// UpdateCompostMaterial 更新物料，普通用户只能修改自己的物料，管理员可修改系统物料
+func (c *CompostMaterialController) UpdateCompostMaterial(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var material models.CompostMaterial
+	if err := ctx.ShouldBindJSON(&material); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateCompostMaterial(material); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if status, err := c.checkMaterialEditable(id, userID); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = c.DB.Exec(`
+		UPDATE compost_materials
+		SET name = ?, category = ?, c_content = ?, n_content = ?, p2o5_content = ?, k2o_content = ?, moisture_content = ?, density = ?, description = ?
+		WHERE id = ?
+	`, material.Name, material.Category, material.C, material.N, material.P2O5, material.K2O, material.Moisture, material.Density, material.Description, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := scanCompostMaterial(c.DB.QueryRow("SELECT "+compostMaterialColumns+" FROM compost_materials WHERE id = ?", id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": updated,
+	})
+}
+
+// DeleteCompostMaterial 删除物料，已保存的堆肥记录保留物料数值副本，不受影响
+func (c *CompostMaterialController) DeleteCompostMaterial(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if status, err := c.checkMaterialEditable(id, userID); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := c.DB.Exec("DELETE FROM compost_materials WHERE id = ?", id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// checkMaterialEditable 检查当前用户是否可以修改物料，返回失败时对应的 HTTP 状态码
+func (c *CompostMaterialController) checkMaterialEditable(id, userID int) (int, error) {
+	var owner sql.NullInt64
+	err := c.DB.QueryRow("SELECT user_id FROM compost_materials WHERE id = ?", id).Scan(&owner)
+	if err == sql.ErrNoRows || (err == nil && owner.Valid && int(owner.Int64) != userID) {
+		return http.StatusNotFound, errors.New("compost material not found")
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if owner.Valid {
+		return 0, nil
+	}
+
+	return checkAdmin(c.DB, userID, "change system materials")
+}
+
+// validateCompostMaterial 校验物料数值范围
+func validateCompostMaterial(material models.CompostMaterial) error {
+	if material.C < 0 || material.C > 100 || material.N < 0 || material.N > 100 {
+		return errors.New("c and n must be between 0 and 100")
+	}
+	if material.P2O5 < 0 || material.P2O5 > 100 || material.K2O < 0 || material.K2O > 100 {
+		return errors.New("p2o5 and k2o must be between 0 and 100")
+	}
+	if material.Moisture < 0 || material.Moisture >= 100 {
+		return errors.New("moisture must be between 0 and 100")
+	}
+	if material.Density < 0 {
+		return errors.New("density must not be negative")
+	}
+	return nil
+}
+
+// resolveCompostMaterials 将引用物料库的物料替换为物料库中的碳、氮、磷、钾与含水率
+func resolveCompostMaterials(db *sql.DB, userID int, sources []models.Fertilizer) error {
+	for i := range sources {
+		if _, err := resolveCompostMaterial(db, userID, &sources[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveCompostSolveSources 同 resolveCompostMaterials，并以物料库中的容重为准
+func resolveCompostSolveSources(db *sql.DB, userID int, sources []models.CompostSolveSource) error {
+	for i := range sources {
+		density, err := resolveCompostMaterial(db, userID, &sources[i].Fertilizer)
+		if err != nil {
+			return err
+		}
+		if sources[i].MaterialID > 0 {
+			sources[i].Density = density
+		}
+	}
+	return nil
+}
+
+// resolveCompostMaterial 物料引用物料库时替换为物料库中的参数，并返回物料库中的容重；未引用时不做修改
+func resolveCompostMaterial(db *sql.DB, userID int, source *models.Fertilizer) (float64, error) {
+	if source.MaterialID == 0 {
+		return 0, nil
+	}
+	var name string
+	var c, n, p2o5, k2o, moisture, density float64
+	err := db.QueryRow(
+		"SELECT name, c_content, n_content, p2o5_content, k2o_content, moisture_content, density FROM compost_materials WHERE id = ? AND (user_id IS NULL OR user_id = ?)",
+		source.MaterialID, userID,
+	).Scan(&name, &c, &n, &p2o5, &k2o, &moisture, &density)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("compost material %d not found", source.MaterialID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if source.Name == "" {
+		source.Name = name
+	}
+	source.C = c
+	source.N = n
+	source.P2O5 = p2o5
+	source.K2O = k2o
+	source.Moisture = moisture
+	return density, nil
+}
+
+// nullableID 将 0 转换为 NULL，用于可选的外键列
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,477 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04:05"
+)
+
+const (
+	// thermophilicTemperature 进入高温期的温度（℃）
+	thermophilicTemperature = 45.0
+	// pathogenKillTemperature、pathogenKillDays 无害化要求：堆温 ≥55℃ 累计不少于 5 天（GB 7959）
+	pathogenKillTemperature = 55.0
+	pathogenKillDays        = 5
+	// 各阶段的经验天数，用于估算腐熟日期
+	expectedHeatingDays      = 7
+	expectedThermophilicDays = 21
+	expectedCuringDays       = 30
+)
+
+// CompostPileController 处理堆体全过程跟踪相关的请求
+type CompostPileController struct {
+	DB *sql.DB
+}
+
+// NewCompostPileController 创建一个新的CompostPileController实例
+func NewCompostPileController(db *sql.DB) *CompostPileController {
+	return &CompostPileController{DB: db}
+}
+
+// CreateCompostPile 创建堆体
+func (c *CompostPileController) CreateCompostPile(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var pile models.CompostPile
+	if err := ctx.ShouldBindJSON(&pile); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := time.Parse(dateLayout, pile.StartDate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "startDate must be formatted as YYYY-MM-DD"})
+		return
+	}
+
+	// 校验关联的堆肥记录归属
+	var historyID int
+	err := c.DB.QueryRow("SELECT id FROM compost_history WHERE id = ? AND user_id = ? AND deleted_at IS NULL", pile.CompostHistoryID, userID).Scan(&historyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Compost history not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	pile.Status = models.PileStatusActive
+	result, err := c.DB.Exec(
+		"INSERT INTO compost_piles (user_id, compost_history_id, name, start_date, status, notes) VALUES (?,?,?,?,?,?)",
+		userID, pile.CompostHistoryID, pile.Name, pile.StartDate, pile.Status, pile.Notes,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	pile.ID = int(id)
+	pile.UserID = userID
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": pile,
+	})
+}
+
+// GetCompostPiles 获取堆体列表
+func (c *CompostPileController) GetCompostPiles(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	status := ctx.Query("status")
+
+	where := " WHERE user_id = ?"
+	params := []interface{}{userID}
+	if status != "" {
+		where += " AND status = ?"
+		params = append(params, status)
+	}
+
+	var totalCount int
+	if err := c.DB.QueryRow("SELECT COUNT(*) FROM compost_piles"+where, params...).Scan(&totalCount); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting total count"})
+		return
+	}
+
+	query := "SELECT id, user_id, compost_history_id, name, start_date, status, notes, created_at FROM compost_piles" +
+		where + " ORDER BY start_date DESC, id DESC LIMIT ? OFFSET ?"
+	params = append(params, pageSize, (page-1)*pageSize)
+
+	rows, err := c.DB.Query(query, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for compost piles"})
+		return
+	}
+	defer rows.Close()
+
+	piles := []models.CompostPile{}
+	for rows.Next() {
+		pile, err := scanCompostPile(rows)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning compost pile row"})
+			return
+		}
+		piles = append(piles, pile)
+	}
+
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating compost pile rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":        200,
+		"msg":         "ok",
+		"data":        piles,
+		"totalCount":  totalCount,
+		"currentPage": page,
+		"pageSize":    pageSize,
+	})
+}
+
+// GetCompostPile 获取单个堆体，包含全部日志与阶段分析
+func (c *CompostPileController) GetCompostPile(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	pile, err := c.loadCompostPile(id, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Compost pile not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": pile,
+	})
+}
+
+// UpdateCompostPile 更新堆体名称、状态与备注，未提供的字段保持原值
+func (c *CompostPileController) UpdateCompostPile(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req struct {
+		Name   *string `json:"name"`
+		Status string  `json:"status" binding:"omitempty,oneof=active finished"`
+		Notes  *string `json:"notes"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, err := c.loadCompostPile(id, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Compost pile not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	// 未提供的字段保持原值，避免清空名称、备注或重新打开已结束的堆体
+	name, notes := current.Name, current.Notes
+	if req.Name != nil {
+		name = *req.Name
+	}
+	if req.Notes != nil {
+		notes = *req.Notes
+	}
+	if req.Status == "" {
+		req.Status = current.Status
+	}
+
+	_, err = c.DB.Exec("UPDATE compost_piles SET name = ?, status = ?, notes = ? WHERE id = ? AND user_id = ?", name, req.Status, notes, id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	pile, err := c.loadCompostPile(id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": pile,
+	})
+}
+
+// DeleteCompostPile 删除堆体及其日志
+func (c *CompostPileController) DeleteCompostPile(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := tx.Exec("DELETE FROM compost_piles WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		tx.Rollback()
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Compost pile not found"})
+		return
+	}
+
+	if _, err = tx.Exec("DELETE FROM compost_pile_logs WHERE pile_id = ?", id); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// AddCompostPileLog 添加堆体日志（温度/含水率读数、翻堆、备注）
+func (c *CompostPileController) AddCompostPileLog(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var log models.CompostPileLog
+	if err := ctx.ShouldBindJSON(&log); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if log.LogType == models.PileLogReading && log.Temperature == nil && log.Moisture == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "reading requires temperature or moisture"})
+		return
+	}
+	if log.Moisture != nil && (*log.Moisture < 0 || *log.Moisture > 100) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "moisture must be between 0 and 100"})
+		return
+	}
+
+	if log.LoggedAt == "" {
+		log.LoggedAt = time.Now().Format(dateTimeLayout)
+	} else if _, err := time.Parse(dateTimeLayout, log.LoggedAt); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "loggedAt must be formatted as YYYY-MM-DD HH:MM:SS"})
+		return
+	}
+
+	if err := c.pileOwnedBy(id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Compost pile not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	result, err := c.DB.Exec(
+		"INSERT INTO compost_pile_logs (pile_id, log_type, logged_at, temperature, moisture, note) VALUES (?,?,?,?,?,?)",
+		id, log.LogType, log.LoggedAt, log.Temperature, log.Moisture, log.Note,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logID, err := result.LastInsertId()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	log.ID = int(logID)
+	log.PileID = id
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": log,
+	})
+}
+
+// pileOwnedBy 校验堆体归属，不属于当前用户时返回 sql.ErrNoRows
+func (c *CompostPileController) pileOwnedBy(id, userID int) error {
+	var pileID int
+	return c.DB.QueryRow("SELECT id FROM compost_piles WHERE id = ? AND user_id = ?", id, userID).Scan(&pileID)
+}
+
+// loadCompostPile 查询堆体、日志并计算阶段
+func (c *CompostPileController) loadCompostPile(id, userID int) (models.CompostPile, error) {
+	row := c.DB.QueryRow("SELECT id, user_id, compost_history_id, name, start_date, status, notes, created_at FROM compost_piles WHERE id = ? AND user_id = ?", id, userID)
+	pile, err := scanCompostPile(row)
+	if err != nil {
+		return pile, err
+	}
+
+	rows, err := c.DB.Query("SELECT id, pile_id, log_type, logged_at, temperature, moisture, note FROM compost_pile_logs WHERE pile_id = ? ORDER BY logged_at, id", id)
+	if err != nil {
+		return pile, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log models.CompostPileLog
+		var loggedAt time.Time
+		var temperature, moisture sql.NullFloat64
+		var note sql.NullString
+		if err := rows.Scan(&log.ID, &log.PileID, &log.LogType, &loggedAt, &temperature, &moisture, &note); err != nil {
+			return pile, err
+		}
+		log.LoggedAt = loggedAt.Format(dateTimeLayout)
+		if temperature.Valid {
+			log.Temperature = &temperature.Float64
+		}
+		if moisture.Valid {
+			log.Moisture = &moisture.Float64
+		}
+		log.Note = note.String
+		pile.Logs = append(pile.Logs, log)
+	}
+	if err := rows.Err(); err != nil {
+		return pile, err
+	}
+
+	startDate, err := time.ParseInLocation(dateLayout, pile.StartDate, time.Local)
+	if err != nil {
+		return pile, err
+	}
+	summary := summarizeCompostPile(startDate, pile.Logs, time.Now())
+	pile.Summary = &summary
+	return pile, nil
+}
+
+// scanCompostPile 扫描一行堆体数据
+func scanCompostPile(scanner interface{ Scan(...interface{}) error }) (models.CompostPile, error) {
+	var pile models.CompostPile
+	var name, notes sql.NullString
+	var startDate, createdAt time.Time
+	err := scanner.Scan(&pile.ID, &pile.UserID, &pile.CompostHistoryID, &name, &startDate, &pile.Status, &notes, &createdAt)
+	if err != nil {
+		return pile, err
+	}
+	pile.Name = name.String
+	pile.Notes = notes.String
+	pile.StartDate = startDate.Format(dateLayout)
+	pile.CreatedAt = createdAt.Format(dateTimeLayout)
+	return pile, nil
+}
+
+// summarizeCompostPile 根据按时间排序的日志判断堆肥阶段、统计无害化天数并估算腐熟日期
+//
+// 最近一次温度 ≥45℃ 为高温期；曾进入高温期后降温为降温腐熟期；否则为升温期。
+// 腐熟日期按高温期结束后再腐熟 30 天估算，尚未进入高温期时按经验天数从开始日期推算。
+func summarizeCompostPile(startDate time.Time, logs []models.CompostPileLog, now time.Time) models.CompostPileSummary {
+	summary := models.CompostPileSummary{
+		Phase:       models.PhaseMesophilic,
+		DaysElapsed: int(now.Sub(startDate).Hours() / 24),
+	}
+
+	hotDays := map[string]bool{}
+	var firstThermophilic, lastThermophilic, lastReading time.Time
+	for _, log := range logs {
+		loggedAt, err := time.ParseInLocation(dateTimeLayout, log.LoggedAt, time.Local)
+		if err != nil {
+			continue
+		}
+
+		switch log.LogType {
+		case models.PileLogTurning:
+			summary.TurningCount++
+			summary.LastTurnedAt = log.LoggedAt
+		case models.PileLogReading:
+			if log.Moisture != nil {
+				summary.LatestMoisture = log.Moisture
+			}
+			if log.Temperature == nil {
+				continue
+			}
+			temperature := *log.Temperature
+			summary.LatestTemperature = log.Temperature
+			if summary.PeakTemperature == nil || temperature > *summary.PeakTemperature {
+				summary.PeakTemperature = log.Temperature
+			}
+			if temperature >= pathogenKillTemperature {
+				hotDays[loggedAt.Format(dateLayout)] = true
+			}
+			if temperature >= thermophilicTemperature {
+				if firstThermophilic.IsZero() {
+					firstThermophilic = loggedAt
+				}
+				lastThermophilic = loggedAt
+			}
+			lastReading = loggedAt
+		}
+	}
+
+	summary.DaysAbove55 = len(hotDays)
+	summary.PathogenKillReached = summary.DaysAbove55 >= pathogenKillDays
+
+	var maturity time.Time
+	switch {
+	case summary.LatestTemperature != nil && *summary.LatestTemperature >= thermophilicTemperature:
+		summary.Phase = models.PhaseThermophilic
+		thermophilicEnd := firstThermophilic.AddDate(0, 0, expectedThermophilicDays)
+		if thermophilicEnd.Before(lastReading) {
+			thermophilicEnd = lastReading
+		}
+		maturity = thermophilicEnd.AddDate(0, 0, expectedCuringDays)
+	case !firstThermophilic.IsZero():
+		summary.Phase = models.PhaseCuring
+		maturity = lastThermophilic.AddDate(0, 0, expectedCuringDays)
+	default:
+		maturity = startDate.AddDate(0, 0, expectedHeatingDays+expectedThermophilicDays+expectedCuringDays)
+	}
+	summary.EstimatedMaturityDate = maturity.Format(dateLayout)
+
+	return summary
+}
@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"go-mengtuobang/models"
+	"go-mengtuobang/utils"
+)
+
+const (
+	// defaultBulkDensity 未提供物料容重时使用的默认值（kg/m³）
+	defaultBulkDensity = 500.0
+	// defaultMinMoisture、defaultMaxMoisture 默认目标含水率范围（%）
+	defaultMinMoisture = 55.0
+	defaultMaxMoisture = 65.0
+	// cnRatioTolerance 判定达到目标碳氮比的允许偏差
+	cnRatioTolerance = 0.5
+)
+
+// solveCompostRecipe 求解各物料用量，使混合物碳氮比尽量接近目标值，
+// 同时满足含水率范围、堆体体积及各物料最大可用量约束，不足的水分通过补水解决
+//
+// 决策变量依次为：各物料鲜重 w_i、补水量 water、碳氮比偏差 e+ 与 e-
+func solveCompostRecipe(req models.CompostSolveRequest) (models.CompostSolveResult, error) {
+	if len(req.Sources) == 0 {
+		return models.CompostSolveResult{}, errors.New("at least one source is required")
+	}
+	if req.TargetCNRatio <= 0 {
+		return models.CompostSolveResult{}, errors.New("targetCNRatio must be greater than 0")
+	}
+	if req.AllVolume <= 0 {
+		return models.CompostSolveResult{}, errors.New("allVolume must be greater than 0")
+	}
+	if req.MinMoisture == 0 && req.MaxMoisture == 0 {
+		req.MinMoisture, req.MaxMoisture = defaultMinMoisture, defaultMaxMoisture
+	}
+	if req.MinMoisture < 0 || req.MaxMoisture >= 100 || req.MinMoisture > req.MaxMoisture {
+		return models.CompostSolveResult{}, errors.New("invalid moisture range")
+	}
+	for _, source := range req.Sources {
+		if source.C < 0 || source.N < 0 || source.Moisture < 0 || source.Moisture >= 100 || source.MaxWeight < 0 || source.Density < 0 {
+			return models.CompostSolveResult{}, fmt.Errorf("invalid values for source %q", source.Name)
+		}
+	}
+
+	k := len(req.Sources)
+	waterIdx, ePlusIdx, eMinusIdx := k, k+1, k+2
+	size := k + 3
+	newRow := func() []float64 { return make([]float64, size) }
+
+	var constraints []utils.LPConstraint
+
+	// 碳氮比：Σ w_i·d_i·(C_i - R·N_i) + e- - e+ = 0，d_i 为干物质比例
+	cn := newRow()
+	for i, source := range req.Sources {
+		dry := (100 - source.Moisture) / 100
+		cn[i] = dry * (source.C - req.TargetCNRatio*source.N) / 100
+	}
+	cn[ePlusIdx], cn[eMinusIdx] = -1, 1
+	constraints = append(constraints, utils.LPConstraint{Coeffs: cn, Op: utils.LPEqual, RHS: 0})
+
+	// 含水率上下限（补水量按含水率 100% 计）
+	upper, lower := newRow(), newRow()
+	for i, source := range req.Sources {
+		upper[i] = source.Moisture - req.MaxMoisture
+		lower[i] = source.Moisture - req.MinMoisture
+	}
+	upper[waterIdx] = 100 - req.MaxMoisture
+	lower[waterIdx] = 100 - req.MinMoisture
+	constraints = append(constraints,
+		utils.LPConstraint{Coeffs: upper, Op: utils.LPLessEq, RHS: 0},
+		utils.LPConstraint{Coeffs: lower, Op: utils.LPGreaterEq, RHS: 0},
+	)
+
+	// 堆体体积：Σ w_i / ρ_i = V，补充的水分被物料吸收，不计入体积
+	volume := newRow()
+	for i, source := range req.Sources {
+		volume[i] = 1 / sourceDensity(source)
+	}
+	constraints = append(constraints, utils.LPConstraint{Coeffs: volume, Op: utils.LPEqual, RHS: req.AllVolume})
+
+	// 最大可用量
+	for i, source := range req.Sources {
+		if source.MaxWeight > 0 {
+			row := newRow()
+			row[i] = 1
+			constraints = append(constraints, utils.LPConstraint{Coeffs: row, Op: utils.LPLessEq, RHS: source.MaxWeight})
+		}
+	}
+
+	// 目标：优先使碳氮比偏差最小，其次尽量少补水
+	objective := newRow()
+	objective[ePlusIdx], objective[eMinusIdx] = 1000, 1000
+	objective[waterIdx] = 0.001
+
+	x, _, err := utils.SolveLP(objective, constraints)
+	if err == utils.ErrLPInfeasible {
+		reasons := explainCompostInfeasible(req, "no mix satisfies the moisture range, volume and maximum weight limits at the same time")
+		return models.CompostSolveResult{Feasible: false, Reasons: reasons}, nil
+	}
+	if err != nil {
+		return models.CompostSolveResult{}, err
+	}
+
+	result := models.CompostSolveResult{Sources: make([]models.Fertilizer, k)}
+	for i, source := range req.Sources {
+		source.Weight = round2(x[i])
+		if source.N > 0 {
+			source.C_N = source.C / source.N
+		}
+		result.Sources[i] = source.Fertilizer
+	}
+	mix, err := mixCompostSources(result.Sources)
+	if err != nil {
+		return models.CompostSolveResult{}, err
+	}
+	result.WaterAdd = round2(x[waterIdx])
+	result.TotalWeight = round2(mix.TotalWeight + result.WaterAdd)
+	result.CNRatio = round2(mix.CNRatio())
+	if total := mix.TotalWeight + result.WaterAdd; total > 0 {
+		result.Moisture = math.Round((mix.TotalWater+result.WaterAdd)/total*10000) / 100
+	}
+
+	result.Feasible = math.Abs(mix.CNRatio()-req.TargetCNRatio) <= cnRatioTolerance
+	if !result.Feasible {
+		result.Reasons = append(result.Reasons, fmt.Sprintf(
+			"target C/N ratio %.2f cannot be reached with the given constraints, closest achievable is %.2f",
+			req.TargetCNRatio, result.CNRatio))
+		result.Reasons = append(result.Reasons, explainCompostInfeasible(req, "the maximum weight limits or the volume are too tight, relax them to get closer to the target")...)
+	}
+	return result, nil
+}
+
+// sourceDensity 物料容重，未提供时使用默认值
+func sourceDensity(source models.CompostSolveSource) float64 {
+	if source.Density > 0 {
+		return source.Density
+	}
+	return defaultBulkDensity
+}
+
+// explainCompostInfeasible 分析无法满足约束的原因，找不到具体原因时返回 fallback
+func explainCompostInfeasible(req models.CompostSolveRequest, fallback string) []string {
+	var reasons []string
+
+	// 碳氮比可达范围由单一物料的碳氮比决定
+	minCN, maxCN := math.Inf(1), math.Inf(-1)
+	for _, source := range req.Sources {
+		ratio := math.Inf(1)
+		if source.N > 0 {
+			ratio = source.C / source.N
+		}
+		minCN = math.Min(minCN, ratio)
+		maxCN = math.Max(maxCN, ratio)
+	}
+	if req.TargetCNRatio < minCN {
+		reasons = append(reasons, fmt.Sprintf(
+			"target C/N ratio %.2f is below every source (minimum %.2f), add a nitrogen-rich source",
+			req.TargetCNRatio, minCN))
+	} else if req.TargetCNRatio > maxCN {
+		reasons = append(reasons, fmt.Sprintf(
+			"target C/N ratio %.2f is above every source (maximum %.2f), add a carbon-rich source",
+			req.TargetCNRatio, maxCN))
+	}
+
+	// 补水只能提高含水率，所有物料都偏湿时无法降低
+	minMoisture := math.Inf(1)
+	for _, source := range req.Sources {
+		minMoisture = math.Min(minMoisture, source.Moisture)
+	}
+	if minMoisture > req.MaxMoisture {
+		reasons = append(reasons, fmt.Sprintf(
+			"every source is wetter than the maximum moisture %.2f%%, add a drier carbon source",
+			req.MaxMoisture))
+	}
+
+	// 所有物料都限量时，总体积可能不足
+	availableVolume, limited := 0.0, true
+	for _, source := range req.Sources {
+		if source.MaxWeight <= 0 {
+			limited = false
+			break
+		}
+		availableVolume += source.MaxWeight / sourceDensity(source)
+	}
+	if limited && availableVolume < req.AllVolume {
+		reasons = append(reasons, fmt.Sprintf(
+			"available sources only fill %.2f m³, less than the requested volume %.2f m³",
+			availableVolume, req.AllVolume))
+	}
+
+	if len(reasons) == 0 {
+		reasons = append(reasons, fallback)
+	}
+	return reasons
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"go-mengtuobang/models"
+)
+
+var (
+	testManure = models.CompostSolveSource{Fertilizer: models.Fertilizer{Name: "鸡粪", C: 30, N: 2, Moisture: 60}, Density: 500}
+	testStraw  = models.CompostSolveSource{Fertilizer: models.Fertilizer{Name: "玉米秸秆", C: 45, N: 0.9, Moisture: 10}, Density: 100}
+)
+
+func TestSolveCompostRecipe(t *testing.T) {
+	// 碳氮比 25 时每 kg 鸡粪提供 0.4 ×（30 − 25 × 2）= −8、每 kg 秸秆提供 0.9 ×（45 − 25 × 0.9）= 20.25 的碳氮偏差，
+	// 鸡粪与秸秆之比为 20.25 : 8；10 m³ 堆体需秸秆 663.90 kg、鸡粪 1680.50 kg，
+	// 混合物含水率 45.84%，补水 477.18 kg 后达到下限 55%
+	result, err := solveCompostRecipe(models.CompostSolveRequest{
+		Sources:       []models.CompostSolveSource{testManure, testStraw},
+		TargetCNRatio: 25,
+		AllVolume:     10,
+	})
+	if err != nil {
+		t.Fatalf("solveCompostRecipe() error = %v", err)
+	}
+	if !result.Feasible {
+		t.Fatalf("solveCompostRecipe() infeasible: %v", result.Reasons)
+	}
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"manure weight", result.Sources[0].Weight, 1680.50},
+		{"straw weight", result.Sources[1].Weight, 663.90},
+		{"water", result.WaterAdd, 477.18},
+		{"C/N ratio", result.CNRatio, 25},
+		{"moisture", result.Moisture, 55},
+		{"total weight", result.TotalWeight, 2821.58},
+	}
+	for _, check := range checks {
+		if math.Abs(check.got-check.want) > 0.05 {
+			t.Errorf("%s = %v, want %v", check.name, check.got, check.want)
+		}
+	}
+}
+
+func TestSolveCompostRecipeInfeasible(t *testing.T) {
+	wetManure := testManure
+	wetManure.Moisture = 80
+	wetSludge := models.CompostSolveSource{Fertilizer: models.Fertilizer{Name: "污泥", C: 35, N: 1, Moisture: 85}}
+	limitedStraw := testStraw
+	limitedStraw.MaxWeight = 100
+
+	tests := []struct {
+		name   string
+		req    models.CompostSolveRequest
+		reason string
+	}{
+		{
+			"target below every source",
+			models.CompostSolveRequest{Sources: []models.CompostSolveSource{testManure, testStraw}, TargetCNRatio: 10, AllVolume: 10},
+			"is below every source",
+		},
+		{
+			"target above every source",
+			models.CompostSolveRequest{Sources: []models.CompostSolveSource{testManure, testStraw}, TargetCNRatio: 60, AllVolume: 10},
+			"is above every source",
+		},
+		{
+			"every source too wet",
+			models.CompostSolveRequest{Sources: []models.CompostSolveSource{wetManure, wetSludge}, TargetCNRatio: 25, AllVolume: 10},
+			"wetter than the maximum moisture",
+		},
+		{
+			"limited sources cannot fill the volume",
+			models.CompostSolveRequest{Sources: []models.CompostSolveSource{limitedStraw}, TargetCNRatio: 50, AllVolume: 10},
+			"only fill 1.00 m³",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := solveCompostRecipe(tt.req)
+			if err != nil {
+				t.Fatalf("solveCompostRecipe() error = %v", err)
+			}
+			if result.Feasible {
+				t.Fatalf("solveCompostRecipe() feasible, want infeasible")
+			}
+			if !strings.Contains(strings.Join(result.Reasons, "; "), tt.reason) {
+				t.Errorf("Reasons = %v, want %q", result.Reasons, tt.reason)
+			}
+		})
+	}
+}
+
+func TestSolveCompostRecipeInvalid(t *testing.T) {
+	sources := []models.CompostSolveSource{testManure, testStraw}
+	negative := testStraw
+	negative.C = -1
+	tests := []struct {
+		name string
+		req  models.CompostSolveRequest
+	}{
+		{"no sources", models.CompostSolveRequest{TargetCNRatio: 25, AllVolume: 10}},
+		{"no target", models.CompostSolveRequest{Sources: sources, AllVolume: 10}},
+		{"no volume", models.CompostSolveRequest{Sources: sources, TargetCNRatio: 25}},
+		{"inverted moisture range", models.CompostSolveRequest{Sources: sources, TargetCNRatio: 25, AllVolume: 10, MinMoisture: 70, MaxMoisture: 60}},
+		{"negative source value", models.CompostSolveRequest{Sources: []models.CompostSolveSource{testManure, negative}, TargetCNRatio: 25, AllVolume: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := solveCompostRecipe(tt.req); err == nil {
+				t.Errorf("solveCompostRecipe() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+)
+
+// CropCoefficientController 处理作物养分系数相关的请求
+type CropCoefficientController struct {
+	DB *sql.DB
+}
+
+// NewCropCoefficientController 创建一个新的CropCoefficientController实例
+func NewCropCoefficientController(db *sql.DB) *CropCoefficientController {
+	return &CropCoefficientController{DB: db}
+}
+
+const cropCoefficientColumns = `id, crop_code, crop_name, version, n_uptake, p2o5_uptake, k2o_uptake,
+	n_efficiency, p2o5_efficiency, k2o_efficiency, n_correction, p2o5_correction, k2o_correction,
+	is_current, note, created_by, created_at`
+
+// scanCropCoefficient 扫描一行作物养分系数
+func scanCropCoefficient(scanner interface{ Scan(...interface{}) error }) (models.CropNutrientCoefficient, error) {
+	var coefficient models.CropNutrientCoefficient
+	var note sql.NullString
+	var createdBy sql.NullInt64
+	err := scanner.Scan(&coefficient.ID, &coefficient.CropCode, &coefficient.CropName, &coefficient.Version,
+		&coefficient.Uptake.N, &coefficient.Uptake.P2O5, &coefficient.Uptake.K2O,
+		&coefficient.Efficiency.N, &coefficient.Efficiency.P2O5, &coefficient.Efficiency.K2O,
+		&coefficient.Correction.N, &coefficient.Correction.P2O5, &coefficient.Correction.K2O,
+		&coefficient.IsCurrent, &note, &createdBy, &coefficient.CreatedAt)
+	if err != nil {
+		return coefficient, err
+	}
+	coefficient.Note = note.String
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		coefficient.CreatedBy = &id
+	}
+	return coefficient, nil
+}
+
+// GetCropCoefficients 获取作物养分系数，默认只返回当前版本，all=true 时包含历史版本
+func (c *CropCoefficientController) GetCropCoefficients(ctx *gin.Context) {
+	query := "SELECT " + cropCoefficientColumns + " FROM crop_nutrient_coefficients WHERE 1 = 1"
+	var params []interface{}
+	if ctx.Query("all") != "true" {
+		query += " AND is_current = TRUE"
+	}
+	if crop := ctx.Query("crop"); crop != "" {
+		query += " AND (crop_code = ? OR crop_name = ?)"
+		params = append(params, crop, crop)
+	}
+	query += " ORDER BY crop_code, version DESC"
+
+	rows, err := c.DB.Query(query, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for crop coefficients"})
+		return
+	}
+	defer rows.Close()
+
+	coefficients := []models.CropNutrientCoefficient{}
+	for rows.Next() {
+		coefficient, err := scanCropCoefficient(rows)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning crop coefficient row"})
+			return
+		}
+		coefficients = append(coefficients, coefficient)
+	}
+
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating crop coefficient rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": coefficients,
+	})
+}
+
+// GetCropCoefficient 获取单个作物养分系数版本
+func (c *CropCoefficientController) GetCropCoefficient(ctx *gin.Context) {
+	coefficient, err := scanCropCoefficient(c.DB.QueryRow(
+		"SELECT "+cropCoefficientColumns+" FROM crop_nutrient_coefficients WHERE id = ?", ctx.Param("id")))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Crop coefficient not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": coefficient,
+	})
+}
+
+// CreateCropCoefficient 为新作物创建养分系数（管理员功能），已有当前版本的作物需通过更新新增版本
+func (c *CropCoefficientController) CreateCropCoefficient(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	if status, err := checkAdmin(c.DB, userID, "change crop coefficients"); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var coefficient models.CropNutrientCoefficient
+	if err := ctx.ShouldBindJSON(&coefficient); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	coefficient.CropCode = strings.TrimSpace(coefficient.CropCode)
+	coefficient.CropName = strings.TrimSpace(coefficient.CropName)
+	if err := validateCropCoefficient(coefficient); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var current, latest int
+	err = tx.QueryRow(
+		"SELECT COALESCE(SUM(is_current), 0), COALESCE(MAX(version), 0) FROM crop_nutrient_coefficients WHERE crop_code = ? FOR UPDATE",
+		coefficient.CropCode,
+	).Scan(&current, &latest)
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if current > 0 {
+		tx.Rollback()
+		ctx.JSON(http.StatusConflict, gin.H{"error": "crop already has coefficients, update them to create a new version"})
+		return
+	}
+
+	coefficient.Version = latest + 1
+	id, err := insertCropCoefficient(tx, coefficient, userID)
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.respondCropCoefficient(ctx, http.StatusCreated, id)
+}
+
+// UpdateCropCoefficient 以新版本替换作物的当前养分系数（管理员功能），旧版本保留
+func (c *CropCoefficientController) UpdateCropCoefficient(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	if status, err := checkAdmin(c.DB, userID, "change crop coefficients"); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var coefficient models.CropNutrientCoefficient
+	if err := ctx.ShouldBindJSON(&coefficient); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	coefficient.CropName = strings.TrimSpace(coefficient.CropName)
+	if err := validateCropCoefficient(coefficient); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var isCurrent bool
+	err = tx.QueryRow("SELECT crop_code, is_current FROM crop_nutrient_coefficients WHERE id = ? FOR UPDATE", id).
+		Scan(&coefficient.CropCode, &isCurrent)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Crop coefficient not found"})
+		return
+	}
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !isCurrent {
+		tx.Rollback()
+		ctx.JSON(http.StatusConflict, gin.H{"error": "only the current version can be updated"})
+		return
+	}
+
+	if err = tx.QueryRow("SELECT MAX(version) FROM crop_nutrient_coefficients WHERE crop_code = ?", coefficient.CropCode).
+		Scan(&coefficient.Version); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	coefficient.Version++
+
+	if _, err = tx.Exec("UPDATE crop_nutrient_coefficients SET is_current = FALSE WHERE id = ?", id); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	newID, err := insertCropCoefficient(tx, coefficient, userID)
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.respondCropCoefficient(ctx, http.StatusOK, newID)
+}
+
+// DeleteCropCoefficient 停用作物的当前养分系数（管理员功能），历史版本保留，已保存的记录仍可复现
+func (c *CropCoefficientController) DeleteCropCoefficient(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	if status, err := checkAdmin(c.DB, userID, "change crop coefficients"); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.DB.Exec("UPDATE crop_nutrient_coefficients SET is_current = FALSE WHERE id = ? AND is_current = TRUE", ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Crop coefficient not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// respondCropCoefficient 返回指定的作物养分系数版本
+func (c *CropCoefficientController) respondCropCoefficient(ctx *gin.Context, status int, id int64) {
+	coefficient, err := scanCropCoefficient(c.DB.QueryRow(
+		"SELECT "+cropCoefficientColumns+" FROM crop_nutrient_coefficients WHERE id = ?", id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(status, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": coefficient,
+	})
+}
+
+// insertCropCoefficient 插入一个作物养分系数版本并设为当前版本
+func insertCropCoefficient(tx *sql.Tx, coefficient models.CropNutrientCoefficient, userID int) (int64, error) {
+	result, err := tx.Exec(`
+		INSERT INTO crop_nutrient_coefficients (crop_code, crop_name, version, n_uptake, p2o5_uptake, k2o_uptake,
+			n_efficiency, p2o5_efficiency, k2o_efficiency, n_correction, p2o5_correction, k2o_correction,
+			is_current, note, created_by)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,TRUE,?,?)
+	`, coefficient.CropCode, coefficient.CropName, coefficient.Version,
+		coefficient.Uptake.N, coefficient.Uptake.P2O5, coefficient.Uptake.K2O,
+		coefficient.Efficiency.N, coefficient.Efficiency.P2O5, coefficient.Efficiency.K2O,
+		coefficient.Correction.N, coefficient.Correction.P2O5, coefficient.Correction.K2O,
+		coefficient.Note, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// validateCropCoefficient 校验作物养分系数数值范围
+func validateCropCoefficient(coefficient models.CropNutrientCoefficient) error {
+	if coefficient.Uptake.N < 0 || coefficient.Uptake.P2O5 < 0 || coefficient.Uptake.K2O < 0 {
+		return errors.New("uptake must not be negative")
+	}
+	if coefficient.Uptake.N+coefficient.Uptake.P2O5+coefficient.Uptake.K2O == 0 {
+		return errors.New("uptake must contain at least one nutrient")
+	}
+	for _, efficiency := range nutrientVector(coefficient.Efficiency) {
+		if efficiency <= 0 || efficiency > 100 {
+			return errors.New("efficiency must be between 0 and 100")
+		}
+	}
+	for _, correction := range nutrientVector(coefficient.Correction) {
+		if correction < 0 {
+			return errors.New("correction must not be negative")
+		}
+	}
+	return nil
+}
+
+// findCropCoefficient 查找作物养分系数：id 大于 0 时按版本查找，否则按作物编码或名称查找当前版本，未找到时返回 nil
+func findCropCoefficient(db *sql.DB, crop string, id int) (*models.CropNutrientCoefficient, error) {
+	var row *sql.Row
+	if id > 0 {
+		row = db.QueryRow("SELECT "+cropCoefficientColumns+" FROM crop_nutrient_coefficients WHERE id = ?", id)
+	} else {
+		crop = strings.TrimSpace(crop)
+		if crop == "" {
+			return nil, nil
+		}
+		row = db.QueryRow("SELECT "+cropCoefficientColumns+
+			" FROM crop_nutrient_coefficients WHERE (crop_code = ? OR crop_name = ?) AND is_current = TRUE LIMIT 1", crop, crop)
+	}
+	coefficient, err := scanCropCoefficient(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &coefficient, nil
+}
@@ -0,0 +1,720 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+	"go-mengtuobang/utils"
+)
+
+// FarmController 处理农场与地块相关的请求
+type FarmController struct {
+	DB *sql.DB
+}
+
+// NewFarmController 创建一个新的FarmController实例
+func NewFarmController(db *sql.DB) *FarmController {
+	return &FarmController{DB: db}
+}
+
+const farmColumns = `f.id, f.user_id, f.name, f.location, f.area, f.notes,
+	(SELECT COUNT(*) FROM plots p WHERE p.farm_id = f.id), f.created_at, f.updated_at`
+
+const plotColumns = `p.id, p.user_id, p.farm_id, f.name, p.name, p.area, p.boundary, p.centroid_lng, p.centroid_lat,
+	p.soil_type, p.current_crop, p.notes, p.created_at, p.updated_at`
+
+// scanFarm 扫描一行农场数据
+func scanFarm(scanner interface{ Scan(...interface{}) error }) (models.Farm, error) {
+	var farm models.Farm
+	var location, notes sql.NullString
+	var createdAt, updatedAt time.Time
+	err := scanner.Scan(&farm.ID, &farm.UserID, &farm.Name, &location, &farm.Area, &notes, &farm.PlotCount, &createdAt, &updatedAt)
+	if err != nil {
+		return farm, err
+	}
+	farm.Location = location.String
+	farm.Notes = notes.String
+	farm.CreatedAt = createdAt.Format(dateTimeLayout)
+	farm.UpdatedAt = updatedAt.Format(dateTimeLayout)
+	return farm, nil
+}
+
+// scanPlot 扫描一行地块数据
+func scanPlot(scanner interface{ Scan(...interface{}) error }) (models.Plot, error) {
+	var plot models.Plot
+	var boundary, soilType, currentCrop, notes sql.NullString
+	var centroidLng, centroidLat sql.NullFloat64
+	var createdAt, updatedAt time.Time
+	err := scanner.Scan(&plot.ID, &plot.UserID, &plot.FarmID, &plot.FarmName, &plot.Name, &plot.Area, &boundary,
+		&centroidLng, &centroidLat, &soilType, &currentCrop, &notes, &createdAt, &updatedAt)
+	if err != nil {
+		return plot, err
+	}
+	if boundary.String != "" {
+		plot.Boundary = json.RawMessage(boundary.String)
+		plot.CoordinateSystem = coordinateSystemWGS84
+	}
+	if centroidLng.Valid && centroidLat.Valid {
+		plot.Centroid = &models.GeoPoint{Lng: centroidLng.Float64, Lat: centroidLat.Float64}
+	}
+	plot.AreaHectares = math.Round(plot.Area/muPerHectare*10000) / 10000
+	plot.SoilType = soilType.String
+	plot.CurrentCrop = currentCrop.String
+	plot.Notes = notes.String
+	plot.CreatedAt = createdAt.Format(dateTimeLayout)
+	plot.UpdatedAt = updatedAt.Format(dateTimeLayout)
+	return plot, nil
+}
+
+// loadPlot 查询当前用户的地块，不属于当前用户时返回 sql.ErrNoRows
+func loadPlot(db *sql.DB, userID, id int) (models.Plot, error) {
+	return scanPlot(db.QueryRow("SELECT "+plotColumns+" FROM plots p JOIN farms f ON f.id = p.farm_id WHERE p.id = ? AND p.user_id = ?", id, userID))
+}
+
+// lockPlot 在写入记录的事务中再次校验关联地块的归属并加共享锁，保证提交前地块不会被删除或转移，
+// 返回失败时对应的 HTTP 状态码；id 为 0 时不做校验
+func lockPlot(tx *sql.Tx, userID, id int) (int, error) {
+	if id == 0 {
+		return 0, nil
+	}
+	var plotID int
+	err := tx.QueryRow("SELECT id FROM plots WHERE id = ? AND user_id = ? LOCK IN SHARE MODE", id, userID).Scan(&plotID)
+	if err == sql.ErrNoRows {
+		return http.StatusBadRequest, errors.New("plot not found")
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return 0, nil
+}
+
+// plotLocation 地块的位置描述，用于补全记录中的地点
+func plotLocation(plot models.Plot) string {
+	return strings.TrimSpace(plot.FarmName + " " + plot.Name)
+}
+
+// GetFarms 获取农场列表
+func (c *FarmController) GetFarms(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+
+	rows, err := c.DB.Query("SELECT "+farmColumns+" FROM farms f WHERE f.user_id = ? ORDER BY f.id", userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for farms"})
+		return
+	}
+	defer rows.Close()
+
+	farms := []models.Farm{}
+	for rows.Next() {
+		farm, err := scanFarm(rows)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning farm row"})
+			return
+		}
+		farms = append(farms, farm)
+	}
+
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating farm rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": farms,
+	})
+}
+
+// GetFarm 获取单个农场及其地块
+func (c *FarmController) GetFarm(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	farm, err := scanFarm(c.DB.QueryRow("SELECT "+farmColumns+" FROM farms f WHERE f.id = ? AND f.user_id = ?", id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Farm not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	crs, err := outputCoordinateSystem(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	plots, err := c.queryPlots(" WHERE p.user_id = ? AND p.farm_id = ?", 0, userID, id)
+	if err == nil {
+		err = convertPlotsCoordinates(plots, crs)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	farm.Plots = plots
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": farm,
+	})
+}
+
+// CreateFarm 创建农场
+func (c *FarmController) CreateFarm(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var farm models.Farm
+	if err := ctx.ShouldBindJSON(&farm); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.DB.Exec("INSERT INTO farms (user_id, name, location, area, notes) VALUES (?,?,?,?,?)",
+		userID, strings.TrimSpace(farm.Name), farm.Location, farm.Area, farm.Notes)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.respondFarm(ctx, http.StatusCreated, int(id), userID)
+}
+
+// UpdateFarm 更新农场信息
+func (c *FarmController) UpdateFarm(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var farm models.Farm
+	if err := ctx.ShouldBindJSON(&farm); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.farmOwnedBy(id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Farm not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	_, err = c.DB.Exec("UPDATE farms SET name = ?, location = ?, area = ?, notes = ? WHERE id = ? AND user_id = ?",
+		strings.TrimSpace(farm.Name), farm.Location, farm.Area, farm.Notes, id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.respondFarm(ctx, http.StatusOK, id, userID)
+}
+
+// DeleteFarm 删除农场，农场下仍有地块时不可删除
+func (c *FarmController) DeleteFarm(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := c.farmOwnedBy(id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Farm not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	var plotCount int
+	if err := c.DB.QueryRow("SELECT COUNT(*) FROM plots WHERE farm_id = ?", id).Scan(&plotCount); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if plotCount > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "farm still has plots, delete or move them first"})
+		return
+	}
+
+	if _, err := c.DB.Exec("DELETE FROM farms WHERE id = ? AND user_id = ?", id, userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// GetPlots 获取地块列表，可按农场、作物筛选
+func (c *FarmController) GetPlots(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	crs, err := outputCoordinateSystem(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	where := " WHERE p.user_id = ?"
+	params := []interface{}{userID}
+	if farmID := ctx.Query("farmId"); farmID != "" {
+		where += " AND p.farm_id = ?"
+		params = append(params, farmID)
+	}
+	if crop := ctx.Query("crop"); crop != "" {
+		where += " AND p.current_crop = ?"
+		params = append(params, crop)
+	}
+
+	plots, err := c.queryPlots(where, 0, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for plots"})
+		return
+	}
+	if err := convertPlotsCoordinates(plots, crs); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": plots,
+	})
+}
+
+// GetPlot 获取单个地块
+func (c *FarmController) GetPlot(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	crs, err := outputCoordinateSystem(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plot, err := loadPlot(c.DB, userID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Plot not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if err := convertPlotCoordinates(&plot, crs); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": plot,
+	})
+}
+
+// plotMapLimit 地图范围查询单次返回的最多地块数
+const plotMapLimit = 500
+
+// GetPlotMap 按地图可视范围查询地块，返回边界、质心及最近一次测土配肥与灌溉摘要
+//
+// bbox 为 minLng,minLat,maxLng,maxLat，crs 指定 bbox 与返回坐标的坐标系（wgs84 或 gcj02）。
+// 只返回已通过边界计算出外包矩形的地块，超过 plotMapLimit 个时只返回前 plotMapLimit 个并将 truncated 置为 true
+func (c *FarmController) GetPlotMap(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	crs, err := outputCoordinateSystem(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	parts := strings.Split(ctx.Query("bbox"), ",")
+	if len(parts) != 4 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "bbox must be minLng,minLat,maxLng,maxLat"})
+		return
+	}
+	var bbox [4]float64
+	for i, part := range parts {
+		if bbox[i], err = strconv.ParseFloat(strings.TrimSpace(part), 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "bbox must be minLng,minLat,maxLng,maxLat"})
+			return
+		}
+	}
+	if bbox[0] > bbox[2] || bbox[1] > bbox[3] {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "bbox minimum must not exceed maximum"})
+		return
+	}
+	if crs == coordinateSystemGCJ02 {
+		bbox[0], bbox[1] = utils.GCJ02ToWGS84(bbox[0], bbox[1])
+		bbox[2], bbox[3] = utils.GCJ02ToWGS84(bbox[2], bbox[3])
+	}
+
+	plots, err := c.queryPlots(" WHERE p.user_id = ? AND p.max_lng >= ? AND p.min_lng <= ? AND p.max_lat >= ? AND p.min_lat <= ?",
+		plotMapLimit+1, userID, bbox[0], bbox[2], bbox[1], bbox[3])
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for plots"})
+		return
+	}
+
+	truncated := len(plots) > plotMapLimit
+	if truncated {
+		plots = plots[:plotMapLimit]
+	}
+
+	items := make([]models.PlotMapItem, 0, len(plots))
+	index := map[int]int{}
+	ids := make([]interface{}, 0, len(plots))
+	for i := range plots {
+		if err := convertPlotCoordinates(&plots[i], crs); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		index[plots[i].ID] = len(items)
+		ids = append(ids, plots[i].ID)
+		items = append(items, models.PlotMapItem{Plot: plots[i]})
+	}
+
+	if len(ids) > 0 {
+		if err := c.attachPlotSummaries(userID, ids, items, index); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": gin.H{
+			"plots":     items,
+			"truncated": truncated,
+		},
+	})
+}
+
+// attachPlotSummaries 查询各地块最近一次测土配肥记录与灌溉记录的摘要
+func (c *FarmController) attachPlotSummaries(userID int, ids []interface{}, items []models.PlotMapItem, index map[int]int) error {
+	placeholders := "?" + strings.Repeat(",?", len(ids)-1)
+	params := append([]interface{}{userID, userID}, ids...)
+
+	soilRows, err := c.DB.Query(`
+		SELECT r.plot_id, r.id, r.timestamp, r.crop, r.supplement_n, r.supplement_p2o5, r.supplement_k2o,
+			r.soil_ph, r.soil_organic_matter
+		FROM records r
+		WHERE r.user_id = ? AND r.id IN (
+			SELECT MAX(id) FROM records WHERE user_id = ? AND plot_id IN (`+placeholders+`) GROUP BY plot_id
+		)
+	`, params...)
+	if err != nil {
+		return err
+	}
+	defer soilRows.Close()
+
+	for soilRows.Next() {
+		var plotID int
+		var summary models.PlotSoilSummary
+		var ph, organicMatter sql.NullFloat64
+		err := soilRows.Scan(&plotID, &summary.RecordID, &summary.Timestamp, &summary.Crop,
+			&summary.Supplement.N, &summary.Supplement.P2O5, &summary.Supplement.K2O, &ph, &organicMatter)
+		if err != nil {
+			return err
+		}
+		summary.PH, summary.OrganicMatter = nullFloatPtr(ph), nullFloatPtr(organicMatter)
+		items[index[plotID]].LatestSoil = &summary
+	}
+	if err := soilRows.Err(); err != nil {
+		return err
+	}
+
+	waterRows, err := c.DB.Query(`
+		SELECT w.plot_id, w.id, w.created_at, w.irrigation_mode, w.crop_type,
+			COALESCE((SELECT SUM(a.water_amount) FROM water_areas a WHERE a.record_id = w.id), 0)
+		FROM water_records w
+		WHERE w.user_id = ? AND w.id IN (
+			SELECT MAX(id) FROM water_records WHERE user_id = ? AND plot_id IN (`+placeholders+`) GROUP BY plot_id
+		)
+	`, params...)
+	if err != nil {
+		return err
+	}
+	defer waterRows.Close()
+
+	for waterRows.Next() {
+		var plotID int
+		var summary models.PlotIrrigationSummary
+		err := waterRows.Scan(&plotID, &summary.RecordID, &summary.CreatedAt, &summary.IrrigationMode, &summary.CropType,
+			&summary.WaterAmount)
+		if err != nil {
+			return err
+		}
+		items[index[plotID]].LatestIrrigation = &summary
+	}
+	return waterRows.Err()
+}
+
+// CreatePlot 在农场下创建地块
+func (c *FarmController) CreatePlot(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var plot models.Plot
+	if err := ctx.ShouldBindJSON(&plot); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	geometry, err := normalizePlot(&plot)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.farmOwnedBy(plot.FarmID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "farm not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	params := []interface{}{userID, plot.FarmID, plot.Name, plot.Area, nullableBoundary(plot.Boundary)}
+	params = append(params, geometryColumns(geometry)...)
+	params = append(params, plot.SoilType, plot.CurrentCrop, plot.Notes)
+	result, err := c.DB.Exec(`
+		INSERT INTO plots (user_id, farm_id, name, area, boundary, centroid_lng, centroid_lat, min_lng, min_lat, max_lng, max_lat,
+			soil_type, current_crop, notes)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+	`, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.respondPlot(ctx, http.StatusCreated, int(id), userID)
+}
+
+// UpdatePlot 更新地块信息，可通过 farmId 移动到其他农场，未提供边界时保留原边界与面积
+func (c *FarmController) UpdatePlot(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var plot models.Plot
+	if err := ctx.ShouldBindJSON(&plot); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// 请求中没有 boundary 字段时保留原边界，显式传 null 时清除边界
+	boundaryProvided := len(plot.Boundary) > 0
+	geometry, err := normalizePlot(&plot)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, err := loadPlot(c.DB, userID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Plot not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if !boundaryProvided && len(current.Boundary) > 0 {
+		plot.Boundary, plot.Area = current.Boundary, current.Area
+		// 早期未经解析保存的边界无法计算质心与外包矩形，按原样保留边界
+		geometry, _ = parsePlotBoundary(current.Boundary, coordinateSystemWGS84)
+	}
+	if err := c.farmOwnedBy(plot.FarmID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "farm not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	params := []interface{}{plot.FarmID, plot.Name, plot.Area, nullableBoundary(plot.Boundary)}
+	params = append(params, geometryColumns(geometry)...)
+	params = append(params, plot.SoilType, plot.CurrentCrop, plot.Notes, id, userID)
+	_, err = c.DB.Exec(`
+		UPDATE plots SET farm_id = ?, name = ?, area = ?, boundary = ?, centroid_lng = ?, centroid_lat = ?,
+			min_lng = ?, min_lat = ?, max_lng = ?, max_lat = ?, soil_type = ?, current_crop = ?, notes = ?
+		WHERE id = ? AND user_id = ?
+	`, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.respondPlot(ctx, http.StatusOK, id, userID)
+}
+
+// DeletePlot 删除地块，已关联的记录保留并解除关联
+func (c *FarmController) DeletePlot(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	// 记录表的 plot_id 外键为 ON DELETE SET NULL，删除地块时由数据库解除关联
+	result, err := c.DB.Exec("DELETE FROM plots WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Plot not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// farmOwnedBy 校验农场归属，不属于当前用户时返回 sql.ErrNoRows
+func (c *FarmController) farmOwnedBy(id, userID int) error {
+	var farmID int
+	return c.DB.QueryRow("SELECT id FROM farms WHERE id = ? AND user_id = ?", id, userID).Scan(&farmID)
+}
+
+// queryPlots 按条件查询地块列表，limit 大于 0 时限制返回条数
+func (c *FarmController) queryPlots(where string, limit int, params ...interface{}) ([]models.Plot, error) {
+	query := "SELECT " + plotColumns + " FROM plots p JOIN farms f ON f.id = p.farm_id" + where + " ORDER BY p.farm_id, p.id"
+	if limit > 0 {
+		query += " LIMIT " + strconv.Itoa(limit)
+	}
+	rows, err := c.DB.Query(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plots := []models.Plot{}
+	for rows.Next() {
+		plot, err := scanPlot(rows)
+		if err != nil {
+			return nil, err
+		}
+		plots = append(plots, plot)
+	}
+	return plots, rows.Err()
+}
+
+// respondFarm 返回指定的农场
+func (c *FarmController) respondFarm(ctx *gin.Context, status, id, userID int) {
+	farm, err := scanFarm(c.DB.QueryRow("SELECT "+farmColumns+" FROM farms f WHERE f.id = ? AND f.user_id = ?", id, userID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(status, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": farm,
+	})
+}
+
+// respondPlot 返回指定的地块
+func (c *FarmController) respondPlot(ctx *gin.Context, status, id, userID int) {
+	plot, err := loadPlot(c.DB, userID, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(status, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": plot,
+	})
+}
+
+// normalizePlot 去除名称首尾空白，解析边界并以边界面积覆盖地块面积
+func normalizePlot(plot *models.Plot) (*plotGeometry, error) {
+	plot.Name = strings.TrimSpace(plot.Name)
+	if plot.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	return applyPlotGeometry(plot)
+}
+
+// geometryColumns 地块质心与外包矩形列的值，依次为 centroid_lng、centroid_lat、min_lng、min_lat、max_lng、max_lat
+func geometryColumns(geometry *plotGeometry) []interface{} {
+	if geometry == nil {
+		return []interface{}{nil, nil, nil, nil, nil, nil}
+	}
+	return []interface{}{geometry.Centroid[0], geometry.Centroid[1], geometry.Min[0], geometry.Min[1], geometry.Max[0], geometry.Max[1]}
+}
+
+// outputCoordinateSystem 读取 crs 查询参数，指定返回坐标使用的坐标系，默认 wgs84
+func outputCoordinateSystem(ctx *gin.Context) (string, error) {
+	crs := ctx.DefaultQuery("crs", coordinateSystemWGS84)
+	if crs != coordinateSystemWGS84 && crs != coordinateSystemGCJ02 {
+		return "", errors.New("crs must be wgs84 or gcj02")
+	}
+	return crs, nil
+}
+
+// convertPlotsCoordinates 将地块列表的坐标转换为指定坐标系
+func convertPlotsCoordinates(plots []models.Plot, crs string) error {
+	for i := range plots {
+		if err := convertPlotCoordinates(&plots[i], crs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullableBoundary 未提供边界时写入 NULL
+func nullableBoundary(boundary json.RawMessage) interface{} {
+	if len(boundary) == 0 || string(boundary) == "null" {
+		return nil
+	}
+	return string(boundary)
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"go-mengtuobang/models"
+)
+
+const (
+	// fertigationPreWaterShare、fertigationFlushShare 注肥前清水湿润与注肥后清水冲洗占灌溉时长的比例
+	fertigationPreWaterShare = 0.25
+	fertigationFlushShare    = 0.25
+	// defaultMaxFertigationPPM、defaultMaxFertigationEC 默认允许的最高肥液浓度与电导率
+	defaultMaxFertigationPPM = 3000.0
+	defaultMaxFertigationEC  = 3.0
+	// ppmPerEC 肥液浓度与电导率的经验换算系数（mg/L 每 mS/cm）
+	ppmPerEC = 640.0
+)
+
+// planFertigation 按“先清水、中间注肥、最后冲洗”的原则生成各区域注肥计划
+//
+// 注肥窗口为灌溉时长的中间一半，排空时长 = 施肥罐容积（L）/ 注肥流量（L/h），
+// 肥液浓度（mg/L）= 肥料用量（kg）× 10⁶ / 注肥期间的灌溉水量（L）
+func planFertigation(req models.FertigationRequest) ([]models.FertigationPlan, error) {
+	if len(req.Areas) == 0 {
+		return nil, errors.New("at least one area is required")
+	}
+	maxPPM, maxEC := req.MaxPPM, req.MaxEC
+	if maxPPM <= 0 {
+		maxPPM = defaultMaxFertigationPPM
+	}
+	if maxEC <= 0 {
+		maxEC = defaultMaxFertigationEC
+	}
+
+	// 未提供灌溉时长的区域按土壤参数计算
+	var calculated []models.IrrigationAreaResult
+	for _, area := range req.Areas {
+		if strings.TrimSpace(area.IrrigationTime) == "" {
+			var err error
+			if calculated, err = calculateIrrigation(req.IrrigationRequest); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	plans := make([]models.FertigationPlan, 0, len(req.Areas))
+	for i, area := range req.Areas {
+		irrigationTime := area.IrrigationTime
+		if strings.TrimSpace(irrigationTime) == "" {
+			irrigationTime = calculated[i].IrrigationTime
+		}
+		runtime, err := strconv.ParseFloat(strings.TrimSpace(irrigationTime), 64)
+		if err != nil || runtime < 0 {
+			return nil, fmt.Errorf("invalid irrigationTime of area %d", area.AreaId)
+		}
+		if area.TankSize <= 0 {
+			return nil, fmt.Errorf("tankSize of area %d must be greater than 0", area.AreaId)
+		}
+		if area.FertilizerFlowRate <= 0 {
+			return nil, fmt.Errorf("fertilizerFlowRate of area %d must be greater than 0", area.AreaId)
+		}
+
+		start := runtime * fertigationPreWaterShare
+		end := runtime * (1 - fertigationFlushShare)
+		injection := area.TankSize / area.FertilizerFlowRate
+		plan := models.FertigationPlan{
+			AreaId:              area.AreaId,
+			IrrigationTime:      round2(runtime),
+			FertilizerStartTime: round2(start),
+			FertilizerEndTime:   round2(end),
+			FertilizerTotalTime: round2(injection),
+			WindowTime:          round2(end - start),
+			TankEmptied:         injection <= end-start,
+			ConcentrationOK:     true,
+		}
+		if !plan.TankEmptied {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"tank needs %.2f h to empty but the injection window is only %.2f h, increase the injector flow rate or split the application",
+				injection, end-start))
+		}
+
+		flowRate := area.WaterFlowRate
+		if flowRate <= 0 {
+			flowRate = area.FlowRate
+		}
+		if area.FertilizerAmount > 0 && flowRate > 0 && injection > 0 {
+			// 注肥期间流经管道的水量（L），罐内溶液体积一并计入
+			water := flowRate*1000*math.Min(injection, end-start) + area.TankSize
+			plan.PPM = round2(area.FertilizerAmount * 1e6 / water)
+			plan.EC = round2(plan.PPM / ppmPerEC)
+			if plan.PPM > maxPPM || plan.EC > maxEC {
+				plan.ConcentrationOK = false
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+					"concentration %.0f mg/L (EC %.2f mS/cm) exceeds the limit of %.0f mg/L (EC %.2f mS/cm), risk of root burn",
+					plan.PPM, plan.EC, maxPPM, maxEC))
+			}
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// fillFertigationTimes 为未填写注肥时间的区域补全注肥开始时间与注肥时长
+func fillFertigationTimes(req *models.IrrigationRequest) {
+	for i, area := range req.Areas {
+		if area.FertilizerStartTime != "" || area.TankSize <= 0 || area.FertilizerFlowRate <= 0 {
+			continue
+		}
+		single := models.FertigationRequest{IrrigationRequest: *req}
+		single.Areas = []models.AreaData{area}
+		plans, err := planFertigation(single)
+		if err != nil {
+			continue
+		}
+		req.Areas[i].FertilizerStartTime = formatFloat(plans[0].FertilizerStartTime)
+		req.Areas[i].FertilizerTotalTime = formatFloat(plans[0].FertilizerTotalTime)
+	}
+}
@@ -0,0 +1,745 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+)
+
+// FertilizationPlanController 处理季节施肥计划相关的请求
+type FertilizationPlanController struct {
+	DB *sql.DB
+}
+
+// NewFertilizationPlanController 创建一个新的FertilizationPlanController实例
+func NewFertilizationPlanController(db *sql.DB) *FertilizationPlanController {
+	return &FertilizationPlanController{DB: db}
+}
+
+const fertilizationPlanColumns = "id, user_id, record_id, plot_id, name, crop, season, sowing_date, plot_size, target_n, target_p2o5, target_k2o, status, notes, created_at"
+
+// GetCropGrowthStages 获取作物默认施肥时期，未配置的作物返回通用的基肥加追肥
+func (c *FertilizationPlanController) GetCropGrowthStages(ctx *gin.Context) {
+	crop := strings.TrimSpace(ctx.Query("crop"))
+	if crop == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "crop is required"})
+		return
+	}
+
+	stages, err := loadCropGrowthStages(c.DB, crop)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for crop growth stages"})
+		return
+	}
+	if len(stages) == 0 {
+		stages = genericGrowthStages
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": stages,
+	})
+}
+
+// CreateFertilizationPlan 将全季补充量按施肥时期拆分，生成带日期、产品与用量的施肥计划
+func (c *FertilizationPlanController) CreateFertilizationPlan(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var req models.FertilizationPlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sowingDate, err := time.ParseInLocation(dateLayout, req.SowingDate, time.Local)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "sowingDate must be formatted as YYYY-MM-DD"})
+		return
+	}
+
+	// 引用测土配肥记录时，默认以记录的作物、面积与补充量生成计划
+	if req.RecordID > 0 {
+		var crop string
+		var plotSize float64
+		var supplement models.NutrientAmount
+		var plotID sql.NullInt64
+		err := c.DB.QueryRow(
+			"SELECT crop, plot_size, supplement_n, supplement_p2o5, supplement_k2o, plot_id FROM records WHERE id = ? AND user_id = ?",
+			req.RecordID, userID,
+		).Scan(&crop, &plotSize, &supplement.N, &supplement.P2O5, &supplement.K2O, &plotID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		if req.Crop == "" {
+			req.Crop = crop
+		}
+		if req.PlotSize == 0 {
+			req.PlotSize = plotSize
+		}
+		if req.Target == nil {
+			req.Target = &supplement
+		}
+		if req.PlotID == 0 {
+			req.PlotID = int(plotID.Int64)
+		}
+	}
+
+	// 关联地块时，未填写的作物与面积取地块信息
+	if req.PlotID > 0 {
+		plot, err := loadPlot(c.DB, userID, req.PlotID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "plot not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		if req.Crop == "" {
+			req.Crop = plot.CurrentCrop
+		}
+		if req.PlotSize == 0 {
+			req.PlotSize = plot.Area
+		}
+	}
+
+	req.Crop = strings.TrimSpace(req.Crop)
+	if req.Crop == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "crop is required when neither recordId nor plotId provides one"})
+		return
+	}
+	if req.Target == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "target is required when recordId is not set"})
+		return
+	}
+	if req.Target.N < 0 || req.Target.P2O5 < 0 || req.Target.K2O < 0 || req.PlotSize < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "target and plotSize must not be negative"})
+		return
+	}
+
+	stages := req.Stages
+	if len(stages) == 0 {
+		stages, err = loadCropGrowthStages(c.DB, req.Crop)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(stages) == 0 {
+			stages = genericGrowthStages
+		}
+	}
+	if err := validateGrowthStages(stages); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.ProductIDs = uniqueIDs(req.ProductIDs)
+	products, err := loadFertilizerProducts(c.DB, userID, req.ProductIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.ProductIDs) > 0 && len(products) < len(req.ProductIDs) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "fertilizer product not found"})
+		return
+	}
+
+	items, warnings, err := planFertilization(*req.Target, stages, products, sowingDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name == "" {
+		req.Name = req.Crop + " " + strings.TrimSpace(req.Season+" "+req.SowingDate)
+	}
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO fertilization_plans (user_id, record_id, plot_id, name, crop, season, sowing_date, plot_size,
+			target_n, target_p2o5, target_k2o, status, notes)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?)
+	`, userID, nullableID(req.RecordID), nullableID(req.PlotID), req.Name, req.Crop, req.Season, req.SowingDate, req.PlotSize,
+		req.Target.N, req.Target.P2O5, req.Target.K2O, models.PlanStatusActive, req.Notes)
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	planID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, item := range items {
+		_, err := tx.Exec(`
+			INSERT INTO fertilization_plan_items (plan_id, stage_code, stage_name, sequence, planned_date, product_id,
+				product_name, dose, n, p2o5, k2o)
+			VALUES (?,?,?,?,?,?,?,?,?,?,?)
+		`, planID, item.StageCode, item.StageName, item.Sequence, item.PlannedDate, nullableID(item.ProductID),
+			item.ProductName, item.Dose, item.Nutrients.N, item.Nutrients.P2O5, item.Nutrients.K2O)
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := c.loadFertilizationPlan(int(planID), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	plan.Warnings = warnings
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": plan,
+	})
+}
+
+// GetFertilizationPlans 获取施肥计划列表
+func (c *FertilizationPlanController) GetFertilizationPlans(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+
+	where := " WHERE user_id = ?"
+	params := []interface{}{userID}
+	if status := ctx.Query("status"); status != "" {
+		where += " AND status = ?"
+		params = append(params, status)
+	}
+	if crop := ctx.Query("crop"); crop != "" {
+		where += " AND crop = ?"
+		params = append(params, crop)
+	}
+	if plotID := ctx.Query("plotId"); plotID != "" {
+		where += " AND plot_id = ?"
+		params = append(params, plotID)
+	}
+
+	var totalCount int
+	if err := c.DB.QueryRow("SELECT COUNT(*) FROM fertilization_plans"+where, params...).Scan(&totalCount); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting total count"})
+		return
+	}
+
+	query := "SELECT " + fertilizationPlanColumns + " FROM fertilization_plans" + where +
+		" ORDER BY sowing_date DESC, id DESC LIMIT ? OFFSET ?"
+	params = append(params, pageSize, (page-1)*pageSize)
+
+	rows, err := c.DB.Query(query, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for fertilization plans"})
+		return
+	}
+	defer rows.Close()
+
+	plans := []models.FertilizationPlan{}
+	for rows.Next() {
+		plan, err := scanFertilizationPlan(rows)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning fertilization plan row"})
+			return
+		}
+		plans = append(plans, plan)
+	}
+
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating fertilization plan rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":        200,
+		"msg":         "ok",
+		"data":        plans,
+		"totalCount":  totalCount,
+		"currentPage": page,
+		"pageSize":    pageSize,
+	})
+}
+
+// GetFertilizationPlan 获取单个施肥计划，包含计划施肥次、实际施用记录与剩余养分预算
+func (c *FertilizationPlanController) GetFertilizationPlan(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	plan, err := c.loadFertilizationPlan(id, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Fertilization plan not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": plan,
+	})
+}
+
+// UpdateFertilizationPlan 更新施肥计划名称、季节、状态与备注，未提供的字段保持原值
+func (c *FertilizationPlanController) UpdateFertilizationPlan(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req struct {
+		Name   *string `json:"name"`
+		Season *string `json:"season"`
+		Status string  `json:"status" binding:"omitempty,oneof=active completed"`
+		Notes  *string `json:"notes"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.planOwnedBy(id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Fertilization plan not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// 名称、季节、备注为 null 或未提供时保持原值，状态为空时保持原值
+	var status interface{}
+	if req.Status != "" {
+		status = req.Status
+	}
+	_, err = c.DB.Exec(`
+		UPDATE fertilization_plans
+		SET name = COALESCE(?, name), season = COALESCE(?, season), status = COALESCE(?, status), notes = COALESCE(?, notes)
+		WHERE id = ? AND user_id = ?
+	`, req.Name, req.Season, status, req.Notes, id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.respondFertilizationPlan(ctx, http.StatusOK, id, userID)
+}
+
+// DeleteFertilizationPlan 删除施肥计划及其施肥次与施用记录
+func (c *FertilizationPlanController) DeleteFertilizationPlan(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := tx.Exec("DELETE FROM fertilization_plans WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		tx.Rollback()
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Fertilization plan not found"})
+		return
+	}
+
+	for _, table := range []string{"fertilization_plan_items", "fertilization_applications"} {
+		if _, err = tx.Exec("DELETE FROM "+table+" WHERE plan_id = ?", id); err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// UpdateFertilizationPlanItem 调整计划施肥次的日期、产品与用量，选定产品时按用量与含量重新计算养分
+func (c *FertilizationPlanController) UpdateFertilizationPlanItem(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	itemID, err := strconv.Atoi(ctx.Param("itemId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	var req models.FertilizationPlanItemUpdate
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := time.Parse(dateLayout, req.PlannedDate); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "plannedDate must be formatted as YYYY-MM-DD"})
+		return
+	}
+
+	if err := c.planOwnedBy(id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Fertilization plan not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	var existing int
+	err = c.DB.QueryRow("SELECT id FROM fertilization_plan_items WHERE id = ? AND plan_id = ?", itemID, id).Scan(&existing)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Plan item not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	var productName string
+	nutrients := req.Nutrients
+	if req.ProductID > 0 {
+		product, err := c.loadProduct(req.ProductID, userID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "fertilizer product not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		productName = product.Name
+		nutrients = doseNutrients(req.Dose, productContent(product))
+	} else if nutrients.N < 0 || nutrients.P2O5 < 0 || nutrients.K2O < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "nutrients must not be negative"})
+		return
+	}
+
+	_, err = c.DB.Exec(`
+		UPDATE fertilization_plan_items SET planned_date = ?, product_id = ?, product_name = ?, dose = ?, n = ?, p2o5 = ?, k2o = ?
+		WHERE id = ? AND plan_id = ?
+	`, req.PlannedDate, nullableID(req.ProductID), productName, req.Dose, nutrients.N, nutrients.P2O5, nutrients.K2O, itemID, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.respondFertilizationPlan(ctx, http.StatusOK, id, userID)
+}
+
+// AddFertilizationApplication 记录一次实际施肥，可关联计划施肥次，养分量按用量与产品含量计算
+func (c *FertilizationPlanController) AddFertilizationApplication(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var application models.FertilizationApplication
+	if err := ctx.ShouldBindJSON(&application); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if application.AppliedAt == "" {
+		application.AppliedAt = time.Now().Format(dateLayout)
+	} else if _, err := time.Parse(dateLayout, application.AppliedAt); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "appliedAt must be formatted as YYYY-MM-DD"})
+		return
+	}
+
+	if err := c.planOwnedBy(id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Fertilization plan not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// 关联计划施肥次时，未指定产品则沿用计划中的产品
+	if application.ItemID > 0 {
+		var itemProductID sql.NullInt64
+		err := c.DB.QueryRow("SELECT product_id FROM fertilization_plan_items WHERE id = ? AND plan_id = ?",
+			application.ItemID, id).Scan(&itemProductID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "itemId does not belong to this plan"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		if application.ProductID == 0 && application.Content == nil {
+			application.ProductID = int(itemProductID.Int64)
+		}
+	}
+
+	var content models.NutrientAmount
+	if application.ProductID > 0 {
+		product, err := c.loadProduct(application.ProductID, userID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "fertilizer product not found"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		application.ProductName = product.Name
+		content = productContent(product)
+	} else {
+		if application.Content == nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "content is required when productId is not set"})
+			return
+		}
+		content = *application.Content
+		for _, v := range nutrientVector(content) {
+			if v < 0 || v > 100 {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "content must be between 0 and 100"})
+				return
+			}
+		}
+	}
+	application.Content = &content
+	application.Nutrients = doseNutrients(application.Dose, content)
+
+	result, err := c.DB.Exec(`
+		INSERT INTO fertilization_applications (plan_id, item_id, applied_at, product_id, product_name, dose, n, p2o5, k2o, note)
+		VALUES (?,?,?,?,?,?,?,?,?,?)
+	`, id, nullableID(application.ItemID), application.AppliedAt, nullableID(application.ProductID), application.ProductName,
+		application.Dose, application.Nutrients.N, application.Nutrients.P2O5, application.Nutrients.K2O, application.Note)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := result.LastInsertId(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.respondFertilizationPlan(ctx, http.StatusCreated, id, userID)
+}
+
+// DeleteFertilizationApplication 删除一条实际施用记录
+func (c *FertilizationPlanController) DeleteFertilizationApplication(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := c.planOwnedBy(id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Fertilization plan not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	result, err := c.DB.Exec("DELETE FROM fertilization_applications WHERE id = ? AND plan_id = ?", ctx.Param("applicationId"), id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return
+	}
+
+	c.respondFertilizationPlan(ctx, http.StatusOK, id, userID)
+}
+
+// planOwnedBy 校验施肥计划归属，不属于当前用户时返回 sql.ErrNoRows
+func (c *FertilizationPlanController) planOwnedBy(id, userID int) error {
+	var planID int
+	return c.DB.QueryRow("SELECT id FROM fertilization_plans WHERE id = ? AND user_id = ?", id, userID).Scan(&planID)
+}
+
+// loadProduct 加载当前用户可用的单个化肥产品
+func (c *FertilizationPlanController) loadProduct(id, userID int) (models.FertilizerProduct, error) {
+	products, err := loadFertilizerProducts(c.DB, userID, []int{id})
+	if err != nil {
+		return models.FertilizerProduct{}, err
+	}
+	if len(products) == 0 {
+		return models.FertilizerProduct{}, sql.ErrNoRows
+	}
+	return products[0], nil
+}
+
+// respondFertilizationPlan 返回指定的施肥计划
+func (c *FertilizationPlanController) respondFertilizationPlan(ctx *gin.Context, status, id, userID int) {
+	plan, err := c.loadFertilizationPlan(id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(status, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": plan,
+	})
+}
+
+// loadFertilizationPlan 查询施肥计划、施肥次与施用记录，并汇总养分预算
+func (c *FertilizationPlanController) loadFertilizationPlan(id, userID int) (models.FertilizationPlan, error) {
+	row := c.DB.QueryRow("SELECT "+fertilizationPlanColumns+" FROM fertilization_plans WHERE id = ? AND user_id = ?", id, userID)
+	plan, err := scanFertilizationPlan(row)
+	if err != nil {
+		return plan, err
+	}
+
+	rows, err := c.DB.Query(`
+		SELECT id, plan_id, stage_code, stage_name, sequence, planned_date, product_id, product_name, dose, n, p2o5, k2o
+		FROM fertilization_plan_items WHERE plan_id = ? ORDER BY planned_date, sequence, id
+	`, id)
+	if err != nil {
+		return plan, err
+	}
+	defer rows.Close()
+
+	plan.Items = []models.FertilizationPlanItem{}
+	for rows.Next() {
+		var item models.FertilizationPlanItem
+		var plannedDate time.Time
+		var productID sql.NullInt64
+		var productName sql.NullString
+		err := rows.Scan(&item.ID, &item.PlanID, &item.StageCode, &item.StageName, &item.Sequence, &plannedDate,
+			&productID, &productName, &item.Dose, &item.Nutrients.N, &item.Nutrients.P2O5, &item.Nutrients.K2O)
+		if err != nil {
+			return plan, err
+		}
+		item.PlannedDate = plannedDate.Format(dateLayout)
+		item.ProductID = int(productID.Int64)
+		item.ProductName = productName.String
+		plan.Items = append(plan.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return plan, err
+	}
+
+	applicationRows, err := c.DB.Query(`
+		SELECT id, plan_id, item_id, applied_at, product_id, product_name, dose, n, p2o5, k2o, note, created_at
+		FROM fertilization_applications WHERE plan_id = ? ORDER BY applied_at, id
+	`, id)
+	if err != nil {
+		return plan, err
+	}
+	defer applicationRows.Close()
+
+	plan.Applications = []models.FertilizationApplication{}
+	for applicationRows.Next() {
+		var application models.FertilizationApplication
+		var appliedAt, createdAt time.Time
+		var itemID, productID sql.NullInt64
+		var productName, note sql.NullString
+		err := applicationRows.Scan(&application.ID, &application.PlanID, &itemID, &appliedAt, &productID, &productName,
+			&application.Dose, &application.Nutrients.N, &application.Nutrients.P2O5, &application.Nutrients.K2O, &note, &createdAt)
+		if err != nil {
+			return plan, err
+		}
+		application.ItemID = int(itemID.Int64)
+		application.AppliedAt = appliedAt.Format(dateLayout)
+		application.ProductID = int(productID.Int64)
+		application.ProductName = productName.String
+		application.Note = note.String
+		application.CreatedAt = createdAt.Format(dateTimeLayout)
+		plan.Applications = append(plan.Applications, application)
+	}
+	if err := applicationRows.Err(); err != nil {
+		return plan, err
+	}
+
+	summarizeFertilizationPlan(&plan, time.Now())
+	return plan, nil
+}
+
+// scanFertilizationPlan 扫描一行施肥计划数据
+func scanFertilizationPlan(scanner interface{ Scan(...interface{}) error }) (models.FertilizationPlan, error) {
+	var plan models.FertilizationPlan
+	var recordID, plotID sql.NullInt64
+	var season, notes sql.NullString
+	var sowingDate, createdAt time.Time
+	err := scanner.Scan(&plan.ID, &plan.UserID, &recordID, &plotID, &plan.Name, &plan.Crop, &season, &sowingDate, &plan.PlotSize,
+		&plan.Target.N, &plan.Target.P2O5, &plan.Target.K2O, &plan.Status, &notes, &createdAt)
+	if err != nil {
+		return plan, err
+	}
+	plan.RecordID = int(recordID.Int64)
+	plan.PlotID = int(plotID.Int64)
+	plan.Season = season.String
+	plan.Notes = notes.String
+	plan.SowingDate = sowingDate.Format(dateLayout)
+	plan.CreatedAt = createdAt.Format(dateTimeLayout)
+	return plan, nil
+}
+
+// productContent 化肥产品的养分含量（%）
+func productContent(product models.FertilizerProduct) models.NutrientAmount {
+	return models.NutrientAmount{N: product.N, P2O5: product.P2O5, K2O: product.K2O}
+}
+
+// doseNutrients 按用量（kg）与养分含量（%）计算纯养分量
+func doseNutrients(dose float64, content models.NutrientAmount) models.NutrientAmount {
+	return models.NutrientAmount{
+		N:    round2(dose * content.N / 100),
+		P2O5: round2(dose * content.P2O5 / 100),
+		K2O:  round2(dose * content.K2O / 100),
+	}
+}
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go-mengtuobang/models"
+)
+
+// genericGrowthStages 作物没有默认施肥时期时采用基肥加一次追肥，比例与配方优化的默认基肥比例一致
+var genericGrowthStages = []models.CropGrowthStage{
+	{StageCode: "basal", StageName: "基肥", Sequence: 1, Basal: true, Share: defaultBasalShare},
+	{StageCode: "topdressing", StageName: "追肥", Sequence: 2, DaysAfterSowing: 40, Share: models.NutrientAmount{
+		N: 100 - defaultBasalShare.N, P2O5: 100 - defaultBasalShare.P2O5, K2O: 100 - defaultBasalShare.K2O,
+	}},
+}
+
+// loadCropGrowthStages 按作物编码或名称加载默认施肥时期
+func loadCropGrowthStages(db *sql.DB, crop string) ([]models.CropGrowthStage, error) {
+	rows, err := db.Query(`
+		SELECT s.id, s.crop_code, s.stage_code, s.stage_name, s.sequence, s.days_after_sowing, s.is_basal,
+			s.n_share, s.p2o5_share, s.k2o_share
+		FROM crop_growth_stages s
+		WHERE s.crop_code = ? OR s.crop_code = (SELECT code FROM crops WHERE name = ? LIMIT 1)
+		ORDER BY s.sequence
+	`, crop, crop)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stages := []models.CropGrowthStage{}
+	for rows.Next() {
+		var stage models.CropGrowthStage
+		err := rows.Scan(&stage.ID, &stage.CropCode, &stage.StageCode, &stage.StageName, &stage.Sequence,
+			&stage.DaysAfterSowing, &stage.Basal, &stage.Share.N, &stage.Share.P2O5, &stage.Share.K2O)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+	return stages, rows.Err()
+}
+
+// validateGrowthStages 校验施肥时期：编码不重复，各养分在全部时期的比例之和为 100
+func validateGrowthStages(stages []models.CropGrowthStage) error {
+	seen := map[string]bool{}
+	var total [3]float64
+	for _, stage := range stages {
+		if seen[stage.StageCode] {
+			return fmt.Errorf("duplicate stage %s", stage.StageCode)
+		}
+		seen[stage.StageCode] = true
+		if stage.DaysAfterSowing < 0 {
+			return fmt.Errorf("daysAfterSowing of stage %s must not be negative", stage.StageCode)
+		}
+		for j, share := range nutrientVector(stage.Share) {
+			if share < 0 || share > 100 {
+				return fmt.Errorf("share of stage %s must be between 0 and 100", stage.StageCode)
+			}
+			total[j] += share
+		}
+	}
+	for j, name := range nutrientNames {
+		if math.Abs(total[j]-100) > 0.5 {
+			return fmt.Errorf("%s shares of all stages must add up to 100, got %s", name, formatFloat(total[j]))
+		}
+	}
+	return nil
+}
+
+// stageProducts 筛选可在该时期施用的产品，并将施用方式限定为该时期，使配方只落在对应阶段
+func stageProducts(products []models.FertilizerProduct, basal bool) []models.FertilizerProduct {
+	usage, excluded := models.FertilizerUsageTopdressing, models.FertilizerUsageBasal
+	if basal {
+		usage, excluded = models.FertilizerUsageBasal, models.FertilizerUsageTopdressing
+	}
+	var selected []models.FertilizerProduct
+	for _, product := range products {
+		if product.Usage == excluded {
+			continue
+		}
+		product.Usage = usage
+		selected = append(selected, product)
+	}
+	return selected
+}
+
+// planFertilization 按施肥时期拆分全季化肥养分，并为每个时期求解最低成本的产品组合
+//
+// 各时期的养分量 = 全季目标 × 时期比例，计划日期 = 播种日期 + 距播种天数。
+// 没有可用产品或无法配出时，该时期只给出纯养分量，并在 warnings 中说明原因
+func planFertilization(target models.NutrientAmount, stages []models.CropGrowthStage, products []models.FertilizerProduct,
+	sowingDate time.Time) ([]models.FertilizationPlanItem, []string, error) {
+	sorted := append([]models.CropGrowthStage(nil), stages...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		if sorted[a].DaysAfterSowing != sorted[b].DaysAfterSowing {
+			return sorted[a].DaysAfterSowing < sorted[b].DaysAfterSowing
+		}
+		return sorted[a].Sequence < sorted[b].Sequence
+	})
+
+	items := []models.FertilizationPlanItem{}
+	var warnings []string
+	for i, stage := range sorted {
+		stageTarget := models.NutrientAmount{
+			N:    round2(target.N * stage.Share.N / 100),
+			P2O5: round2(target.P2O5 * stage.Share.P2O5 / 100),
+			K2O:  round2(target.K2O * stage.Share.K2O / 100),
+		}
+		if stageTarget.N+stageTarget.P2O5+stageTarget.K2O == 0 {
+			continue
+		}
+		base := models.FertilizationPlanItem{
+			StageCode:   stage.StageCode,
+			StageName:   stage.StageName,
+			Sequence:    i + 1,
+			PlannedDate: sowingDate.AddDate(0, 0, stage.DaysAfterSowing).Format(dateLayout),
+		}
+
+		candidates := stageProducts(products, stage.Basal)
+		if len(candidates) == 0 {
+			if len(products) > 0 {
+				warnings = append(warnings, fmt.Sprintf("%s: no selected product can be applied at this stage", stage.StageName))
+			}
+			item := base
+			item.Nutrients = stageTarget
+			items = append(items, item)
+			continue
+		}
+
+		share := models.NutrientAmount{}
+		if stage.Basal {
+			share = models.NutrientAmount{N: 100, P2O5: 100, K2O: 100}
+		}
+		blend, err := optimizeFertilizerBlend(models.FertilizerBlendRequest{Target: stageTarget, BasalShare: &share}, candidates)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !blend.Feasible {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", stage.StageName, strings.Join(blend.Reasons, "; ")))
+			item := base
+			item.Nutrients = stageTarget
+			items = append(items, item)
+			continue
+		}
+		doses := blend.Topdressing
+		if stage.Basal {
+			doses = blend.Basal
+		}
+		for _, dose := range doses {
+			item := base
+			item.ProductID = dose.ProductID
+			item.ProductName = dose.Name
+			item.Dose = dose.Weight
+			item.Nutrients = dose.Nutrients
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil, nil, errors.New("no stage receives any nutrient, check the target and stage shares")
+	}
+	return items, warnings, nil
+}
+
+// summarizeFertilizationPlan 汇总实际施用量，计算各施肥次的执行状态与全季养分预算
+func summarizeFertilizationPlan(plan *models.FertilizationPlan, today time.Time) {
+	add := func(sum *models.NutrientAmount, amount models.NutrientAmount) {
+		sum.N += amount.N
+		sum.P2O5 += amount.P2O5
+		sum.K2O += amount.K2O
+	}
+
+	budget := &models.FertilizationBudget{Target: plan.Target}
+	applied := map[int]*models.NutrientAmount{}
+	for _, application := range plan.Applications {
+		add(&budget.Applied, application.Nutrients)
+		if application.ItemID > 0 {
+			if applied[application.ItemID] == nil {
+				applied[application.ItemID] = &models.NutrientAmount{}
+			}
+			add(applied[application.ItemID], application.Nutrients)
+		}
+	}
+
+	todayText := today.Format(dateLayout)
+	for i := range plan.Items {
+		item := &plan.Items[i]
+		add(&budget.Planned, item.Nutrients)
+		switch {
+		case applied[item.ID] != nil:
+			item.Status = models.PlanItemApplied
+			item.Applied = models.NutrientAmount{N: round2(applied[item.ID].N), P2O5: round2(applied[item.ID].P2O5), K2O: round2(applied[item.ID].K2O)}
+		case item.PlannedDate < todayText:
+			item.Status = models.PlanItemOverdue
+		default:
+			item.Status = models.PlanItemPlanned
+		}
+	}
+
+	progress := func(applied, target float64) float64 {
+		if target <= 0 {
+			return 0
+		}
+		return round2(applied / target * 100)
+	}
+	budget.Planned = models.NutrientAmount{N: round2(budget.Planned.N), P2O5: round2(budget.Planned.P2O5), K2O: round2(budget.Planned.K2O)}
+	budget.Applied = models.NutrientAmount{N: round2(budget.Applied.N), P2O5: round2(budget.Applied.P2O5), K2O: round2(budget.Applied.K2O)}
+	budget.Remaining = models.NutrientAmount{
+		N:    round2(plan.Target.N - budget.Applied.N),
+		P2O5: round2(plan.Target.P2O5 - budget.Applied.P2O5),
+		K2O:  round2(plan.Target.K2O - budget.Applied.K2O),
+	}
+	budget.Progress = models.NutrientAmount{
+		N:    progress(budget.Applied.N, plan.Target.N),
+		P2O5: progress(budget.Applied.P2O5, plan.Target.P2O5),
+		K2O:  progress(budget.Applied.K2O, plan.Target.K2O),
+	}
+	plan.Budget = budget
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"go-mengtuobang/models"
+	"go-mengtuobang/utils"
+)
+
+// defaultBlendTolerance 配方养分总量默认允许超出目标的比例（%）
+const defaultBlendTolerance = 10.0
+
+// defaultBasalShare 各养分作基肥施用的默认比例（%），磷肥全部基施
+var defaultBasalShare = models.NutrientAmount{N: 40, P2O5: 100, K2O: 60}
+
+// nutrientNames 氮、磷、钾的名称，与 nutrientVector 的顺序一致
+var nutrientNames = [3]string{"N", "P2O5", "K2O"}
+
+// nutrientVector 将养分量转换为 N、P2O5、K2O 顺序的数组
+func nutrientVector(amount models.NutrientAmount) [3]float64 {
+	return [3]float64{amount.N, amount.P2O5, amount.K2O}
+}
+
+// productNutrients 化肥产品的 N、P2O5、K2O 含量（%）
+func productNutrients(product models.FertilizerProduct) [3]float64 {
+	return [3]float64{product.N, product.P2O5, product.K2O}
+}
+
+// optimizeFertilizerBlend 求解满足补充量的最低成本化肥组合，并分为基肥与追肥
+//
+// 决策变量依次为各产品的基肥用量与追肥用量（kg）。每种养分的总量不低于目标、不超过目标 ×（1 + tolerance），
+// 基肥、追肥各自不低于目标 × 对应比例 ×（1 − tolerance），目标为 0 的养分不设上限
+func optimizeFertilizerBlend(req models.FertilizerBlendRequest, products []models.FertilizerProduct) (models.FertilizerBlendResult, error) {
+	target := nutrientVector(req.Target)
+	if target[0] < 0 || target[1] < 0 || target[2] < 0 {
+		return models.FertilizerBlendResult{}, errors.New("target must not be negative")
+	}
+	if target[0]+target[1]+target[2] == 0 {
+		return models.FertilizerBlendResult{}, errors.New("target must contain at least one nutrient")
+	}
+	if len(products) == 0 {
+		return models.FertilizerBlendResult{}, errors.New("at least one fertilizer product is required")
+	}
+	tolerance := req.Tolerance
+	if tolerance == 0 {
+		tolerance = defaultBlendTolerance
+	}
+	if tolerance < 0 || tolerance >= 100 {
+		return models.FertilizerBlendResult{}, errors.New("tolerance must be between 0 and 100")
+	}
+	tolerance /= 100
+	shareAmount := defaultBasalShare
+	if req.BasalShare != nil {
+		shareAmount = *req.BasalShare
+	}
+	share := nutrientVector(shareAmount)
+	for _, s := range share {
+		if s < 0 || s > 100 {
+			return models.FertilizerBlendResult{}, errors.New("basalShare must be between 0 and 100")
+		}
+	}
+
+	k := len(products)
+	size := 2 * k
+	newRow := func() []float64 { return make([]float64, size) }
+
+	var constraints []utils.LPConstraint
+	for j := range target {
+		total, basal, top := newRow(), newRow(), newRow()
+		for i, product := range products {
+			content := productNutrients(product)[j] / 100
+			total[i], total[k+i] = content, content
+			basal[i] = content
+			top[k+i] = content
+		}
+		constraints = append(constraints, utils.LPConstraint{Coeffs: total, Op: utils.LPGreaterEq, RHS: target[j]})
+		if target[j] > 0 {
+			constraints = append(constraints, utils.LPConstraint{Coeffs: total, Op: utils.LPLessEq, RHS: target[j] * (1 + tolerance)})
+		}
+		constraints = append(constraints,
+			utils.LPConstraint{Coeffs: basal, Op: utils.LPGreaterEq, RHS: target[j] * share[j] / 100 * (1 - tolerance)},
+			utils.LPConstraint{Coeffs: top, Op: utils.LPGreaterEq, RHS: target[j] * (100 - share[j]) / 100 * (1 - tolerance)},
+		)
+	}
+
+	// 仅作基肥或仅作追肥的产品，另一阶段用量为 0
+	for i, product := range products {
+		row := newRow()
+		switch product.Usage {
+		case models.FertilizerUsageBasal:
+			row[k+i] = 1
+		case models.FertilizerUsageTopdressing:
+			row[i] = 1
+		default:
+			continue
+		}
+		constraints = append(constraints, utils.LPConstraint{Coeffs: row, Op: utils.LPLessEq, RHS: 0})
+	}
+
+	objective := newRow()
+	for i, product := range products {
+		objective[i] = product.Price / product.PackageSize
+		objective[k+i] = objective[i]
+	}
+
+	result := models.FertilizerBlendResult{
+		Target:      req.Target,
+		Basal:       []models.FertilizerDose{},
+		Topdressing: []models.FertilizerDose{},
+	}
+	x, _, err := utils.SolveLP(objective, constraints)
+	if err == utils.ErrLPInfeasible {
+		result.Reasons = explainBlendInfeasible(products, target, share, tolerance)
+		return result, nil
+	}
+	if err != nil {
+		return models.FertilizerBlendResult{}, err
+	}
+
+	var supplied [3]float64
+	var totalCost float64
+	for i, product := range products {
+		for stage, weight := range []float64{x[i], x[k+i]} {
+			if weight < 0.005 {
+				continue
+			}
+			dose := models.FertilizerDose{
+				ProductID: product.ID,
+				Name:      product.Name,
+				Weight:    round2(weight),
+				Bags:      round2(weight / product.PackageSize),
+				Cost:      round2(weight * product.Price / product.PackageSize),
+				Nutrients: models.NutrientAmount{
+					N:    round2(weight * product.N / 100),
+					P2O5: round2(weight * product.P2O5 / 100),
+					K2O:  round2(weight * product.K2O / 100),
+				},
+			}
+			supplied[0] += weight * product.N / 100
+			supplied[1] += weight * product.P2O5 / 100
+			supplied[2] += weight * product.K2O / 100
+			totalCost += weight * product.Price / product.PackageSize
+			if stage == 0 {
+				result.Basal = append(result.Basal, dose)
+			} else {
+				result.Topdressing = append(result.Topdressing, dose)
+			}
+		}
+	}
+	result.Feasible = true
+	result.Supplied = models.NutrientAmount{N: round2(supplied[0]), P2O5: round2(supplied[1]), K2O: round2(supplied[2])}
+	result.TotalCost = round2(totalCost)
+	return result, nil
+}
+
+// explainBlendInfeasible 分析无法配出目标养分的原因
+func explainBlendInfeasible(products []models.FertilizerProduct, target, share [3]float64, tolerance float64) []string {
+	var reasons []string
+	for j, name := range nutrientNames {
+		if target[j] <= 0 {
+			continue
+		}
+		var found, basal, top bool
+		for _, product := range products {
+			content := productNutrients(product)[j]
+			if content <= 0 {
+				continue
+			}
+			found = true
+			if product.Usage != models.FertilizerUsageTopdressing {
+				basal = true
+			}
+			if product.Usage != models.FertilizerUsageBasal {
+				top = true
+			}
+		}
+		switch {
+		case !found:
+			reasons = append(reasons, fmt.Sprintf("no selected product supplies %s, add a product containing it", name))
+		case share[j] > 0 && !basal:
+			reasons = append(reasons, fmt.Sprintf("no selected product can supply %s as basal fertilizer", name))
+		case share[j] < 100 && !top:
+			reasons = append(reasons, fmt.Sprintf("no selected product can supply %s as topdressing, add a topdressing product or raise its basal share", name))
+		}
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, fmt.Sprintf(
+			"the selected products cannot match the N-P2O5-K2O ratio within +%.0f%%, widen the tolerance or add single-nutrient products",
+			tolerance*100))
+	}
+	return reasons
+}
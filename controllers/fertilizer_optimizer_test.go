@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"go-mengtuobang/models"
+)
+
+var (
+	testUrea     = models.FertilizerProduct{ID: 1, Name: "尿素", N: 46, PackageSize: 50, Price: 120, Usage: models.FertilizerUsageBoth}
+	testDAP      = models.FertilizerProduct{ID: 2, Name: "磷酸二铵", N: 18, P2O5: 46, PackageSize: 50, Price: 180, Usage: models.FertilizerUsageBasal}
+	testPotash   = models.FertilizerProduct{ID: 3, Name: "氯化钾", K2O: 60, PackageSize: 50, Price: 150, Usage: models.FertilizerUsageBoth}
+	testCompound = models.FertilizerProduct{ID: 4, Name: "复合肥 15-15-15", N: 15, P2O5: 15, K2O: 15, PackageSize: 40, Price: 140, Usage: models.FertilizerUsageBoth}
+)
+
+func TestOptimizeFertilizerBlend(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      models.FertilizerBlendRequest
+		products []models.FertilizerProduct
+		supplied models.NutrientAmount
+		cost     float64
+	}{
+		{
+			// 磷只能来自磷酸二铵 10 kg，其带入的 1.8 kg 氮由尿素补足
+			"single nutrient products",
+			models.FertilizerBlendRequest{Target: models.NutrientAmount{N: 10, P2O5: 4.6, K2O: 6}},
+			[]models.FertilizerProduct{testUrea, testDAP, testPotash},
+			models.NutrientAmount{N: 10, P2O5: 4.6, K2O: 6},
+			108.78,
+		},
+		{
+			"compound fertilizer all basal",
+			models.FertilizerBlendRequest{Target: models.NutrientAmount{N: 6, P2O5: 6, K2O: 6}, BasalShare: &models.NutrientAmount{N: 100, P2O5: 100, K2O: 100}},
+			[]models.FertilizerProduct{testCompound},
+			models.NutrientAmount{N: 6, P2O5: 6, K2O: 6},
+			140,
+		},
+		{
+			"nitrogen only",
+			models.FertilizerBlendRequest{Target: models.NutrientAmount{N: 9.2}, Tolerance: 5},
+			[]models.FertilizerProduct{testUrea, testPotash},
+			models.NutrientAmount{N: 9.2},
+			48,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := optimizeFertilizerBlend(tt.req, tt.products)
+			if err != nil {
+				t.Fatalf("optimizeFertilizerBlend() error = %v", err)
+			}
+			if !result.Feasible {
+				t.Fatalf("optimizeFertilizerBlend() infeasible: %v", result.Reasons)
+			}
+			got, want := nutrientVector(result.Supplied), nutrientVector(tt.supplied)
+			for j := range got {
+				if math.Abs(got[j]-want[j]) > 0.02 {
+					t.Errorf("supplied %s = %v, want %v", nutrientNames[j], got[j], want[j])
+				}
+			}
+			if math.Abs(result.TotalCost-tt.cost) > 0.02 {
+				t.Errorf("TotalCost = %v, want %v", result.TotalCost, tt.cost)
+			}
+
+			share := nutrientVector(defaultBasalShare)
+			if tt.req.BasalShare != nil {
+				share = nutrientVector(*tt.req.BasalShare)
+			}
+			var basal [3]float64
+			for _, dose := range result.Basal {
+				basal[0], basal[1], basal[2] = basal[0]+dose.Nutrients.N, basal[1]+dose.Nutrients.P2O5, basal[2]+dose.Nutrients.K2O
+			}
+			for j, target := range nutrientVector(tt.req.Target) {
+				if floor := target * share[j] / 100 * 0.9; basal[j] < floor-0.02 {
+					t.Errorf("basal %s = %v, want at least %v", nutrientNames[j], basal[j], floor)
+				}
+			}
+			for _, dose := range result.Topdressing {
+				if dose.ProductID == testDAP.ID {
+					t.Errorf("basal-only product %s used as topdressing", dose.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestOptimizeFertilizerBlendInfeasible(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      models.FertilizerBlendRequest
+		products []models.FertilizerProduct
+		reason   string
+	}{
+		{
+			"missing nutrient",
+			models.FertilizerBlendRequest{Target: models.NutrientAmount{N: 10, K2O: 6}},
+			[]models.FertilizerProduct{testUrea, testDAP},
+			"no selected product supplies K2O",
+		},
+		{
+			"no basal source",
+			models.FertilizerBlendRequest{Target: models.NutrientAmount{N: 10}},
+			[]models.FertilizerProduct{{ID: 5, Name: "尿素（追肥）", N: 46, PackageSize: 50, Price: 120, Usage: models.FertilizerUsageTopdressing}},
+			"no selected product can supply N as basal fertilizer",
+		},
+		{
+			"no topdressing source",
+			models.FertilizerBlendRequest{Target: models.NutrientAmount{N: 10, P2O5: 4.6}, BasalShare: &models.NutrientAmount{N: 40, P2O5: 80}},
+			[]models.FertilizerProduct{testUrea, testDAP},
+			"no selected product can supply P2O5 as topdressing",
+		},
+		{
+			"ratio out of tolerance",
+			models.FertilizerBlendRequest{Target: models.NutrientAmount{N: 10, P2O5: 2, K2O: 2}},
+			[]models.FertilizerProduct{testCompound},
+			"cannot match the N-P2O5-K2O ratio within +10%",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := optimizeFertilizerBlend(tt.req, tt.products)
+			if err != nil {
+				t.Fatalf("optimizeFertilizerBlend() error = %v", err)
+			}
+			if result.Feasible {
+				t.Fatalf("optimizeFertilizerBlend() feasible, want infeasible")
+			}
+			if len(result.Reasons) == 0 || !strings.Contains(result.Reasons[0], tt.reason) {
+				t.Errorf("Reasons = %v, want %q", result.Reasons, tt.reason)
+			}
+		})
+	}
+}
+
+func TestOptimizeFertilizerBlendInvalid(t *testing.T) {
+	products := []models.FertilizerProduct{testUrea, testDAP, testPotash}
+	tests := []struct {
+		name     string
+		req      models.FertilizerBlendRequest
+		products []models.FertilizerProduct
+	}{
+		{"negative target", models.FertilizerBlendRequest{Target: models.NutrientAmount{N: -1, K2O: 5}}, products},
+		{"empty target", models.FertilizerBlendRequest{}, products},
+		{"no products", models.FertilizerBlendRequest{Target: models.NutrientAmount{N: 10}}, nil},
+		{"tolerance too large", models.FertilizerBlendRequest{Target: models.NutrientAmount{N: 10}, Tolerance: 100}, products},
+		{"negative tolerance", models.FertilizerBlendRequest{Target: models.NutrientAmount{N: 10}, Tolerance: -5}, products},
+		{"basal share too large", models.FertilizerBlendRequest{Target: models.NutrientAmount{N: 10}, BasalShare: &models.NutrientAmount{N: 120}}, products},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := optimizeFertilizerBlend(tt.req, tt.products); err == nil {
+				t.Errorf("optimizeFertilizerBlend() error = nil, want error")
+			}
+		})
+	}
+}
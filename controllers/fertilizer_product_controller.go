@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+)
+
+// FertilizerProductController 处理化肥产品库相关的请求
+type FertilizerProductController struct {
+	DB *sql.DB
+}
+
+// NewFertilizerProductController 创建一个新的FertilizerProductController实例
+func NewFertilizerProductController(db *sql.DB) *FertilizerProductController {
+	return &FertilizerProductController{DB: db}
+}
+
+const fertilizerProductColumns = "id, user_id, name, n_content, p2o5_content, k2o_content, package_size, price, usage_type, description, created_at"
+
+// scanFertilizerProduct 扫描一行化肥产品数据
+func scanFertilizerProduct(scanner interface{ Scan(...interface{}) error }) (models.FertilizerProduct, error) {
+	var product models.FertilizerProduct
+	var userID sql.NullInt64
+	var description sql.NullString
+	err := scanner.Scan(&product.ID, &userID, &product.Name, &product.N, &product.P2O5, &product.K2O,
+		&product.PackageSize, &product.Price, &product.Usage, &description, &product.CreatedAt)
+	if err != nil {
+		return product, err
+	}
+	if userID.Valid {
+		id := int(userID.Int64)
+		product.UserID = &id
+	}
+	product.IsSystem = !userID.Valid
+	product.Description = description.String
+	return product, nil
+}
+
+// GetFertilizerProducts 搜索化肥产品库（系统默认产品与当前用户的自定义产品）
+func (c *FertilizerProductController) GetFertilizerProducts(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "50"))
+	keyword := ctx.Query("keyword")
+	usage := ctx.Query("usage")
+
+	where := " WHERE (user_id IS NULL OR user_id = ?)"
+	params := []interface{}{userID}
+
+	if keyword != "" {
+		where += " AND (name LIKE ? OR description LIKE ?)"
+		keywordLike := "%" + keyword + "%"
+		params = append(params, keywordLike, keywordLike)
+	}
+
+	if usage != "" {
+		where += " AND (usage_type = ? OR usage_type = ?)"
+		params = append(params, usage, models.FertilizerUsageBoth)
+	}
+
+	var totalCount int
+	if err := c.DB.QueryRow("SELECT COUNT(*) FROM fertilizer_products"+where, params...).Scan(&totalCount); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting total count"})
+		return
+	}
+
+	// 自定义产品排在系统产品之前
+	query := "SELECT " + fertilizerProductColumns + " FROM fertilizer_products" + where +
+		" ORDER BY user_id IS NULL, name LIMIT ? OFFSET ?"
+	params = append(params, pageSize, (page-1)*pageSize)
+
+	rows, err := c.DB.Query(query, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for fertilizer products"})
+		return
+	}
+	defer rows.Close()
+
+	products := []models.FertilizerProduct{}
+	for rows.Next() {
+		product, err := scanFertilizerProduct(rows)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning fertilizer product row"})
+			return
+		}
+		products = append(products, product)
+	}
+
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating fertilizer product rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":        200,
+		"msg":         "ok",
+		"data":        products,
+		"totalCount":  totalCount,
+		"currentPage": page,
+		"pageSize":    pageSize,
+	})
+}
+
+// GetFertilizerProduct 获取单个化肥产品
+func (c *FertilizerProductController) GetFertilizerProduct(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id := ctx.Param("id")
+
+	query := "SELECT " + fertilizerProductColumns + " FROM fertilizer_products WHERE id = ? AND (user_id IS NULL OR user_id = ?)"
+	product, err := scanFertilizerProduct(c.DB.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Fertilizer product not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": product,
+	})
+}
+
+// CreateFertilizerProduct 创建自定义化肥产品，管理员可通过 isSystem 创建系统默认产品
+func (c *FertilizerProductController) CreateFertilizerProduct(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var product models.FertilizerProduct
+	if err := ctx.ShouldBindJSON(&product); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := normalizeFertilizerProduct(&product); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var owner interface{} = userID
+	if product.IsSystem {
+		if status, err := checkAdmin(c.DB, userID, "create system products"); err != nil {
+			ctx.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		owner = nil
+	}
+
+	result, err := c.DB.Exec(`
+		INSERT INTO fertilizer_products (user_id, name, n_content, p2o5_content, k2o_content, package_size, price, usage_type, description)
+		VALUES (?,?,?,?,?,?,?,?,?)
+	`, owner, product.Name, product.N, product.P2O5, product.K2O, product.PackageSize, product.Price, product.Usage, product.Description)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	product.ID = int(id)
+	if !product.IsSystem {
+		product.UserID = &userID
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": product,
+	})
+}
+
+// UpdateFertilizerProduct 更新化肥产品，普通用户只能修改自己的产品，管理员可修改系统产品
+func (c *FertilizerProductController) UpdateFertilizerProduct(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var product models.FertilizerProduct
+	if err := ctx.ShouldBindJSON(&product); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := normalizeFertilizerProduct(&product); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if status, err := c.checkProductEditable(id, userID); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = c.DB.Exec(`
+		UPDATE fertilizer_products
+		SET name = ?, n_content = ?, p2o5_content = ?, k2o_content = ?, package_size = ?, price = ?, usage_type = ?, description = ?
+		WHERE id = ?
+	`, product.Name, product.N, product.P2O5, product.K2O, product.PackageSize, product.Price, product.Usage, product.Description, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := scanFertilizerProduct(c.DB.QueryRow("SELECT "+fertilizerProductColumns+" FROM fertilizer_products WHERE id = ?", id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": updated,
+	})
+}
+
+// DeleteFertilizerProduct 删除化肥产品
+func (c *FertilizerProductController) DeleteFertilizerProduct(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if status, err := c.checkProductEditable(id, userID); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := c.DB.Exec("DELETE FROM fertilizer_products WHERE id = ?", id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// checkProductEditable 检查当前用户是否可以修改化肥产品，返回失败时对应的 HTTP 状态码
+func (c *FertilizerProductController) checkProductEditable(id, userID int) (int, error) {
+	var owner sql.NullInt64
+	err := c.DB.QueryRow("SELECT user_id FROM fertilizer_products WHERE id = ?", id).Scan(&owner)
+	if err == sql.ErrNoRows || (err == nil && owner.Valid && int(owner.Int64) != userID) {
+		return http.StatusNotFound, errors.New("fertilizer product not found")
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if owner.Valid {
+		return 0, nil
+	}
+
+	return checkAdmin(c.DB, userID, "change system products")
+}
+
+// normalizeFertilizerProduct 校验化肥产品数值范围并补全默认施用方式
+func normalizeFertilizerProduct(product *models.FertilizerProduct) error {
+	if product.N < 0 || product.P2O5 < 0 || product.K2O < 0 || product.N+product.P2O5+product.K2O > 100 {
+		return errors.New("n, p2o5 and k2o must not be negative and must not add up to more than 100")
+	}
+	if product.N+product.P2O5+product.K2O == 0 {
+		return errors.New("product must contain at least one nutrient")
+	}
+	if product.PackageSize <= 0 {
+		return errors.New("packageSize must be greater than 0")
+	}
+	if product.Price < 0 {
+		return errors.New("price must not be negative")
+	}
+	if product.Usage == "" {
+		product.Usage = models.FertilizerUsageBoth
+	}
+	return nil
+}
+
+// uniqueIDs 去除重复的 ID，保持首次出现的顺序
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// loadFertilizerProducts 加载当前用户可用的化肥产品，ids 为空时加载全部
+func loadFertilizerProducts(db *sql.DB, userID int, ids []int) ([]models.FertilizerProduct, error) {
+	query := "SELECT " + fertilizerProductColumns + " FROM fertilizer_products WHERE (user_id IS NULL OR user_id = ?)"
+	params := []interface{}{userID}
+	if len(ids) > 0 {
+		query += " AND id IN (?" + strings.Repeat(",?", len(ids)-1) + ")"
+		for _, id := range ids {
+			params = append(params, id)
+		}
+	}
+	query += " ORDER BY id"
+
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []models.FertilizerProduct
+	for rows.Next() {
+		product, err := scanFertilizerProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
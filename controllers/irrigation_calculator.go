@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"go-mengtuobang/models"
+)
+
+const (
+	// squareMetersPerMu 每亩面积（m²）
+	squareMetersPerMu = 666.67
+	// irrigationTolerance 保存时提交的灌水量与服务端计算结果的允许相对偏差
+	irrigationTolerance = 0.05
+)
+
+// irrigationEfficiency 灌溉水利用系数，大于 1 时按百分数处理
+func irrigationEfficiency(efficiency float64) float64 {
+	if efficiency > 1 {
+		return efficiency / 100
+	}
+	return efficiency
+}
+
+// averageMoisture 各水分点含水量的平均值
+func averageMoisture(points []models.MoisturePoint) float64 {
+	if len(points) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, point := range points {
+		total += point.Value
+	}
+	return total / float64(len(points))
+}
+
+// calculateIrrigation 根据土壤含水量亏缺计算各区域灌水量与灌溉时长
+//
+// 灌水量（m³）= 面积（m²）× 计划湿润层深度（m）× 土壤容重（t/m³）× 含水量亏缺（%）/ 100 / 灌溉水利用系数，
+// 其中目标含水量 = 田间持水量 × 适宜含水率 / 100，灌溉时长 = 灌水量 / 区域流量（m³/h）
+func calculateIrrigation(req models.IrrigationRequest) ([]models.IrrigationAreaResult, error) {
+	if req.Depth <= 0 {
+		return nil, errors.New("depth must be greater than 0")
+	}
+	if req.SoilDensity <= 0 {
+		return nil, errors.New("soilDensity must be greater than 0")
+	}
+	if req.FieldCapacity <= 0 || req.FieldCapacity > 100 {
+		return nil, errors.New("fieldCapacity must be between 0 and 100")
+	}
+	if req.OptimalMoisture <= 0 || req.OptimalMoisture > 100 {
+		return nil, errors.New("optimalMoisture must be between 0 and 100")
+	}
+	efficiency := irrigationEfficiency(req.Efficiency)
+	if efficiency <= 0 || efficiency > 1 {
+		return nil, errors.New("efficiency must be between 0 and 100")
+	}
+	if len(req.Areas) == 0 {
+		return nil, errors.New("at least one area is required")
+	}
+
+	target := req.FieldCapacity * req.OptimalMoisture / 100
+	results := make([]models.IrrigationAreaResult, 0, len(req.Areas))
+	for _, area := range req.Areas {
+		if area.PlotSize <= 0 {
+			return nil, fmt.Errorf("plotSize of area %d must be greater than 0", area.AreaId)
+		}
+		if len(area.MoisturePoints) == 0 {
+			return nil, fmt.Errorf("area %d has no moisture points", area.AreaId)
+		}
+
+		result := models.IrrigationAreaResult{
+			AreaId:          area.AreaId,
+			AverageMoisture: round2(averageMoisture(area.MoisturePoints)),
+			TargetMoisture:  round2(target),
+		}
+		deficit := target - averageMoisture(area.MoisturePoints)
+		result.Deficit = round2(deficit)
+		result.Negative = deficit < 0
+
+		waterAmount := 0.0
+		if deficit > 0 {
+			waterAmount = area.PlotSize * squareMetersPerMu * req.Depth / 100 * req.SoilDensity * deficit / 100 / efficiency
+		}
+		result.WaterAmount = round2(waterAmount)
+
+		flowRate := area.WaterFlowRate
+		if flowRate <= 0 {
+			flowRate = area.FlowRate
+		}
+		if flowRate > 0 {
+			result.IrrigationTime = formatFloat(waterAmount / flowRate)
+		} else {
+			result.IrrigationTime = formatFloat(0)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// validateIrrigationAreas 校验提交的各区域灌水量与服务端计算结果是否一致，并以服务端结果修正是否需要灌溉
+func validateIrrigationAreas(req *models.IrrigationRequest) error {
+	results, err := calculateIrrigation(*req)
+	if err != nil {
+		return err
+	}
+	for i := range req.Areas {
+		expected := results[i].WaterAmount
+		diff := math.Abs(req.Areas[i].WaterAmount - expected)
+		if diff > 0.01 && diff > expected*irrigationTolerance {
+			return fmt.Errorf("waterAmount of area %d is %.2f, expected %.2f", req.Areas[i].AreaId, req.Areas[i].WaterAmount, expected)
+		}
+		req.Areas[i].Negative = results[i].Negative
+	}
+	return nil
+}
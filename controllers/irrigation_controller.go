@@ -3,9 +3,12 @@ package controllers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -25,24 +28,38 @@ func NewIrrigationController(db *sql.DB) *IrrigationController {
 // SaveIrrigationData 保存灌溉数据
 func (c *IrrigationController) SaveIrrigationRecord(ctx *gin.Context) {
 	userID := ctx.GetInt("userID")
-	var requestData struct {
-		AreaNum         int               `json:"areaNum"`
-		IrrigationMode  string            `json:"irrigationMode"`
-		Efficiency      float64           `json:"efficiency"`
-		CropType        string            `json:"cropType"`
-		Depth           float64           `json:"depth"`
-		OptimalMoisture float64           `json:"optimalMoisture"`
-		SoilType        string            `json:"soilType"`
-		FieldCapacity   float64           `json:"fieldCapacity"`
-		SoilDensity     float64           `json:"soilDensity"`
-		Areas           []models.AreaData `json:"areas"`
-	}
+	var requestData models.IrrigationRequest
 
 	if err := ctx.ShouldBindJSON(&requestData); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// 关联地块时，未填写的作物与土壤质地取地块信息
+	if status, err := applyPlotToIrrigation(c.DB, userID, &requestData); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 未填写的土壤与作物参数按参考表补全
+	if err := applyIrrigationDefaults(c.DB, &requestData); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 未填写水分点的区域使用传感器最新读数，与计算接口一致，读数随记录保存
+	if err := applySensorMoisture(c.DB, userID, &requestData); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 以服务端计算结果校验客户端提交的灌水量
+	if err := validateIrrigationAreas(&requestData); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fillFertigationTimes(&requestData)
+
 	// 开始事务
 	tx, err := c.DB.Begin()
 	if err != nil {
@@ -50,12 +67,18 @@ func (c *IrrigationController) SaveIrrigationRecord(ctx *gin.Context) {
 		return
 	}
 
+	if status, err := lockPlot(tx, userID, requestData.PlotID); err != nil {
+		tx.Rollback()
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 插入灌溉记录
 	insertRecordSQL := `
 		INSERT INTO water_records (
 			user_id, irrigation_mode, efficiency, crop_type, 
-			depth, optimal_moisture, soil_type, field_capacity, soil_density
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			depth, optimal_moisture, soil_type, field_capacity, soil_density, plot_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	result, err := tx.Exec(
 		insertRecordSQL,
@@ -68,6 +91,7 @@ func (c *IrrigationController) SaveIrrigationRecord(ctx *gin.Context) {
 		requestData.SoilType,
 		requestData.FieldCapacity,
 		requestData.SoilDensity,
+		nullableID(requestData.PlotID),
 	)
 
 	if err != nil {
@@ -84,6 +108,50 @@ func (c *IrrigationController) SaveIrrigationRecord(ctx *gin.Context) {
 	}
 
 	// 插入区域数据
+	if err = insertWaterAreas(tx, recordID, requestData.Areas); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 提交事务
+	if err = tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK,
+		gin.H{
+			"code":      200,
+			"msg":       "ok",
+			"record_id": recordID,
+		},
+	)
+}
+
+// applyPlotToIrrigation 校验关联地块的归属，并以地块的作物与土壤质地补全未填写的参数，返回失败时对应的 HTTP 状态码
+func applyPlotToIrrigation(db *sql.DB, userID int, req *models.IrrigationRequest) (int, error) {
+	if req.PlotID == 0 {
+		return 0, nil
+	}
+	plot, err := loadPlot(db, userID, req.PlotID)
+	if err == sql.ErrNoRows {
+		return http.StatusBadRequest, errors.New("plot not found")
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if req.CropType == "" {
+		req.CropType = plot.CurrentCrop
+	}
+	if req.SoilType == "" {
+		req.SoilType = plot.SoilType
+	}
+	return 0, nil
+}
+
+// insertWaterAreas 插入灌溉记录的区域数据
+func insertWaterAreas(tx *sql.Tx, recordID int64, areas []models.AreaData) error {
 	insertAreaSQL := `
 		INSERT INTO water_areas (
 			record_id, moisture_points,plot_size,water_flow_rate,tank_size, water_amount, irrigation_time,
@@ -92,15 +160,18 @@ func (c *IrrigationController) SaveIrrigationRecord(ctx *gin.Context) {
 	`
 
 	// 循环处理每个区域
-	for _, area := range requestData.Areas {
+	for _, area := range areas {
 		moisturePointsJSON, err := json.Marshal(area.MoisturePoints)
+		if err != nil {
+			return err
+		}
 		// 将布尔值转换为整数
 		negative := 0
 		if area.Negative {
 			negative = 1
 		}
 
-		result, err = tx.Exec(
+		_, err = tx.Exec(
 			insertAreaSQL,
 			recordID,
 			moisturePointsJSON,
@@ -108,33 +179,185 @@ func (c *IrrigationController) SaveIrrigationRecord(ctx *gin.Context) {
 			area.WaterFlowRate,
 			area.TankSize,
 			area.WaterAmount,
-			area.IrrigationTime, // 转换为字符串
+			area.IrrigationTime,
 			area.FertilizerStartTime,
 			area.FertilizerTotalTime,
 			area.FertilizerFlowRate,
 			negative,
 		)
-
 		if err != nil {
-			tx.Rollback()
+			return err
+		}
+	}
+	return nil
+}
+
+// CalculateIrrigation 根据各区域水分点计算灌水量与灌溉时长
+func (c *IrrigationController) CalculateIrrigation(ctx *gin.Context) {
+	var req models.IrrigationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 未填写的土壤与作物参数按参考表补全
+	if err := applyIrrigationDefaults(c.DB, &req); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 未填写水分点的区域使用传感器最新读数
+	if err := applySensorMoisture(c.DB, ctx.GetInt("userID"), &req); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := calculateIrrigation(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": results,
+	})
+}
+
+// PlanFertigation 生成各区域的注肥计划并校验肥液浓度
+func (c *IrrigationController) PlanFertigation(ctx *gin.Context) {
+	var req models.FertigationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 未填写的土壤与作物参数按参考表补全
+	if err := applyIrrigationDefaults(c.DB, &req.IrrigationRequest); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	plans, err := planFertigation(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": plans,
+	})
+}
+
+// PlanRotation 在水泵流量与每日灌溉窗口约束下生成多区域轮灌排程
+func (c *IrrigationController) PlanRotation(ctx *gin.Context) {
+	var req models.RotationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 未填写的土壤与作物参数按参考表补全
+	if err := applyIrrigationDefaults(c.DB, &req.IrrigationRequest); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 未填写水分点的区域使用传感器最新读数
+	if err := applySensorMoisture(c.DB, ctx.GetInt("userID"), &req.IrrigationRequest); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := planRotation(req, time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": plan,
+	})
+}
+
+// ScheduleIrrigation 基于 ET0 与作物系数生成各区域的逐日水量平衡与推荐灌溉日期、灌水量
+func (c *IrrigationController) ScheduleIrrigation(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var req models.IrrigationScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	plantingDate, err := time.Parse(dateLayout, req.PlantingDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "plantingDate must be formatted as YYYY-MM-DD"})
+		return
+	}
+
+	record, err := loadWaterRecord(c.DB, req.RecordID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Irrigation record not found"})
+		} else {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
 		}
+		return
 	}
 
-	// 提交事务
-	if err = tx.Commit(); err != nil {
+	cropType := req.CropType
+	if cropType == "" {
+		cropType = record.CropType
+	}
+	coefficient, ok, err := lookupCropCoefficient(c.DB, cropType)
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("no crop coefficient curve for crop %q", cropType)})
+		return
+	}
 
-	ctx.JSON(http.StatusOK,
-		gin.H{
-			"code":      200,
-			"msg":       "ok",
-			"record_id": recordID,
+	// 默认排程整个生育期
+	startDate, endDate := req.StartDate, req.EndDate
+	if startDate == "" {
+		startDate = req.PlantingDate
+	}
+	if endDate == "" {
+		endDate = plantingDate.AddDate(0, 0, coefficient.SeasonLength()-1).Format(dateLayout)
+	}
+
+	weather, err := loadWeatherDays(c.DB, userID, req.Station, startDate, endDate)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for weather data"})
+		return
+	}
+	if len(weather) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "no weather data for the station in the requested period"})
+		return
+	}
+
+	plots, err := scheduleIrrigation(record, coefficient, plantingDate, weather)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": models.IrrigationSchedule{
+			RecordID: record.ID,
+			CropType: cropType,
+			Station:  req.Station,
+			Plots:    plots,
 		},
-	)
+	})
 }
 
 // GetIrrigationRecords 获取灌溉记录
@@ -147,12 +370,13 @@ func (c *IrrigationController) GetIrrigationRecords(ctx *gin.Context) {
 	startDate := ctx.Query("startDate")
 	endDate := ctx.Query("endDate")
 	irrigationMode := ctx.Query("query") // 新增：灌溉方式模糊查询参数
+	plotID := ctx.Query("plotId")
 
 	// 构建基础查询
 	query := `
 		SELECT 
 			id, user_id, irrigation_mode, efficiency, crop_type, 
-			depth, optimal_moisture, soil_type, field_capacity, soil_density, created_at 
+			depth, optimal_moisture, soil_type, field_capacity, soil_density, plot_id, created_at 
 		FROM water_records 
 		WHERE user_id = ?
 	`
@@ -171,6 +395,12 @@ func (c *IrrigationController) GetIrrigationRecords(ctx *gin.Context) {
 		queryParams = append(queryParams, "%"+irrigationMode+"%") // 使用 % 实现模糊匹配
 	}
 
+	// 按地块筛选
+	if plotID != "" {
+		query += " AND plot_id = ?"
+		queryParams = append(queryParams, plotID)
+	}
+
 	// 添加排序和分页
 	query += " ORDER BY created_at DESC LIMIT ? OFFSET ?"
 	queryParams = append(queryParams, pageSize, (page-1)*pageSize)
@@ -186,46 +416,34 @@ func (c *IrrigationController) GetIrrigationRecords(ctx *gin.Context) {
 	var records []models.WaterRecord
 	for rows.Next() {
 		var record models.WaterRecord
+		var plotID sql.NullInt64
 		err := rows.Scan(
 			&record.ID, &record.UserID, &record.IrrigationMode, &record.Efficiency,
 			&record.CropType, &record.Depth, &record.OptimalMoisture, &record.SoilType,
-			&record.FieldCapacity, &record.SoilDensity, &record.CreatedAt,
+			&record.FieldCapacity, &record.SoilDensity, &plotID, &record.CreatedAt,
 		)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "解析灌溉记录失败"})
 			return
 		}
-
-		// 查询区域数据（保持不变）
-		areaRows, err := c.DB.Query(`
-			SELECT 
-				id, record_id, plot_size, tank_size, water_amount, irrigation_time,fertilizer_start_time, fertilizer_total_time, fertilizer_flow_rate,moisture_points, negative
-			FROM water_areas 
-			WHERE record_id = ?
-		`, record.ID)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "查询灌溉区域失败"})
-			return
-		}
-		defer areaRows.Close()
-
-		for areaRows.Next() {
-			var area models.WaterArea
-			err := areaRows.Scan(
-				&area.ID, &area.RecordID, &area.PlotSize, &area.TankSize, &area.WaterAmount, &area.IrrigationTime,
-				&area.FertilizerStartTime, &area.FertilizerTotalTime, &area.FertilizerFlowRate, &area.MoisturePoints, &area.Negative,
-			)
-			if err != nil {
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "解析灌溉区域失败"})
-				return
-			}
-
-			record.Areas = append(record.Areas, area)
-		}
-
+		record.PlotID = int(plotID.Int64)
 		records = append(records, record)
 	}
 
+	// 批量查询区域数据
+	ids := make([]interface{}, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+	areas, err := loadWaterAreas(c.DB, ids)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "查询灌溉区域失败"})
+		return
+	}
+	for i := range records {
+		records[i].Areas = areas[records[i].ID]
+	}
+
 	// 获取总记录数（同时考虑灌溉方式筛选）
 	var totalCount int
 	countQuery := "SELECT COUNT(*) FROM water_records WHERE user_id = ?"
@@ -242,6 +460,11 @@ func (c *IrrigationController) GetIrrigationRecords(ctx *gin.Context) {
 		countParams = append(countParams, "%"+irrigationMode+"%")
 	}
 
+	if plotID != "" {
+		countQuery += " AND plot_id = ?"
+		countParams = append(countParams, plotID)
+	}
+
 	err = c.DB.QueryRow(countQuery, countParams...).Scan(&totalCount)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "获取总记录数失败"})
@@ -258,72 +481,472 @@ func (c *IrrigationController) GetIrrigationRecords(ctx *gin.Context) {
 	})
 }
 
+// ExportIrrigationRecords 导出灌溉记录，筛选条件与 GetIrrigationRecords 相同，不分页，区域数据导出为子表
+func (c *IrrigationController) ExportIrrigationRecords(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	startDate := ctx.Query("startDate")
+	endDate := ctx.Query("endDate")
+	irrigationMode := ctx.Query("query")
+	plotID := ctx.Query("plotId")
+
+	// 主表与区域子表使用相同的筛选条件
+	conditions := " WHERE w.user_id = ?"
+	params := []interface{}{userID}
+	if startDate != "" && endDate != "" {
+		conditions += " AND w.created_at BETWEEN ? AND ?"
+		params = append(params, startDate, endDate)
+	}
+	if irrigationMode != "" {
+		conditions += " AND w.irrigation_mode LIKE ?"
+		params = append(params, "%"+irrigationMode+"%")
+	}
+	if plotID != "" {
+		conditions += " AND w.plot_id = ?"
+		params = append(params, plotID)
+	}
+
+	writeRecordExport(ctx, c.DB, "irrigation-records", []exportSheet{
+		{
+			Key:   "records",
+			Title: "灌溉记录",
+			Header: []string{
+				"记录ID", "时间", "地块ID", "灌溉方式", "灌溉效率", "作物", "计划湿润层深度", "适宜含水率",
+				"土壤质地", "田间持水量", "土壤容重",
+			},
+			Query: `
+				SELECT w.id, w.created_at, w.plot_id, w.irrigation_mode, w.efficiency, w.crop_type, w.depth,
+					w.optimal_moisture, w.soil_type, w.field_capacity, w.soil_density
+				FROM water_records w` + conditions + `
+				ORDER BY w.created_at DESC, w.id DESC
+			`,
+			Params: params,
+			Row: func(rows *sql.Rows) ([]interface{}, error) {
+				var record models.WaterRecord
+				var plotID sql.NullInt64
+				err := rows.Scan(&record.ID, &record.CreatedAt, &plotID, &record.IrrigationMode, &record.Efficiency,
+					&record.CropType, &record.Depth, &record.OptimalMoisture, &record.SoilType, &record.FieldCapacity,
+					&record.SoilDensity)
+				if err != nil {
+					return nil, err
+				}
+				return []interface{}{
+					record.ID, exportTime(record.CreatedAt), exportID(int(plotID.Int64)), record.IrrigationMode,
+					record.Efficiency, record.CropType, record.Depth, record.OptimalMoisture, record.SoilType,
+					record.FieldCapacity, record.SoilDensity,
+				}, nil
+			},
+		},
+		{
+			Key:   "areas",
+			Title: "灌溉区域",
+			Header: []string{
+				"记录ID", "区域ID", "面积", "流量", "施肥罐容积", "灌水量（m³）", "灌溉时长",
+				"施肥开始时间", "施肥总时长", "施肥流量", "水分测点", "负值",
+			},
+			Query: `
+				SELECT a.record_id, a.id, a.plot_size, a.water_flow_rate, a.tank_size, a.water_amount, a.irrigation_time,
+					a.fertilizer_start_time, a.fertilizer_total_time, a.fertilizer_flow_rate, a.moisture_points, a.negative
+				FROM water_areas a
+				JOIN water_records w ON w.id = a.record_id` + conditions + `
+				ORDER BY w.created_at DESC, w.id DESC, a.id
+			`,
+			Params: params,
+			Row: func(rows *sql.Rows) ([]interface{}, error) {
+				var area models.WaterArea
+				var negative int
+				err := rows.Scan(
+					&area.RecordID, &area.ID, &area.PlotSize, &area.WaterFlowRate, &area.TankSize, &area.WaterAmount, &area.IrrigationTime,
+					&area.FertilizerStartTime, &area.FertilizerTotalTime, &area.FertilizerFlowRate, &area.MoisturePoints, &negative,
+				)
+				if err != nil {
+					return nil, err
+				}
+				return []interface{}{
+					area.RecordID, area.ID, area.PlotSize, area.WaterFlowRate, area.TankSize, area.WaterAmount, area.IrrigationTime,
+					area.FertilizerStartTime, area.FertilizerTotalTime, area.FertilizerFlowRate, area.MoisturePoints,
+					exportBool(negative != 0),
+				}, nil
+			},
+		},
+	})
+}
+
 // GetIrrigationRecord 获取单个灌溉记录
 func (c *IrrigationController) GetIrrigationRecord(ctx *gin.Context) {
 	userID := ctx.GetInt("userID")
 	id := ctx.Query("id")
 
-	// 查询记录
+	record, err := loadWaterRecord(c.DB, id, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK,
+		gin.H{
+			"code": 200,
+			"msg":  "ok",
+			"data": record,
+		},
+	)
+}
+
+// sqlQuerier *sql.DB 与 *sql.Tx 共有的查询方法
+type sqlQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// loadWaterRecord 查询单个灌溉记录及其区域数据
+func loadWaterRecord(db sqlQuerier, id interface{}, userID int) (models.WaterRecord, error) {
 	var record models.WaterRecord
+	var plotID sql.NullInt64
 	query := `
 		SELECT 
 			id, user_id, irrigation_mode, efficiency, crop_type, 
-			depth, optimal_moisture, soil_type, field_capacity, soil_density, created_at 
+			depth, optimal_moisture, soil_type, field_capacity, soil_density, plot_id, created_at 
 		FROM water_records 
 		WHERE id = ? AND user_id = ?
 	`
-	fmt.Println(query)
-	fmt.Println(id)
-	err := c.DB.QueryRow(query, id, userID).Scan(
+	err := db.QueryRow(query, id, userID).Scan(
 		&record.ID, &record.UserID, &record.IrrigationMode, &record.Efficiency,
 		&record.CropType, &record.Depth, &record.OptimalMoisture, &record.SoilType,
-		&record.FieldCapacity, &record.SoilDensity, &record.CreatedAt,
+		&record.FieldCapacity, &record.SoilDensity, &plotID, &record.CreatedAt,
 	)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
-		return
+		return record, err
 	}
+	record.PlotID = int(plotID.Int64)
 
-	// 查询区域数据
-	areaRows, err := c.DB.Query(`
-		SELECT 
-			id, record_id, plot_size, water_flow_rate,tank_size, water_amount, irrigation_time,
-			fertilizer_start_time, fertilizer_total_time, fertilizer_flow_rate,moisture_points, negative
-		FROM water_areas 
-		WHERE record_id = ?
-	`, record.ID)
+	areas, err := loadWaterAreas(db, []interface{}{record.ID})
+	if err != nil {
+		return record, err
+	}
+	record.Areas = areas[record.ID]
+	return record, nil
+}
 
+// loadWaterAreas 批量查询灌溉记录的区域数据，按记录 ID 分组
+func loadWaterAreas(db sqlQuerier, recordIDs []interface{}) (map[int][]models.WaterArea, error) {
+	result := map[int][]models.WaterArea{}
+	if len(recordIDs) == 0 {
+		return result, nil
+	}
+	rows, err := db.Query(`
+		SELECT
+			id, record_id, plot_size, water_flow_rate, tank_size, water_amount, irrigation_time,
+			fertilizer_start_time, fertilizer_total_time, fertilizer_flow_rate, moisture_points, negative
+		FROM water_areas
+		WHERE record_id IN (?`+strings.Repeat(",?", len(recordIDs)-1)+`)
+		ORDER BY id
+	`, recordIDs...)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
 	}
-	defer areaRows.Close()
+	defer rows.Close()
 
-	for areaRows.Next() {
+	for rows.Next() {
 		var area models.WaterArea
 		var negative int
-		err := areaRows.Scan(
+		err := rows.Scan(
 			&area.ID, &area.RecordID, &area.PlotSize, &area.WaterFlowRate, &area.TankSize, &area.WaterAmount, &area.IrrigationTime,
 			&area.FertilizerStartTime, &area.FertilizerTotalTime, &area.FertilizerFlowRate, &area.MoisturePoints, &negative,
 		)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "解析灌溉区域失败"})
+			return nil, err
+		}
+		area.Negative = negative != 0
+		result[area.RecordID] = append(result[area.RecordID], area)
+	}
+	return result, rows.Err()
+}
+
+// UpdateIrrigationRecord 更新灌溉记录并整体替换区域数据，修改前的内容记入编辑历史
+func (c *IrrigationController) UpdateIrrigationRecord(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Query("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var requestData models.IrrigationRequest
+	if err := ctx.ShouldBindJSON(&requestData); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if status, err := applyPlotToIrrigation(c.DB, userID, &requestData); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyIrrigationDefaults(c.DB, &requestData); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applySensorMoisture(c.DB, userID, &requestData); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateIrrigationAreas(&requestData); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fillFertigationTimes(&requestData)
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if status, err := lockPlot(tx, userID, requestData.PlotID); err != nil {
+		tx.Rollback()
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous, status, err := lockWaterRecord(tx, id, userID)
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	changes := diffWaterRecord(previous, requestData)
+	if err = insertWaterRecordHistory(tx, previous, userID, models.WaterRecordActionUpdate, changes); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE water_records SET
+			irrigation_mode = ?, efficiency = ?, crop_type = ?, depth = ?, optimal_moisture = ?,
+			soil_type = ?, field_capacity = ?, soil_density = ?, plot_id = ?
+		WHERE id = ? AND user_id = ?
+	`, requestData.IrrigationMode, requestData.Efficiency, requestData.CropType, requestData.Depth, requestData.OptimalMoisture,
+		requestData.SoilType, requestData.FieldCapacity, requestData.SoilDensity, nullableID(requestData.PlotID), id, userID)
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err = tx.Exec("DELETE FROM water_areas WHERE record_id = ?", id); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err = insertWaterAreas(tx, int64(id), requestData.Areas); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":      200,
+		"msg":       "ok",
+		"record_id": id,
+		"changes":   changes,
+	})
+}
+
+// DeleteIrrigationRecord 删除灌溉记录及其区域数据，删除前的内容记入编辑历史
+func (c *IrrigationController) DeleteIrrigationRecord(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Query("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous, status, err := lockWaterRecord(tx, id, userID)
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = insertWaterRecordHistory(tx, previous, userID, models.WaterRecordActionDelete, nil); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err = tx.Exec("DELETE FROM water_areas WHERE record_id = ?", id); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err = tx.Exec("DELETE FROM water_records WHERE id = ? AND user_id = ?", id, userID); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// GetIrrigationRecordHistory 获取灌溉记录的编辑历史，记录删除后仍可查询
+func (c *IrrigationController) GetIrrigationRecordHistory(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Query("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	rows, err := c.DB.Query(`
+		SELECT id, record_id, action, snapshot, changes, created_at
+		FROM water_record_histories
+		WHERE record_id = ? AND user_id = ?
+		ORDER BY id DESC
+	`, id, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "查询编辑历史失败"})
+		return
+	}
+	defer rows.Close()
+
+	histories := []models.WaterRecordHistory{}
+	for rows.Next() {
+		var history models.WaterRecordHistory
+		var snapshot, changes []byte
+		var createdAt time.Time
+		if err := rows.Scan(&history.ID, &history.RecordID, &history.Action, &snapshot, &changes, &createdAt); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "解析编辑历史失败"})
 			return
 		}
+		history.Snapshot = json.RawMessage(snapshot)
+		if len(changes) > 0 {
+			if err := json.Unmarshal(changes, &history.Changes); err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "解析编辑历史失败"})
+				return
+			}
+		}
+		history.CreatedAt = createdAt.Format(dateTimeLayout)
+		histories = append(histories, history)
+	}
 
-		record.Areas = append(record.Areas, area)
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "解析编辑历史失败"})
+		return
 	}
 
-	ctx.JSON(http.StatusOK,
-		gin.H{
-			"code": 200,
-			"msg":  "ok",
-			"data": record,
-		},
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": histories,
+	})
+}
+
+// lockWaterRecord 在事务中锁定并读取当前用户的灌溉记录，返回失败时对应的 HTTP 状态码
+func lockWaterRecord(tx *sql.Tx, id, userID int) (models.WaterRecord, int, error) {
+	var lockedID int
+	err := tx.QueryRow("SELECT id FROM water_records WHERE id = ? AND user_id = ? FOR UPDATE", id, userID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return models.WaterRecord{}, http.StatusNotFound, errors.New("irrigation record not found")
+	}
+	if err != nil {
+		return models.WaterRecord{}, http.StatusInternalServerError, err
+	}
+
+	record, err := loadWaterRecord(tx, id, userID)
+	if err != nil {
+		return record, http.StatusInternalServerError, err
+	}
+	return record, 0, nil
+}
+
+// insertWaterRecordHistory 记录灌溉记录修改或删除前的快照
+func insertWaterRecordHistory(tx *sql.Tx, previous models.WaterRecord, userID int, action string, changes []string) error {
+	snapshot, err := json.Marshal(previous)
+	if err != nil {
+		return err
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO water_record_histories (record_id, user_id, action, snapshot, changes) VALUES (?,?,?,?,?)",
+		previous.ID, userID, action, snapshot, changesJSON,
 	)
+	return err
+}
+
+// diffWaterRecord 比较修改前后的灌溉记录，返回发生变化的字段
+func diffWaterRecord(previous models.WaterRecord, next models.IrrigationRequest) []string {
+	changes := []string{}
+	addIf := func(changed bool, field string) {
+		if changed {
+			changes = append(changes, field)
+		}
+	}
+	addIf(previous.IrrigationMode != next.IrrigationMode, "irrigationMode")
+	addIf(previous.Efficiency != next.Efficiency, "efficiency")
+	addIf(previous.CropType != next.CropType, "cropType")
+	addIf(previous.Depth != next.Depth, "depth")
+	addIf(previous.OptimalMoisture != next.OptimalMoisture, "optimalMoisture")
+	addIf(previous.SoilType != next.SoilType, "soilType")
+	addIf(previous.FieldCapacity != next.FieldCapacity, "fieldCapacity")
+	addIf(previous.SoilDensity != next.SoilDensity, "soilDensity")
+	addIf(previous.PlotID != next.PlotID, "plotId")
+
+	if len(previous.Areas) != len(next.Areas) {
+		changes = append(changes, "areas")
+	}
+	for i := 0; i < len(previous.Areas) && i < len(next.Areas); i++ {
+		before, after := previous.Areas[i], next.Areas[i]
+		prefix := fmt.Sprintf("areas[%d].", i)
+		var points []models.MoisturePoint
+		json.Unmarshal([]byte(before.MoisturePoints), &points)
+		fertilizerFlowRate, _ := strconv.ParseFloat(before.FertilizerFlowRate, 64)
+
+		addIf(before.PlotSize != after.PlotSize, prefix+"plotSize")
+		addIf(before.WaterFlowRate != after.WaterFlowRate, prefix+"waterFlowRate")
+		addIf(before.TankSize != after.TankSize, prefix+"tankSize")
+		addIf(before.WaterAmount != after.WaterAmount, prefix+"waterAmount")
+		addIf(before.IrrigationTime != after.IrrigationTime, prefix+"irrigationTime")
+		addIf(before.FertilizerStartTime != after.FertilizerStartTime, prefix+"fertilizerStartTime")
+		addIf(before.FertilizerTotalTime != after.FertilizerTotalTime, prefix+"fertilizerTotalTime")
+		addIf(fertilizerFlowRate != after.FertilizerFlowRate, prefix+"fertilizerFlowRate")
+		addIf(!equalMoisturePoints(points, after.MoisturePoints), prefix+"moisturePoints")
+	}
+	return changes
+}
+
+// equalMoisturePoints 比较两组水分点是否相同
+func equalMoisturePoints(a, b []models.MoisturePoint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Value != b[i].Value {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+)
+
+// IrrigationReferenceController 处理土壤质地与作物参考参数相关的请求
+type IrrigationReferenceController struct {
+	DB *sql.DB
+}
+
+// NewIrrigationReferenceController 创建一个新的IrrigationReferenceController实例
+func NewIrrigationReferenceController(db *sql.DB) *IrrigationReferenceController {
+	return &IrrigationReferenceController{DB: db}
+}
+
+const (
+	soilTextureColumns = "id, code, name, field_capacity, wilting_point, bulk_density, infiltration_rate, description"
+	cropColumns        = "id, code, name, root_depth_initial, root_depth_development, root_depth_mid, root_depth_late, optimal_moisture_min, optimal_moisture_max, " +
+		"kc_initial, kc_mid, kc_end, stage_initial_days, stage_development_days, stage_mid_days, stage_late_days, description"
+)
+
+// scanSoilTexture 扫描一行土壤质地数据
+func scanSoilTexture(scanner interface{ Scan(...interface{}) error }) (models.SoilTexture, error) {
+	var texture models.SoilTexture
+	var description sql.NullString
+	err := scanner.Scan(&texture.ID, &texture.Code, &texture.Name, &texture.FieldCapacity, &texture.WiltingPoint,
+		&texture.BulkDensity, &texture.InfiltrationRate, &description)
+	texture.Description = description.String
+	return texture, err
+}
+
+// scanCrop 扫描一行作物数据
+func scanCrop(scanner interface{ Scan(...interface{}) error }) (models.Crop, error) {
+	var crop models.Crop
+	var description sql.NullString
+	err := scanner.Scan(&crop.ID, &crop.Code, &crop.Name, &crop.RootDepthInitial, &crop.RootDepthDevelopment,
+		&crop.RootDepthMid, &crop.RootDepthLate, &crop.OptimalMoistureMin, &crop.OptimalMoistureMax,
+		&crop.KcInitial, &crop.KcMid, &crop.KcEnd, &crop.StageInitialDays, &crop.StageDevelopmentDays,
+		&crop.StageMidDays, &crop.StageLateDays, &description)
+	crop.Description = description.String
+	return crop, err
+}
+
+// GetSoilTextures 获取土壤质地参考参数，可按编码或名称筛选
+func (c *IrrigationReferenceController) GetSoilTextures(ctx *gin.Context) {
+	query := "SELECT " + soilTextureColumns + " FROM soil_textures"
+	var params []interface{}
+	if keyword := ctx.Query("keyword"); keyword != "" {
+		query += " WHERE code = ? OR name LIKE ?"
+		params = append(params, keyword, "%"+keyword+"%")
+	}
+	query += " ORDER BY field_capacity"
+
+	rows, err := c.DB.Query(query, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for soil textures"})
+		return
+	}
+	defer rows.Close()
+
+	textures := []models.SoilTexture{}
+	for rows.Next() {
+		texture, err := scanSoilTexture(rows)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning soil texture row"})
+			return
+		}
+		textures = append(textures, texture)
+	}
+
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating soil texture rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": textures,
+	})
+}
+
+// GetCrops 获取作物参考参数，可按编码或名称筛选
+func (c *IrrigationReferenceController) GetCrops(ctx *gin.Context) {
+	query := "SELECT " + cropColumns + " FROM crops"
+	var params []interface{}
+	if keyword := ctx.Query("keyword"); keyword != "" {
+		query += " WHERE code = ? OR name LIKE ?"
+		params = append(params, keyword, "%"+keyword+"%")
+	}
+	query += " ORDER BY id"
+
+	rows, err := c.DB.Query(query, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for crops"})
+		return
+	}
+	defer rows.Close()
+
+	crops := []models.Crop{}
+	for rows.Next() {
+		crop, err := scanCrop(rows)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning crop row"})
+			return
+		}
+		crops = append(crops, crop)
+	}
+
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating crop rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": crops,
+	})
+}
+
+// findSoilTexture 按编码或名称查找土壤质地，未找到时返回 nil
+func findSoilTexture(db *sql.DB, soilType string) (*models.SoilTexture, error) {
+	soilType = strings.TrimSpace(soilType)
+	if soilType == "" {
+		return nil, nil
+	}
+	texture, err := scanSoilTexture(db.QueryRow("SELECT "+soilTextureColumns+" FROM soil_textures WHERE code = ? OR name = ? LIMIT 1", soilType, soilType))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &texture, nil
+}
+
+// findCrop 按编码或名称查找作物，未找到时返回 nil
+func findCrop(db *sql.DB, cropType string) (*models.Crop, error) {
+	cropType = strings.TrimSpace(cropType)
+	if cropType == "" {
+		return nil, nil
+	}
+	crop, err := scanCrop(db.QueryRow("SELECT "+cropColumns+" FROM crops WHERE code = ? OR name = ? LIMIT 1", cropType, cropType))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &crop, nil
+}
+
+// applyIrrigationDefaults 根据土壤质地与作物参考表补全未填写的田间持水量、容重、计划湿润层深度与适宜含水率
+func applyIrrigationDefaults(db *sql.DB, req *models.IrrigationRequest) error {
+	texture, err := findSoilTexture(db, req.SoilType)
+	if err != nil {
+		return err
+	}
+	if texture != nil {
+		if req.FieldCapacity == 0 {
+			req.FieldCapacity = texture.FieldCapacity
+		}
+		if req.SoilDensity == 0 {
+			req.SoilDensity = texture.BulkDensity
+		}
+	}
+
+	crop, err := findCrop(db, req.CropType)
+	if err != nil {
+		return err
+	}
+	if crop != nil {
+		if req.Depth == 0 {
+			req.Depth = crop.RootDepth(req.GrowthStage)
+		}
+		if req.OptimalMoisture == 0 {
+			req.OptimalMoisture = (crop.OptimalMoistureMin + crop.OptimalMoistureMax) / 2
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go-mengtuobang/models"
+)
+
+// cropCoefficient FAO-56 分段作物系数曲线
+type cropCoefficient struct {
+	Ini, Mid, End float64
+	// Stages 初始期、快速发育期、生育中期、成熟期的天数
+	Stages [4]int
+}
+
+// SeasonLength 生育期总天数
+func (k cropCoefficient) SeasonLength() int {
+	return k.Stages[0] + k.Stages[1] + k.Stages[2] + k.Stages[3]
+}
+
+// At 播种（定植）后第 day 天的作物系数，发育期与成熟期线性插值
+func (k cropCoefficient) At(day int) float64 {
+	ini, dev, mid, late := k.Stages[0], k.Stages[1], k.Stages[2], k.Stages[3]
+	switch {
+	case day < ini:
+		return k.Ini
+	case day < ini+dev:
+		return k.Ini + (k.Mid-k.Ini)*float64(day-ini)/float64(dev)
+	case day < ini+dev+mid:
+		return k.Mid
+	case day < ini+dev+mid+late:
+		return k.Mid + (k.End-k.Mid)*float64(day-ini-dev-mid)/float64(late)
+	default:
+		return k.End
+	}
+}
+
+// lookupCropCoefficient 按作物编码或名称从作物参考表读取作物系数曲线，作物不存在或未配置作物系数时 ok 为 false
+func lookupCropCoefficient(db *sql.DB, cropType string) (coefficient cropCoefficient, ok bool, err error) {
+	crop, err := findCrop(db, cropType)
+	if err != nil || crop == nil || crop.KcMid <= 0 {
+		return cropCoefficient{}, false, err
+	}
+	coefficient = cropCoefficient{
+		Ini:    crop.KcInitial,
+		Mid:    crop.KcMid,
+		End:    crop.KcEnd,
+		Stages: [4]int{crop.StageInitialDays, crop.StageDevelopmentDays, crop.StageMidDays, crop.StageLateDays},
+	}
+	return coefficient, coefficient.SeasonLength() > 0, nil
+}
+
+// effectiveRainfall 有效降雨量（mm），小于 5 mm 的降雨视为被冠层截留和蒸发
+func effectiveRainfall(rainfall float64) float64 {
+	if rainfall < 5 {
+		return 0
+	}
+	return rainfall * 0.8
+}
+
+// scheduleIrrigation 根据逐日 ET0 与作物系数进行根区土壤水量平衡，亏缺达到易利用水量时安排灌溉并补充至田间持水量
+//
+// 水深换算：1 cm 土层、容重 ρ（g/cm³）、质量含水量 θ（%）对应 10·ρ·θ/100 mm 水
+func scheduleIrrigation(record models.WaterRecord, coefficient cropCoefficient, plantingDate time.Time, weather []models.WeatherDay) ([]models.PlotSchedule, error) {
+	if record.Depth <= 0 || record.SoilDensity <= 0 || record.FieldCapacity <= 0 {
+		return nil, errors.New("irrigation record must have depth, soilDensity and fieldCapacity")
+	}
+	if record.OptimalMoisture <= 0 || record.OptimalMoisture >= 100 {
+		return nil, errors.New("optimalMoisture of the irrigation record must be between 0 and 100")
+	}
+	efficiency := irrigationEfficiency(record.Efficiency)
+	if efficiency <= 0 || efficiency > 1 {
+		return nil, errors.New("efficiency of the irrigation record must be between 0 and 100")
+	}
+	if len(record.Areas) == 0 {
+		return nil, errors.New("irrigation record has no areas")
+	}
+
+	waterDepth := func(moisture float64) float64 {
+		return record.Depth * 10 * record.SoilDensity * moisture / 100
+	}
+	readilyAvailable := waterDepth(record.FieldCapacity * (1 - record.OptimalMoisture/100))
+
+	plots := make([]models.PlotSchedule, 0, len(record.Areas))
+	for _, area := range record.Areas {
+		var points []models.MoisturePoint
+		if area.MoisturePoints != "" {
+			if err := json.Unmarshal([]byte(area.MoisturePoints), &points); err != nil {
+				return nil, fmt.Errorf("invalid moisture points of area %d", area.ID)
+			}
+		}
+		depletion := 0.0
+		if len(points) > 0 {
+			depletion = math.Max(waterDepth(record.FieldCapacity-averageMoisture(points)), 0)
+		}
+
+		plot := models.PlotSchedule{
+			AreaId:           area.ID,
+			PlotSize:         area.PlotSize,
+			ReadilyAvailable: round2(readilyAvailable),
+			Days:             []models.WaterBalanceDay{},
+			Events:           []models.IrrigationEvent{},
+		}
+		for _, day := range weather {
+			date, err := time.Parse(dateLayout, day.Date)
+			if err != nil {
+				return nil, fmt.Errorf("invalid weather date %q", day.Date)
+			}
+			kc := coefficient.At(int(date.Sub(plantingDate).Hours() / 24))
+			etc := kc * day.ET0
+			rain := effectiveRainfall(day.Rainfall)
+
+			// 降雨超出亏缺的部分视为深层渗漏
+			depletion = math.Max(depletion-rain+etc, 0)
+			balance := models.WaterBalanceDay{
+				Date:          day.Date,
+				ET0:           round2(day.ET0),
+				Kc:            round2(kc),
+				ETc:           round2(etc),
+				Rainfall:      round2(day.Rainfall),
+				EffectiveRain: round2(rain),
+			}
+			if depletion >= readilyAvailable && depletion > 0 {
+				gross := depletion / efficiency
+				waterAmount := gross / 1000 * area.PlotSize * squareMetersPerMu
+				event := models.IrrigationEvent{
+					Date:           day.Date,
+					NetDepth:       round2(depletion),
+					GrossDepth:     round2(gross),
+					WaterAmount:    round2(waterAmount),
+					IrrigationTime: formatFloat(0),
+				}
+				if area.WaterFlowRate > 0 {
+					event.IrrigationTime = formatFloat(waterAmount / area.WaterFlowRate)
+				}
+				plot.Events = append(plot.Events, event)
+				balance.Irrigation = event.NetDepth
+				depletion = 0
+			}
+			balance.Depletion = round2(depletion)
+			plot.Days = append(plot.Days, balance)
+		}
+		plots = append(plots, plot)
+	}
+	return plots, nil
+}
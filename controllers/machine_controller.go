@@ -119,7 +119,7 @@ func (mc *MachineController) CheckMachineCode(c *gin.Context) {
 		return
 	}
 
-	if machineCode.UserID != nil {
+	if machineCode.UserID != nil && *machineCode.UserID > 0 {
 		c.JSON(http.StatusOK, gin.H{
 			"code": 403,
 			"msg":  "机器码已被绑定",
@@ -175,7 +175,7 @@ func (mc *MachineController) BindMachineCode(c *gin.Context) {
 		return
 	}
 
-	if machineCode.UserID != nil {
+	if machineCode.UserID != nil && *machineCode.UserID > 0 {
 		c.JSON(http.StatusOK, gin.H{
 			"code": 403,
 			"msg":  "机器码已被绑定",
@@ -285,7 +285,7 @@ func (mc *MachineController) UnbindMachineCode(c *gin.Context) {
 
 	// 更新机器码信息
 	now := time.Now()
-	query = `UPDATE machine_codes SET user_id = '', updated_at = ? WHERE id = ?`
+	query = `UPDATE machine_codes SET user_id = NULL, updated_at = ? WHERE id = ?`
 	_, err = mc.DB.Exec(query, now, id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"go-mengtuobang/models"
+	"go-mengtuobang/utils"
+)
+
+// 地块边界坐标系
+const (
+	coordinateSystemWGS84 = "wgs84"
+	coordinateSystemGCJ02 = "gcj02"
+)
+
+// muPerHectare 每公顷的亩数
+const muPerHectare = 15.0
+
+// plotGeometry 解析后的地块边界，坐标均为 WGS84
+type plotGeometry struct {
+	Polygons []utils.Polygon
+	Area     float64 // 面积（m²）
+	Centroid utils.LngLat
+	Min, Max utils.LngLat // 外包矩形
+}
+
+// parsePlotBoundary 解析 GeoJSON 边界，按 crs 转换为 WGS84 并计算面积、质心与外包矩形
+//
+// 支持 Polygon、MultiPolygon 以及几何为二者之一的 Feature；未闭合的环自动闭合，高程等多余坐标分量忽略
+func parsePlotBoundary(boundary json.RawMessage, crs string) (*plotGeometry, error) {
+	var object struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+		Geometry    json.RawMessage `json:"geometry"`
+	}
+	if err := json.Unmarshal(boundary, &object); err != nil {
+		return nil, errors.New("boundary must be a GeoJSON object")
+	}
+	if object.Type == "Feature" {
+		if len(object.Geometry) == 0 || string(object.Geometry) == "null" {
+			return nil, errors.New("boundary feature has no geometry")
+		}
+		return parsePlotBoundary(object.Geometry, crs)
+	}
+
+	var raw [][][][]float64
+	switch object.Type {
+	case "Polygon":
+		var polygon [][][]float64
+		if err := json.Unmarshal(object.Coordinates, &polygon); err != nil {
+			return nil, errors.New("boundary Polygon coordinates must be an array of rings")
+		}
+		raw = [][][][]float64{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(object.Coordinates, &raw); err != nil {
+			return nil, errors.New("boundary MultiPolygon coordinates must be an array of polygons")
+		}
+	default:
+		return nil, errors.New("boundary type must be Polygon, MultiPolygon or a Feature of either")
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("boundary coordinates are required")
+	}
+
+	geometry := &plotGeometry{
+		Min: utils.LngLat{math.Inf(1), math.Inf(1)},
+		Max: utils.LngLat{math.Inf(-1), math.Inf(-1)},
+	}
+	for i, rawPolygon := range raw {
+		if len(rawPolygon) == 0 {
+			return nil, fmt.Errorf("polygon %d has no rings", i+1)
+		}
+		polygon := make(utils.Polygon, 0, len(rawPolygon))
+		for j, rawRing := range rawPolygon {
+			ring := make([]utils.LngLat, 0, len(rawRing)+1)
+			for _, position := range rawRing {
+				if len(position) < 2 {
+					return nil, fmt.Errorf("polygon %d ring %d has a position with fewer than 2 coordinates", i+1, j+1)
+				}
+				lng, lat := position[0], position[1]
+				if lng < -180 || lng > 180 || lat < -90 || lat > 90 {
+					return nil, fmt.Errorf("position [%v, %v] is out of range, coordinates must be [longitude, latitude]", lng, lat)
+				}
+				if crs == coordinateSystemGCJ02 {
+					lng, lat = utils.GCJ02ToWGS84(lng, lat)
+				}
+				ring = append(ring, utils.LngLat{lng, lat})
+				geometry.Min[0], geometry.Min[1] = math.Min(geometry.Min[0], lng), math.Min(geometry.Min[1], lat)
+				geometry.Max[0], geometry.Max[1] = math.Max(geometry.Max[0], lng), math.Max(geometry.Max[1], lat)
+			}
+			if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+				ring = append(ring, ring[0])
+			}
+			if len(ring) < 4 {
+				return nil, fmt.Errorf("polygon %d ring %d must have at least 3 distinct positions", i+1, j+1)
+			}
+			polygon = append(polygon, ring)
+		}
+		geometry.Polygons = append(geometry.Polygons, polygon)
+	}
+
+	geometry.Area = utils.PolygonArea(geometry.Polygons)
+	if geometry.Area <= 0 {
+		return nil, errors.New("boundary encloses no area")
+	}
+	geometry.Centroid = utils.PolygonCentroid(geometry.Polygons)
+	return geometry, nil
+}
+
+// boundaryGeoJSON 将 WGS84 多边形输出为 GeoJSON，crs 为 gcj02 时转换为 GCJ-02 坐标，坐标保留 7 位小数（约 1 cm）
+func boundaryGeoJSON(polygons []utils.Polygon, crs string) (json.RawMessage, error) {
+	round := func(v float64) float64 { return math.Round(v*1e7) / 1e7 }
+	coordinates := make([][][][2]float64, 0, len(polygons))
+	for _, polygon := range polygons {
+		rings := make([][][2]float64, 0, len(polygon))
+		for _, ring := range polygon {
+			positions := make([][2]float64, 0, len(ring))
+			for _, p := range ring {
+				lng, lat := p[0], p[1]
+				if crs == coordinateSystemGCJ02 {
+					lng, lat = utils.WGS84ToGCJ02(lng, lat)
+				}
+				positions = append(positions, [2]float64{round(lng), round(lat)})
+			}
+			rings = append(rings, positions)
+		}
+		coordinates = append(coordinates, rings)
+	}
+
+	if len(coordinates) == 1 {
+		return json.Marshal(map[string]interface{}{"type": "Polygon", "coordinates": coordinates[0]})
+	}
+	return json.Marshal(map[string]interface{}{"type": "MultiPolygon", "coordinates": coordinates})
+}
+
+// applyPlotGeometry 解析请求中的边界，将边界改存为 WGS84 坐标并以边界面积覆盖地块面积，未提供边界时返回 nil
+func applyPlotGeometry(plot *models.Plot) (*plotGeometry, error) {
+	if len(plot.Boundary) == 0 || string(plot.Boundary) == "null" {
+		plot.Boundary = nil
+		return nil, nil
+	}
+	geometry, err := parsePlotBoundary(plot.Boundary, plot.CoordinateSystem)
+	if err != nil {
+		return nil, err
+	}
+	if plot.Boundary, err = boundaryGeoJSON(geometry.Polygons, coordinateSystemWGS84); err != nil {
+		return nil, err
+	}
+	plot.CoordinateSystem = coordinateSystemWGS84
+	plot.Area = round2(geometry.Area / squareMetersPerMu)
+	return geometry, nil
+}
+
+// convertPlotCoordinates 将地块边界与质心转换为指定坐标系输出，crs 为空或 wgs84 时不转换，
+// 无法解析的边界（早期未经解析保存的数据）按原样返回
+func convertPlotCoordinates(plot *models.Plot, crs string) error {
+	if crs != coordinateSystemGCJ02 || len(plot.Boundary) == 0 {
+		return nil
+	}
+	geometry, err := parsePlotBoundary(plot.Boundary, coordinateSystemWGS84)
+	if err != nil {
+		return nil
+	}
+	if plot.Boundary, err = boundaryGeoJSON(geometry.Polygons, coordinateSystemGCJ02); err != nil {
+		return err
+	}
+	if plot.Centroid != nil {
+		plot.Centroid.Lng, plot.Centroid.Lat = utils.WGS84ToGCJ02(plot.Centroid.Lng, plot.Centroid.Lat)
+	}
+	plot.CoordinateSystem = coordinateSystemGCJ02
+	return nil
+}
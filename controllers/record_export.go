@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// exportSheet 导出文件中的一个工作表，按 Query 逐行查询并写出，不在内存中缓存全部记录
+type exportSheet struct {
+	Key    string // CSV 导出时 sheet 参数使用的名称
+	Title  string // XLSX 工作表名称
+	Header []string
+	Query  string
+	Params []interface{}
+	Row    func(rows *sql.Rows) ([]interface{}, error)
+}
+
+// exportFloat 可空数值的导出值，空值导出为空单元格
+func exportFloat(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+// exportID 可空关联 ID 的导出值，0 导出为空单元格
+func exportID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// exportBool 布尔值导出为“是”“否”
+func exportBool(v bool) string {
+	if v {
+		return "是"
+	}
+	return "否"
+}
+
+// exportTime 将数据库返回的时间文本格式化为 dateTimeLayout，无法解析时原样导出
+func exportTime(value string) string {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t.Format(dateTimeLayout)
+	}
+	return value
+}
+
+// exportText 以 =、+、-、@ 开头的文本前加单引号，防止 CSV 在电子表格中打开时被当作公式执行
+func exportText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// exportCell 将单元格的值格式化为 CSV 文本
+//
+// XLSX 中的文本单元格按字符串类型写入，不会被当作公式，因此只有 CSV 需要 exportText 转义
+func exportCell(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return exportText(value)
+	case int:
+		return strconv.Itoa(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// writeRecordExport 按 format 参数导出记录
+//
+// format=xlsx（默认）时每个 exportSheet 写为一个工作表；format=csv 时只导出 sheet 参数指定的表，
+// 默认第一个（主记录），子表通过首列的主记录 ID 与主表关联
+func writeRecordExport(ctx *gin.Context, db *sql.DB, name string, sheets []exportSheet) {
+	fileName := name + "-" + time.Now().Format("20060102")
+	switch format := ctx.DefaultQuery("format", "xlsx"); format {
+	case "xlsx":
+		writeXLSXExport(ctx, db, fileName+".xlsx", sheets)
+	case "csv":
+		key := ctx.DefaultQuery("sheet", sheets[0].Key)
+		var keys []string
+		for _, sheet := range sheets {
+			if sheet.Key == key {
+				writeCSVExport(ctx, db, fileName+"-"+sheet.Key+".csv", sheet)
+				return
+			}
+			keys = append(keys, sheet.Key)
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "sheet must be one of " + strings.Join(keys, ", ")})
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be xlsx or csv"})
+	}
+}
+
+// writeCSVExport 以 CSV 流式写出单个表，带 UTF-8 BOM 以便 Excel 正确识别中文
+func writeCSVExport(ctx *gin.Context, db *sql.DB, fileName string, sheet exportSheet) {
+	rows, err := db.Query(sheet.Query, sheet.Params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	ctx.Status(http.StatusOK)
+	ctx.Writer.WriteString("\ufeff")
+
+	// 响应头已发出，之后的错误只能中断输出并记录日志
+	writer := csv.NewWriter(ctx.Writer)
+	if err := writer.Write(sheet.Header); err != nil {
+		log.Printf("Failed to export %s: %v", fileName, err)
+		return
+	}
+	record := make([]string, len(sheet.Header))
+	for rows.Next() {
+		values, err := sheet.Row(rows)
+		if err != nil {
+			log.Printf("Failed to export %s: %v", fileName, err)
+			return
+		}
+		for i, value := range values {
+			record[i] = exportCell(value)
+		}
+		if err := writer.Write(record); err != nil {
+			log.Printf("Failed to export %s: %v", fileName, err)
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to export %s: %v", fileName, err)
+		return
+	}
+	writer.Flush()
+}
+
+// writeXLSXExport 以 XLSX 写出全部表，各表通过流式写入器逐行写入
+//
+// 所有表在同一个只读事务中查询，保证主表与子表取自同一时刻的数据
+func writeXLSXExport(ctx *gin.Context, db *sql.DB, fileName string, sheets []exportSheet) {
+	tx, err := db.BeginTx(ctx.Request.Context(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := f.SetSheetName(f.GetSheetName(0), sheet.Title); err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		} else if _, err := f.NewSheet(sheet.Title); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := writeXLSXSheet(f, tx, sheet); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	ctx.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	ctx.Status(http.StatusOK)
+	if err := f.Write(ctx.Writer); err != nil {
+		log.Printf("Failed to export %s: %v", fileName, err)
+	}
+}
+
+// writeXLSXSheet 查询并写出一个工作表
+func writeXLSXSheet(f *excelize.File, db sqlQuerier, sheet exportSheet) error {
+	stream, err := f.NewStreamWriter(sheet.Title)
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(sheet.Header))
+	for i, name := range sheet.Header {
+		header[i] = name
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	rows, err := db.Query(sheet.Query, sheet.Params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for row := 2; rows.Next(); row++ {
+		values, err := sheet.Row(rows)
+		if err != nil {
+			return err
+		}
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+		if err := stream.SetRow(cell, values); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return stream.Flush()
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go-mengtuobang/models"
+)
+
+const (
+	// defaultRotationWindowStart、defaultRotationWindowEnd 默认每日灌溉窗口
+	defaultRotationWindowStart = "06:00"
+	defaultRotationWindowEnd   = "18:00"
+	// maxRotationDays 轮灌排程最多占用的灌溉窗口数
+	maxRotationDays = 31
+	// clockLayout 灌溉窗口时刻格式
+	clockLayout = "15:04"
+)
+
+// rotationZone 排程中的区域状态
+type rotationZone struct {
+	index     int
+	flow      float64
+	remaining time.Duration
+	started   time.Time
+}
+
+// planRotation 在水泵最大流量与每日灌溉窗口约束下为各区域安排轮灌顺序
+//
+// 灌溉时长长的区域优先开启，同一时刻运行区域的总流量不超过水泵流量，
+// 有区域结束时按顺序补入能放下的等待区域；窗口结束时未完成的区域暂停，于下一窗口开始时优先继续
+func planRotation(req models.RotationRequest, now time.Time) (*models.RotationPlan, error) {
+	if len(req.Areas) == 0 {
+		return nil, errors.New("at least one area is required")
+	}
+	if req.PumpCapacity <= 0 {
+		return nil, errors.New("pumpCapacity must be greater than 0")
+	}
+
+	windowStart, windowEnd := req.WindowStart, req.WindowEnd
+	if windowStart == "" {
+		windowStart = defaultRotationWindowStart
+	}
+	if windowEnd == "" {
+		windowEnd = defaultRotationWindowEnd
+	}
+	openClock, err := time.Parse(clockLayout, windowStart)
+	if err != nil {
+		return nil, errors.New("windowStart must be formatted as HH:MM")
+	}
+	closeClock, err := time.Parse(clockLayout, windowEnd)
+	if err != nil {
+		return nil, errors.New("windowEnd must be formatted as HH:MM")
+	}
+	if openClock.Equal(closeClock) {
+		return nil, errors.New("windowStart and windowEnd must differ")
+	}
+
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if req.StartDate != "" {
+		if day, err = time.ParseInLocation(dateLayout, req.StartDate, time.Local); err != nil {
+			return nil, errors.New("startDate must be formatted as YYYY-MM-DD")
+		}
+	}
+
+	// 未提供灌水量的区域按土壤参数计算
+	var calculated []models.IrrigationAreaResult
+	for _, area := range req.Areas {
+		if area.WaterAmount <= 0 {
+			if calculated, err = calculateIrrigation(req.IrrigationRequest); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	plan := &models.RotationPlan{
+		PumpCapacity: req.PumpCapacity,
+		Areas:        make([]models.RotationArea, 0, len(req.Areas)),
+	}
+	var queue []*rotationZone
+	for i, area := range req.Areas {
+		waterAmount := area.WaterAmount
+		if waterAmount <= 0 {
+			waterAmount = calculated[i].WaterAmount
+		}
+		flowRate := area.WaterFlowRate
+		if flowRate <= 0 {
+			flowRate = area.FlowRate
+		}
+		if flowRate <= 0 {
+			return nil, fmt.Errorf("waterFlowRate of area %d must be greater than 0", area.AreaId)
+		}
+		if flowRate > req.PumpCapacity {
+			return nil, fmt.Errorf("flow rate of area %d is %.2f m³/h, exceeds the pump capacity of %.2f m³/h", area.AreaId, flowRate, req.PumpCapacity)
+		}
+
+		runtime := time.Duration(waterAmount / flowRate * float64(time.Hour)).Round(time.Minute)
+		plan.Areas = append(plan.Areas, models.RotationArea{
+			AreaId:         area.AreaId,
+			WaterAmount:    round2(waterAmount),
+			FlowRate:       flowRate,
+			IrrigationTime: round2(runtime.Hours()),
+			Segments:       []models.RotationSegment{},
+		})
+		if runtime > 0 {
+			queue = append(queue, &rotationZone{index: i, flow: flowRate, remaining: runtime})
+		}
+	}
+	sort.SliceStable(queue, func(a, b int) bool { return queue[a].remaining > queue[b].remaining })
+
+	var first, last time.Time
+	var pumpTime time.Duration
+	closeSegment := func(zone *rotationZone, end time.Time) {
+		area := &plan.Areas[zone.index]
+		area.Segments = append(area.Segments, models.RotationSegment{
+			StartTime: zone.started.Format(dateTimeLayout),
+			EndTime:   end.Format(dateTimeLayout),
+			Duration:  round2(end.Sub(zone.started).Hours()),
+		})
+		if area.StartTime == "" {
+			area.StartTime = zone.started.Format(dateTimeLayout)
+		}
+		area.EndTime = end.Format(dateTimeLayout)
+		if first.IsZero() || zone.started.Before(first) {
+			first = zone.started
+		}
+		if end.After(last) {
+			last = end
+		}
+	}
+
+	for ; len(queue) > 0; day = day.AddDate(0, 0, 1) {
+		windowOpen := time.Date(day.Year(), day.Month(), day.Day(), openClock.Hour(), openClock.Minute(), 0, 0, time.Local)
+		windowClose := time.Date(day.Year(), day.Month(), day.Day(), closeClock.Hour(), closeClock.Minute(), 0, 0, time.Local)
+		if !windowClose.After(windowOpen) {
+			windowClose = windowClose.AddDate(0, 0, 1)
+		}
+		// 不安排已过去的时间
+		if windowOpen.Before(now) {
+			windowOpen = now.Truncate(time.Minute).Add(time.Minute)
+		}
+		if !windowOpen.Before(windowClose) {
+			continue
+		}
+		if plan.Days >= maxRotationDays {
+			return nil, fmt.Errorf("rotation needs more than %d irrigation windows, increase the pump capacity or widen the window", maxRotationDays)
+		}
+		plan.Days++
+
+		t := windowOpen
+		var running []*rotationZone
+		for {
+			used := 0.0
+			for _, zone := range running {
+				used += zone.flow
+			}
+			var waiting []*rotationZone
+			for _, zone := range queue {
+				if used+zone.flow <= req.PumpCapacity+1e-9 {
+					zone.started = t
+					running = append(running, zone)
+					used += zone.flow
+				} else {
+					waiting = append(waiting, zone)
+				}
+			}
+			queue = waiting
+			if used > plan.PeakFlow {
+				plan.PeakFlow = round2(used)
+			}
+
+			next := windowClose
+			for _, zone := range running {
+				if end := t.Add(zone.remaining); end.Before(next) {
+					next = end
+				}
+			}
+			pumpTime += next.Sub(t)
+
+			var still, paused []*rotationZone
+			for _, zone := range running {
+				zone.remaining -= next.Sub(t)
+				switch {
+				case zone.remaining <= 0:
+					closeSegment(zone, next)
+				case next.Equal(windowClose):
+					closeSegment(zone, next)
+					paused = append(paused, zone)
+				default:
+					still = append(still, zone)
+				}
+			}
+			running = still
+			t = next
+
+			if t.Equal(windowClose) {
+				queue = append(paused, queue...)
+				break
+			}
+			if len(running) == 0 && len(queue) == 0 {
+				break
+			}
+		}
+	}
+
+	if !first.IsZero() {
+		plan.StartTime = first.Format(dateTimeLayout)
+		plan.EndTime = last.Format(dateTimeLayout)
+		plan.CycleLength = round2(last.Sub(first).Hours())
+	}
+	plan.PumpHours = round2(pumpTime.Hours())
+	return plan, nil
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go-mengtuobang/models"
+)
+
+// rotationRequest 以 06:00～18:00 为默认窗口、自 2024-05-02 开始的轮灌请求，areas 依次为区域的灌水量与流量
+func rotationRequest(pumpCapacity float64, areas ...[2]float64) models.RotationRequest {
+	req := models.RotationRequest{PumpCapacity: pumpCapacity, StartDate: "2024-05-02"}
+	for i, area := range areas {
+		req.Areas = append(req.Areas, models.AreaData{AreaId: i + 1, WaterAmount: area[0], WaterFlowRate: area[1]})
+	}
+	return req
+}
+
+func TestPlanRotation(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name     string
+		req      models.RotationRequest
+		now      time.Time
+		want     models.RotationPlan
+		segments [][][2]string // 各区域的灌溉时段
+	}{
+		{
+			"zones run together",
+			rotationRequest(20, [2]float64{20, 10}, [2]float64{10, 10}),
+			now,
+			models.RotationPlan{StartTime: "2024-05-02 06:00:00", EndTime: "2024-05-02 08:00:00", CycleLength: 2, PumpHours: 2, PeakFlow: 20, Days: 1},
+			[][][2]string{
+				{{"2024-05-02 06:00:00", "2024-05-02 08:00:00"}},
+				{{"2024-05-02 06:00:00", "2024-05-02 07:00:00"}},
+			},
+		},
+		{
+			"longest zone first when the pump allows one",
+			rotationRequest(10, [2]float64{10, 10}, [2]float64{20, 10}),
+			now,
+			models.RotationPlan{StartTime: "2024-05-02 06:00:00", EndTime: "2024-05-02 09:00:00", CycleLength: 3, PumpHours: 3, PeakFlow: 10, Days: 1},
+			[][][2]string{
+				{{"2024-05-02 08:00:00", "2024-05-02 09:00:00"}},
+				{{"2024-05-02 06:00:00", "2024-05-02 08:00:00"}},
+			},
+		},
+		{
+			"waiting zone fills freed capacity",
+			rotationRequest(15, [2]float64{30, 10}, [2]float64{5, 5}, [2]float64{5, 5}),
+			now,
+			models.RotationPlan{StartTime: "2024-05-02 06:00:00", EndTime: "2024-05-02 09:00:00", CycleLength: 3, PumpHours: 3, PeakFlow: 15, Days: 1},
+			[][][2]string{
+				{{"2024-05-02 06:00:00", "2024-05-02 09:00:00"}},
+				{{"2024-05-02 06:00:00", "2024-05-02 07:00:00"}},
+				{{"2024-05-02 07:00:00", "2024-05-02 08:00:00"}},
+			},
+		},
+		{
+			"paused at window close and resumed next day",
+			rotationRequest(10, [2]float64{150, 10}),
+			now,
+			models.RotationPlan{StartTime: "2024-05-02 06:00:00", EndTime: "2024-05-03 09:00:00", CycleLength: 27, PumpHours: 15, PeakFlow: 10, Days: 2},
+			[][][2]string{
+				{{"2024-05-02 06:00:00", "2024-05-02 18:00:00"}, {"2024-05-03 06:00:00", "2024-05-03 09:00:00"}},
+			},
+		},
+		{
+			"overnight window",
+			func() models.RotationRequest {
+				req := rotationRequest(10, [2]float64{100, 10})
+				req.WindowStart, req.WindowEnd = "20:00", "04:00"
+				return req
+			}(),
+			now,
+			models.RotationPlan{StartTime: "2024-05-02 20:00:00", EndTime: "2024-05-03 22:00:00", CycleLength: 26, PumpHours: 10, PeakFlow: 10, Days: 2},
+			[][][2]string{
+				{{"2024-05-02 20:00:00", "2024-05-03 04:00:00"}, {"2024-05-03 20:00:00", "2024-05-03 22:00:00"}},
+			},
+		},
+		{
+			"starts after now inside today's window",
+			func() models.RotationRequest {
+				req := rotationRequest(10, [2]float64{10, 10})
+				req.StartDate = ""
+				return req
+			}(),
+			time.Date(2024, 5, 2, 10, 30, 20, 0, time.Local),
+			models.RotationPlan{StartTime: "2024-05-02 10:31:00", EndTime: "2024-05-02 11:31:00", CycleLength: 1, PumpHours: 1, PeakFlow: 10, Days: 1},
+			[][][2]string{
+				{{"2024-05-02 10:31:00", "2024-05-02 11:31:00"}},
+			},
+		},
+		{
+			"today's window already closed",
+			func() models.RotationRequest {
+				req := rotationRequest(10, [2]float64{10, 10})
+				req.StartDate = ""
+				return req
+			}(),
+			time.Date(2024, 5, 2, 19, 0, 0, 0, time.Local),
+			models.RotationPlan{StartTime: "2024-05-03 06:00:00", EndTime: "2024-05-03 07:00:00", CycleLength: 1, PumpHours: 1, PeakFlow: 10, Days: 1},
+			[][][2]string{
+				{{"2024-05-03 06:00:00", "2024-05-03 07:00:00"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planRotation(tt.req, tt.now)
+			if err != nil {
+				t.Fatalf("planRotation() error = %v", err)
+			}
+			got := *plan
+			got.PumpCapacity, got.Areas = 0, nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planRotation() = %+v, want %+v", got, tt.want)
+			}
+			segments := make([][][2]string, 0, len(plan.Areas))
+			for _, area := range plan.Areas {
+				var spans [][2]string
+				for _, segment := range area.Segments {
+					spans = append(spans, [2]string{segment.StartTime, segment.EndTime})
+				}
+				segments = append(segments, spans)
+			}
+			if !reflect.DeepEqual(segments, tt.segments) {
+				t.Errorf("planRotation() segments = %v, want %v", segments, tt.segments)
+			}
+		})
+	}
+}
+
+func TestPlanRotationInvalid(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	with := func(req models.RotationRequest, edit func(*models.RotationRequest)) models.RotationRequest {
+		edit(&req)
+		return req
+	}
+	valid := rotationRequest(10, [2]float64{10, 10})
+	tests := []struct {
+		name string
+		req  models.RotationRequest
+	}{
+		{"no areas", rotationRequest(10)},
+		{"no pump capacity", rotationRequest(0, [2]float64{10, 10})},
+		{"bad window start", with(valid, func(r *models.RotationRequest) { r.WindowStart = "6am" })},
+		{"bad window end", with(valid, func(r *models.RotationRequest) { r.WindowEnd = "25:00" })},
+		{"empty window", with(valid, func(r *models.RotationRequest) { r.WindowStart, r.WindowEnd = "08:00", "08:00" })},
+		{"bad start date", with(valid, func(r *models.RotationRequest) { r.StartDate = "2024/05/02" })},
+		{"no flow rate", rotationRequest(10, [2]float64{10, 0})},
+		{"flow rate exceeds pump", rotationRequest(10, [2]float64{10, 12})},
+		{"more windows than allowed", rotationRequest(10, [2]float64{10 * 12 * (maxRotationDays + 1), 10})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := planRotation(tt.req, now); err == nil {
+				t.Errorf("planRotation() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+)
+
+// sensorMoistureMaxAge 作为灌溉计算默认水分点的传感器读数最长时效
+const sensorMoistureMaxAge = 24 * time.Hour
+
+// SensorController 处理土壤水分传感器数据相关的请求
+type SensorController struct {
+	DB *sql.DB
+}
+
+// NewSensorController 创建一个新的SensorController实例
+func NewSensorController(db *sql.DB) *SensorController {
+	return &SensorController{DB: db}
+}
+
+// sensorBucket 聚合桶的唯一标识
+type sensorBucket struct {
+	AreaID      int
+	Depth       float64
+	Granularity string
+	Start       time.Time
+}
+
+// sensorAggregate 聚合桶内的统计值
+type sensorAggregate struct {
+	Sum, Min, Max float64
+	Count         int
+}
+
+// IngestReadings 设备上报土壤水分读数，同一设备、区域、深度、时间的重复读数会被忽略，
+// 新增读数同时累加到小时与日聚合中
+func (c *SensorController) IngestReadings(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	machineCodeID := ctx.GetInt("machineCodeID")
+
+	var req models.SensorIngestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	recordedAt := make([]time.Time, len(req.Readings))
+	for i, reading := range req.Readings {
+		if *reading.Moisture < 0 || *reading.Moisture > 100 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("moisture of reading %d must be between 0 and 100", i)})
+			return
+		}
+		if reading.Depth < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("depth of reading %d must not be negative", i)})
+			return
+		}
+		recordedAt[i] = now
+		if reading.RecordedAt != "" {
+			t, err := time.ParseInLocation(dateTimeLayout, reading.RecordedAt, time.Local)
+			if err != nil {
+				t, err = time.Parse(time.RFC3339, reading.RecordedAt)
+			}
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid recordedAt of reading %d", i)})
+				return
+			}
+			recordedAt[i] = t.Local()
+		}
+	}
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	accepted := 0
+	aggregates := map[sensorBucket]*sensorAggregate{}
+	latestAt := map[int]time.Time{}
+	latestSum := map[int]*sensorAggregate{}
+	for i, reading := range req.Readings {
+		result, err := tx.Exec(`
+			INSERT IGNORE INTO sensor_readings (machine_code_id, user_id, area_id, depth, moisture, temperature, recorded_at)
+			VALUES (?,?,?,?,?,?,?)
+		`, machineCodeID, userID, reading.AreaID, reading.Depth, *reading.Moisture, reading.Temperature, recordedAt[i].Format(dateTimeLayout))
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			continue
+		}
+		accepted++
+
+		t := recordedAt[i]
+		if last, ok := latestAt[reading.AreaID]; !ok || t.After(last) {
+			latestAt[reading.AreaID] = t
+			latestSum[reading.AreaID] = &sensorAggregate{}
+		}
+		if t.Equal(latestAt[reading.AreaID]) {
+			latestSum[reading.AreaID].Sum += *reading.Moisture
+			latestSum[reading.AreaID].Count++
+		}
+		hour := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		for _, bucket := range []sensorBucket{
+			{AreaID: reading.AreaID, Depth: reading.Depth, Granularity: models.SensorGranularityHour, Start: hour},
+			{AreaID: reading.AreaID, Depth: reading.Depth, Granularity: models.SensorGranularityDay, Start: day},
+		} {
+			aggregate, ok := aggregates[bucket]
+			if !ok {
+				aggregate = &sensorAggregate{Min: *reading.Moisture, Max: *reading.Moisture}
+				aggregates[bucket] = aggregate
+			}
+			aggregate.Sum += *reading.Moisture
+			aggregate.Count++
+			if *reading.Moisture < aggregate.Min {
+				aggregate.Min = *reading.Moisture
+			}
+			if *reading.Moisture > aggregate.Max {
+				aggregate.Max = *reading.Moisture
+			}
+		}
+	}
+
+	// 平均值须在样本数之前更新，MySQL 按书写顺序执行赋值
+	upsertSQL := `
+		INSERT INTO sensor_reading_aggregates (machine_code_id, user_id, area_id, depth, granularity, bucket_start,
+			avg_moisture, min_moisture, max_moisture, sample_count)
+		VALUES (?,?,?,?,?,?,?,?,?,?)
+		ON DUPLICATE KEY UPDATE
+			avg_moisture = (avg_moisture * sample_count + VALUES(avg_moisture) * VALUES(sample_count)) / (sample_count + VALUES(sample_count)),
+			min_moisture = LEAST(min_moisture, VALUES(min_moisture)),
+			max_moisture = GREATEST(max_moisture, VALUES(max_moisture)),
+			sample_count = sample_count + VALUES(sample_count)
+	`
+	for bucket, aggregate := range aggregates {
+		_, err := tx.Exec(upsertSQL, machineCodeID, userID, bucket.AreaID, bucket.Depth, bucket.Granularity,
+			bucket.Start.Format(dateTimeLayout), aggregate.Sum/float64(aggregate.Count), aggregate.Min, aggregate.Max, aggregate.Count)
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 以各区域本次上报的最新读数均值评估含水量告警，评估失败只记录日志，不影响上报结果
+	if len(latestSum) > 0 {
+		latest := make(map[int]float64, len(latestSum))
+		for areaID, aggregate := range latestSum {
+			latest[areaID] = aggregate.Sum / float64(aggregate.Count)
+		}
+		evaluateMoistureAlerts(c.DB, userID, latest)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":       200,
+		"msg":        "ok",
+		"accepted":   accepted,
+		"duplicates": len(req.Readings) - accepted,
+	})
+}
+
+// GetSensorSeries 获取传感器时间序列，按设备、区域、深度分组
+//
+// granularity 可选 raw、hour（默认）、day，startTime、endTime 默认为最近 7 天
+func (c *SensorController) GetSensorSeries(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+
+	granularity := ctx.DefaultQuery("granularity", models.SensorGranularityHour)
+	if granularity != models.SensorGranularityRaw && granularity != models.SensorGranularityHour && granularity != models.SensorGranularityDay {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "granularity must be raw, hour or day"})
+		return
+	}
+	endTime := ctx.DefaultQuery("endTime", time.Now().Format(dateTimeLayout))
+	startTime := ctx.DefaultQuery("startTime", time.Now().AddDate(0, 0, -7).Format(dateTimeLayout))
+
+	var query string
+	if granularity == models.SensorGranularityRaw {
+		query = `
+			SELECT machine_code_id, area_id, depth, recorded_at, moisture, moisture, moisture, 1
+			FROM sensor_readings
+			WHERE user_id = ? AND recorded_at BETWEEN ? AND ?`
+	} else {
+		query = `
+			SELECT machine_code_id, area_id, depth, bucket_start, avg_moisture, min_moisture, max_moisture, sample_count
+			FROM sensor_reading_aggregates
+			WHERE user_id = ? AND bucket_start BETWEEN ? AND ? AND granularity = '` + granularity + `'`
+	}
+	params := []interface{}{userID, startTime, endTime}
+
+	if areaID := ctx.Query("areaId"); areaID != "" {
+		query += " AND area_id = ?"
+		params = append(params, areaID)
+	}
+	if depth := ctx.Query("depth"); depth != "" {
+		query += " AND depth = ?"
+		params = append(params, depth)
+	}
+	if machineCodeID := ctx.Query("machineCodeId"); machineCodeID != "" {
+		query += " AND machine_code_id = ?"
+		params = append(params, machineCodeID)
+	}
+	query += " ORDER BY machine_code_id, area_id, depth, 4"
+
+	rows, err := c.DB.Query(query, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for sensor readings"})
+		return
+	}
+	defer rows.Close()
+
+	seriesList := []models.SensorSeries{}
+	for rows.Next() {
+		var machineCodeID, areaID int
+		var depth float64
+		var at time.Time
+		var point models.SensorSeriesPoint
+		err := rows.Scan(&machineCodeID, &areaID, &depth, &at, &point.Moisture, &point.MinMoisture, &point.MaxMoisture, &point.SampleCount)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning sensor reading row"})
+			return
+		}
+		point.Time = at.Format(dateTimeLayout)
+
+		last := len(seriesList) - 1
+		if last < 0 || seriesList[last].MachineCodeID != machineCodeID || seriesList[last].AreaID != areaID || seriesList[last].Depth != depth {
+			seriesList = append(seriesList, models.SensorSeries{
+				MachineCodeID: machineCodeID,
+				AreaID:        areaID,
+				Depth:         depth,
+				Granularity:   granularity,
+			})
+			last++
+		}
+		seriesList[last].Points = append(seriesList[last].Points, point)
+	}
+
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating sensor reading rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": seriesList,
+	})
+}
+
+// GetLatestMoisture 获取设备在指定区域最近 24 小时内每个深度的最新读数，格式与灌溉计算的水分点一致
+func (c *SensorController) GetLatestMoisture(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	machineCodeID, err := strconv.Atoi(ctx.Query("machineCodeId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid machineCodeId"})
+		return
+	}
+	areaID, err := strconv.Atoi(ctx.Query("areaId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid areaId"})
+		return
+	}
+
+	points, err := latestMoisturePoints(c.DB, userID, machineCodeID, areaID, time.Now().Add(-sensorMoistureMaxAge))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for sensor readings"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": gin.H{
+			"machineCodeId":  machineCodeID,
+			"areaId":         areaID,
+			"moisturePoints": points,
+		},
+	})
+}
+
+// latestMoisturePoints 查询设备在区域内每个深度 since 之后的最新读数
+//
+// 区域编号是单条灌溉记录内的序号，不同田块、设备会重复使用，因此读数必须按设备限定
+func latestMoisturePoints(db *sql.DB, userID, machineCodeID, areaID int, since time.Time) ([]models.MoisturePoint, error) {
+	rows, err := db.Query(`
+		SELECT r.moisture
+		FROM sensor_readings r
+		JOIN (
+			SELECT depth, MAX(recorded_at) AS recorded_at
+			FROM sensor_readings
+			WHERE machine_code_id = ? AND area_id = ? AND user_id = ? AND recorded_at >= ?
+			GROUP BY depth
+		) latest ON r.depth = latest.depth AND r.recorded_at = latest.recorded_at
+		WHERE r.machine_code_id = ? AND r.area_id = ? AND r.user_id = ?
+		ORDER BY r.depth
+	`, machineCodeID, areaID, userID, since.Format(dateTimeLayout), machineCodeID, areaID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []models.MoisturePoint{}
+	for rows.Next() {
+		var point models.MoisturePoint
+		if err := rows.Scan(&point.Value); err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// applySensorMoisture 为未填写水分点的区域补全请求指定设备的最新读数，未指定设备时不补全
+func applySensorMoisture(db *sql.DB, userID int, req *models.IrrigationRequest) error {
+	if req.MachineCodeID == 0 {
+		return nil
+	}
+	since := time.Now().Add(-sensorMoistureMaxAge)
+	for i, area := range req.Areas {
+		if len(area.MoisturePoints) > 0 {
+			continue
+		}
+		points, err := latestMoisturePoints(db, userID, req.MachineCodeID, area.AreaId, since)
+		if err != nil {
+			return err
+		}
+		req.Areas[i].MoisturePoints = points
+	}
+	return nil
+}
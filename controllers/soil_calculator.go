@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"go-mengtuobang/models"
+)
+
+const (
+	// soilNutrientFactor 土壤测试值（mg/kg）换算为每亩耕层养分量（kg）的系数，耕层 20 cm 土重约 15 万 kg/亩
+	soilNutrientFactor = 0.15
+	// p2o5PerP、k2oPerK 元素态磷、钾换算为氧化物的系数
+	p2o5PerP = 2.29
+	k2oPerK  = 1.2046
+	// defaultYieldIncrease 目标产量较前三年平均亩产的默认增幅（%）
+	defaultYieldIncrease = 10.0
+)
+
+var (
+	// defaultFertilizerEfficiency 化肥当季利用率（%）
+	defaultFertilizerEfficiency = models.NutrientAmount{N: 35, P2O5: 20, K2O: 45}
+	// defaultSoilCorrection 土壤有效养分校正系数
+	defaultSoilCorrection = models.NutrientAmount{N: 0.6, P2O5: 0.5, K2O: 0.5}
+)
+
+// calculateTargetYieldFertilization 按目标产量法（养分平衡法）计算施肥量，结果未扣除有机肥养分
+//
+// 目标亩产 = 平均亩产 ×（1 + 增幅），需养分量 = 目标亩产 / 100 × 百千克产量养分吸收量 × 面积，
+// 土壤供养分量 = 测试值 × 0.15 × 校正系数 × 面积（磷、钾分别换算为 P2O5、K2O），
+// 补充量 =（需养分量 − 土壤供养分量）/ 肥料利用率，最低为 0。
+// 吸收量、利用率与校正系数默认取作物养分系数，请求中提供时以请求为准
+func calculateTargetYieldFertilization(req models.SoilCalculateRequest, coefficient *models.CropNutrientCoefficient) (*models.SoilCalculation, error) {
+	if req.AverageYield <= 0 {
+		return nil, errors.New("averageYield must be greater than 0")
+	}
+	if req.PlotSize <= 0 {
+		return nil, errors.New("plotSize must be greater than 0")
+	}
+	if req.SoilTest.AlkaliN < 0 || req.SoilTest.OlsenP < 0 || req.SoilTest.AvailableK < 0 {
+		return nil, errors.New("soil test values must not be negative")
+	}
+
+	result := &models.SoilCalculation{
+		Efficiency: defaultFertilizerEfficiency,
+		Correction: defaultSoilCorrection,
+	}
+	if coefficient != nil {
+		result.CoefficientID = coefficient.ID
+		result.CoefficientVersion = coefficient.Version
+		result.Uptake = coefficient.Uptake
+		result.Efficiency = coefficient.Efficiency
+		result.Correction = coefficient.Correction
+	}
+	if req.Uptake != nil {
+		result.Uptake = *req.Uptake
+		result.CoefficientID, result.CoefficientVersion = 0, 0
+	}
+	if result.Uptake.N+result.Uptake.P2O5+result.Uptake.K2O <= 0 {
+		return nil, fmt.Errorf("nutrient uptake of crop %s is unknown, provide uptake per 100 kg yield", req.Crop)
+	}
+	if req.Efficiency != nil {
+		result.Efficiency = *req.Efficiency
+	}
+	if req.Correction != nil {
+		result.Correction = *req.Correction
+	}
+	if result.Efficiency.N <= 0 || result.Efficiency.N > 100 ||
+		result.Efficiency.P2O5 <= 0 || result.Efficiency.P2O5 > 100 ||
+		result.Efficiency.K2O <= 0 || result.Efficiency.K2O > 100 {
+		return nil, errors.New("efficiency must be between 0 and 100")
+	}
+	if result.Correction.N < 0 || result.Correction.P2O5 < 0 || result.Correction.K2O < 0 {
+		return nil, errors.New("correction must not be negative")
+	}
+
+	increase := defaultYieldIncrease
+	if req.YieldIncrease != nil {
+		increase = *req.YieldIncrease
+	}
+	if increase < 0 {
+		return nil, errors.New("yieldIncrease must not be negative")
+	}
+
+	targetYield := req.AverageYield * (1 + increase/100)
+	result.TargetYield = round2(targetYield)
+
+	demand := models.NutrientAmount{
+		N:    targetYield / 100 * result.Uptake.N * req.PlotSize,
+		P2O5: targetYield / 100 * result.Uptake.P2O5 * req.PlotSize,
+		K2O:  targetYield / 100 * result.Uptake.K2O * req.PlotSize,
+	}
+	supply := models.NutrientAmount{
+		N:    req.SoilTest.AlkaliN * soilNutrientFactor * result.Correction.N * req.PlotSize,
+		P2O5: req.SoilTest.OlsenP * p2o5PerP * soilNutrientFactor * result.Correction.P2O5 * req.PlotSize,
+		K2O:  req.SoilTest.AvailableK * k2oPerK * soilNutrientFactor * result.Correction.K2O * req.PlotSize,
+	}
+
+	result.FertilizerDemand = models.NutrientAmount{N: round2(demand.N), P2O5: round2(demand.P2O5), K2O: round2(demand.K2O)}
+	result.TotalSupply = models.NutrientAmount{N: round2(supply.N), P2O5: round2(supply.P2O5), K2O: round2(supply.K2O)}
+	result.Supplement = models.NutrientAmount{
+		N:    round2(math.Max(0, demand.N-supply.N) / (result.Efficiency.N / 100)),
+		P2O5: round2(math.Max(0, demand.P2O5-supply.P2O5) / (result.Efficiency.P2O5 / 100)),
+		K2O:  round2(math.Max(0, demand.K2O-supply.K2O) / (result.Efficiency.K2O / 100)),
+	}
+	return result, nil
+}
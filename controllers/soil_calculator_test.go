@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"testing"
+
+	"go-mengtuobang/models"
+)
+
+func TestCalculateTargetYieldFertilization(t *testing.T) {
+	float := func(v float64) *float64 { return &v }
+	wheat := &models.CropNutrientCoefficient{
+		ID: 3, CropCode: "wheat", CropName: "小麦", Version: 2,
+		Uptake:     models.NutrientAmount{N: 3, P2O5: 1.25, K2O: 2.5},
+		Efficiency: models.NutrientAmount{N: 35, P2O5: 20, K2O: 45},
+		Correction: models.NutrientAmount{N: 0.6, P2O5: 0.5, K2O: 0.5},
+	}
+	soilTest := models.SoilTest{AlkaliN: 80, OlsenP: 20, AvailableK: 100}
+	// 目标亩产 500 ×（1 + 10%）= 550 kg，2 亩需 N 33、P2O5 13.75、K2O 27.5 kg；
+	// 土壤供 N 80 × 0.15 × 0.6 × 2 = 14.4、P2O5 20 × 2.29 × 0.15 × 0.5 × 2 = 6.87、K2O 100 × 1.2046 × 0.15 × 0.5 × 2 = 18.07 kg
+	wheatResult := models.SoilCalculation{
+		TargetYield: 550, CoefficientID: 3, CoefficientVersion: 2,
+		Uptake: wheat.Uptake, Efficiency: wheat.Efficiency, Correction: wheat.Correction,
+		FertilizerDemand: models.NutrientAmount{N: 33, P2O5: 13.75, K2O: 27.5},
+		TotalSupply:      models.NutrientAmount{N: 14.4, P2O5: 6.87, K2O: 18.07},
+		Supplement:       models.NutrientAmount{N: 53.14, P2O5: 34.4, K2O: 20.96},
+	}
+	customUptake := wheatResult
+	customUptake.CoefficientID, customUptake.CoefficientVersion = 0, 0
+
+	tests := []struct {
+		name        string
+		req         models.SoilCalculateRequest
+		coefficient *models.CropNutrientCoefficient
+		want        models.SoilCalculation
+	}{
+		{
+			"crop coefficient",
+			models.SoilCalculateRequest{Crop: "小麦", AverageYield: 500, PlotSize: 2, SoilTest: soilTest},
+			wheat, wheatResult,
+		},
+		{
+			"request uptake replaces the coefficient version",
+			models.SoilCalculateRequest{Crop: "小麦", AverageYield: 500, PlotSize: 2, SoilTest: soilTest, Uptake: &wheat.Uptake},
+			wheat, customUptake,
+		},
+		{
+			// 无系数时取默认利用率与校正系数；土壤供氮超过需氮量时补充量为 0
+			"default efficiency and rich soil",
+			models.SoilCalculateRequest{
+				Crop: "玉米", AverageYield: 400, PlotSize: 1, YieldIncrease: float(0),
+				SoilTest: models.SoilTest{AlkaliN: 200, OlsenP: 5},
+				Uptake:   &models.NutrientAmount{N: 2, P2O5: 1, K2O: 2},
+			},
+			nil,
+			models.SoilCalculation{
+				TargetYield: 400,
+				Uptake:      models.NutrientAmount{N: 2, P2O5: 1, K2O: 2},
+				Efficiency:  defaultFertilizerEfficiency, Correction: defaultSoilCorrection,
+				FertilizerDemand: models.NutrientAmount{N: 8, P2O5: 4, K2O: 8},
+				TotalSupply:      models.NutrientAmount{N: 18, P2O5: 0.86, K2O: 0},
+				Supplement:       models.NutrientAmount{N: 0, P2O5: 15.71, K2O: 17.78},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calculateTargetYieldFertilization(tt.req, tt.coefficient)
+			if err != nil {
+				t.Fatalf("calculateTargetYieldFertilization() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("calculateTargetYieldFertilization() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateTargetYieldFertilizationInvalid(t *testing.T) {
+	float := func(v float64) *float64 { return &v }
+	uptake := &models.NutrientAmount{N: 3, P2O5: 1.25, K2O: 2.5}
+	valid := func(modify func(*models.SoilCalculateRequest)) models.SoilCalculateRequest {
+		req := models.SoilCalculateRequest{Crop: "小麦", AverageYield: 500, PlotSize: 2, Uptake: uptake}
+		modify(&req)
+		return req
+	}
+	tests := []struct {
+		name string
+		req  models.SoilCalculateRequest
+	}{
+		{"no average yield", valid(func(r *models.SoilCalculateRequest) { r.AverageYield = 0 })},
+		{"no plot size", valid(func(r *models.SoilCalculateRequest) { r.PlotSize = 0 })},
+		{"negative soil test", valid(func(r *models.SoilCalculateRequest) { r.SoilTest.OlsenP = -1 })},
+		{"unknown uptake", valid(func(r *models.SoilCalculateRequest) { r.Uptake = nil })},
+		{"zero efficiency", valid(func(r *models.SoilCalculateRequest) {
+			r.Efficiency = &models.NutrientAmount{N: 0, P2O5: 20, K2O: 45}
+		})},
+		{"efficiency above 100", valid(func(r *models.SoilCalculateRequest) {
+			r.Efficiency = &models.NutrientAmount{N: 35, P2O5: 120, K2O: 45}
+		})},
+		{"negative correction", valid(func(r *models.SoilCalculateRequest) {
+			r.Correction = &models.NutrientAmount{N: 0.6, P2O5: 0.5, K2O: -0.5}
+		})},
+		{"negative yield increase", valid(func(r *models.SoilCalculateRequest) { r.YieldIncrease = float(-5) })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := calculateTargetYieldFertilization(tt.req, nil); err == nil {
+				t.Errorf("calculateTargetYieldFertilization() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestDeductOrganicCredit(t *testing.T) {
+	efficiency := models.NutrientAmount{N: 35, P2O5: 20, K2O: 45}
+	supplement := models.NutrientAmount{N: 53.14, P2O5: 34.4, K2O: 20.96}
+	tests := []struct {
+		name   string
+		credit models.NutrientAmount
+		want   models.NutrientAmount
+	}{
+		{"no credit", models.NutrientAmount{}, supplement},
+		// 有机肥供 N 3.5、P2O5 2 kg，按利用率折算后分别抵扣 10 kg 化肥养分
+		{"credit divided by efficiency", models.NutrientAmount{N: 3.5, P2O5: 2}, models.NutrientAmount{N: 43.14, P2O5: 24.4, K2O: 20.96}},
+		{"credit exceeds supplement", models.NutrientAmount{N: 30, P2O5: 10, K2O: 20}, models.NutrientAmount{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deductOrganicCredit(supplement, tt.credit, efficiency); got != tt.want {
+				t.Errorf("deductOrganicCredit() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
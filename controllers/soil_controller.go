@@ -3,8 +3,10 @@ package controllers
 import (
 	"database/sql"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -22,6 +24,66 @@ func NewSoilController(db *sql.DB) *SoilController {
 	return &SoilController{DB: db}
 }
 
+const soilRecordColumns = `id, user_id, add_number, timestamp, location, crop, plot_size, average_yield,
+	fertilizer_demand_n, fertilizer_demand_p2o5, fertilizer_demand_k2o,
+	total_supply_n, total_supply_p2o5, total_supply_k2o,
+	supplement_n, supplement_p2o5, supplement_k2o,
+	nitrogen_replenish_name, nitrogen_replenish_weight,
+	phosphorus_replenish_name, phosphorus_replenish_weight,
+	potassium_replenish_name, potassium_replenish_weight,
+	organic_fertilizer_name, organic_fertilizer_amount,
+	nitrogen_Basic_name, nitrogen_Basic_weight,
+	phosphorus_Basic_name, phosphorus_Basic_weight,
+	potassium_Basic_name, potassium_Basic_weight,
+	custom_ratios, organic_compost_id, organic_credit_n, organic_credit_p2o5, organic_credit_k2o,
+	soil_alkali_n, soil_olsen_p, soil_available_k, target_yield, yield_increase, coefficient_id, sample_id,
+	soil_region, soil_ph, soil_organic_matter, soil_ec, soil_cec, soil_ca, soil_mg, soil_s, soil_zn, soil_b, soil_fe, soil_mn, plot_id`
+
+// scanSoilRecord 扫描一行测土配肥记录
+func scanSoilRecord(scanner interface{ Scan(...interface{}) error }) (models.Soil, error) {
+	var record models.Soil
+	var compostID sql.NullInt64
+	var alkaliN, olsenP, availableK, yieldIncrease sql.NullFloat64
+	var coefficientID, sampleID, plotID sql.NullInt64
+	var props models.SoilProperties
+	var ph, organicMatter, ec, cec, ca, mg, sulfur, zn, b, fe, mn sql.NullFloat64
+	err := scanner.Scan(
+		&record.Id, &record.UserId, &record.AddNumber, &record.Timestamp, &record.Location, &record.Crop,
+		&record.PlotSize, &record.AverageYield,
+		&record.FertilizerDemand.N, &record.FertilizerDemand.P2O5, &record.FertilizerDemand.K2O,
+		&record.TotalSupply.N, &record.TotalSupply.P2O5, &record.TotalSupply.K2O,
+		&record.Supplement.N, &record.Supplement.P2O5, &record.Supplement.K2O,
+		&record.NitrogenReplenish.Name, &record.NitrogenReplenish.Weight,
+		&record.PhosphorusReplenish.Name, &record.PhosphorusReplenish.Weight,
+		&record.PotassiumReplenish.Name, &record.PotassiumReplenish.Weight,
+		&record.OrganicFertilizer.Name, &record.OrganicFertilizer.Amount,
+		&record.NitrogenBasic.Name, &record.NitrogenBasic.Weight,
+		&record.PhosphorusBasic.Name, &record.PhosphorusBasic.Weight,
+		&record.PotassiumBasic.Name, &record.PotassiumBasic.Weight,
+		&record.CustomRatios, &compostID,
+		&record.OrganicCredit.N, &record.OrganicCredit.P2O5, &record.OrganicCredit.K2O,
+		&alkaliN, &olsenP, &availableK, &record.TargetYield, &yieldIncrease, &coefficientID, &sampleID,
+		&record.Region, &ph, &organicMatter, &ec, &cec, &ca, &mg, &sulfur, &zn, &b, &fe, &mn, &plotID,
+	)
+	record.OrganicFertilizer.CompostID = int(compostID.Int64)
+	if alkaliN.Valid || olsenP.Valid || availableK.Valid {
+		record.SoilTest = &models.SoilTest{AlkaliN: alkaliN.Float64, OlsenP: olsenP.Float64, AvailableK: availableK.Float64}
+	}
+	if yieldIncrease.Valid {
+		record.YieldIncrease = &yieldIncrease.Float64
+	}
+	record.CoefficientID = int(coefficientID.Int64)
+	record.SampleID = int(sampleID.Int64)
+	record.PlotID = int(plotID.Int64)
+	props.PH, props.OrganicMatter, props.EC, props.CEC = nullFloatPtr(ph), nullFloatPtr(organicMatter), nullFloatPtr(ec), nullFloatPtr(cec)
+	props.Ca, props.Mg, props.S = nullFloatPtr(ca), nullFloatPtr(mg), nullFloatPtr(sulfur)
+	props.Zn, props.B, props.Fe, props.Mn = nullFloatPtr(zn), nullFloatPtr(b), nullFloatPtr(fe), nullFloatPtr(mn)
+	if hasSoilProperties(props) {
+		record.Properties = &props
+	}
+	return record, err
+}
+
 // SaveSoilSoil 保存测土配肥记录
 func (c *SoilController) SaveSoilRecord(ctx *gin.Context) {
 	userID := ctx.GetInt("userID")
@@ -31,6 +93,123 @@ func (c *SoilController) SaveSoilRecord(ctx *gin.Context) {
 		return
 	}
 
+	// 关联地块时，未填写的地点、作物与面积取地块信息
+	if record.PlotID > 0 {
+		plot, err := loadPlot(c.DB, userID, record.PlotID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "地块不存在"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		if record.Location == "" {
+			record.Location = plotLocation(plot)
+		}
+		if record.Crop == "" {
+			record.Crop = plot.CurrentCrop
+		}
+		if record.PlotSize == 0 {
+			record.PlotSize = plot.Area
+		}
+	}
+
+	// 引用土壤样品且未提供测试值时，取样品的化验值
+	if record.SampleID > 0 {
+		sample, err := loadSoilSample(c.DB, userID, record.SampleID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "土壤样品不存在"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		if record.SoilTest == nil {
+			if record.SoilTest, err = soilTestFromSample(sample); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if record.Location == "" {
+			record.Location = sample.Location
+		}
+		if props := soilPropertiesFromSample(sample); record.Properties == nil && hasSoilProperties(props) {
+			record.Properties = &props
+		} else if record.Properties != nil {
+			mergeSoilProperties(record.Properties, props)
+		}
+	}
+
+	// 土壤理化性质按区域分级指标分级，并给出石灰、石膏与中微量元素建议
+	record.Region = strings.TrimSpace(record.Region)
+	record.Interpretation, record.Amendments = nil, nil
+	if record.Properties != nil {
+		if err := validateSoilProperties(*record.Properties); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		records := []models.Soil{record}
+		if err := interpretSoilRecords(c.DB, records); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		record = records[0]
+	}
+
+	// 提供土壤测试值时，由服务端按目标产量法计算需肥量、供肥量与补充量，并记录使用的作物养分系数版本
+	record.CoefficientID = 0
+	efficiency := defaultFertilizerEfficiency
+	if record.SoilTest != nil {
+		req := models.SoilCalculateRequest{
+			Crop:          record.Crop,
+			AverageYield:  record.AverageYield,
+			PlotSize:      record.PlotSize,
+			YieldIncrease: record.YieldIncrease,
+			SoilTest:      *record.SoilTest,
+		}
+		coefficient, err := findCropCoefficient(c.DB, record.Crop, 0)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		result, err := calculateTargetYieldFertilization(req, coefficient)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		record.CoefficientID = result.CoefficientID
+		record.TargetYield = result.TargetYield
+		record.FertilizerDemand = result.FertilizerDemand
+		record.TotalSupply = result.TotalSupply
+		record.Supplement = result.Supplement
+		efficiency = result.Efficiency
+	}
+
+	// 引用堆肥记录作为有机肥时，扣除其当季养分供应；补充量由客户端提供时按作物养分系数的肥料利用率折算
+	record.OrganicCredit.N, record.OrganicCredit.P2O5, record.OrganicCredit.K2O = 0, 0, 0
+	if record.OrganicFertilizer.CompostID > 0 {
+		if record.SoilTest == nil {
+			coefficient, err := findCropCoefficient(c.DB, record.Crop, 0)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if coefficient != nil {
+				efficiency = coefficient.Efficiency
+			}
+		}
+		if err := applyOrganicCredit(c.DB, userID, &record, efficiency); err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "堆肥记录不存在"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+	}
+
 	// 开始事务
 	tx, err := c.DB.Begin()
 	if err != nil {
@@ -38,6 +217,12 @@ func (c *SoilController) SaveSoilRecord(ctx *gin.Context) {
 		return
 	}
 
+	if status, err := lockPlot(tx, userID, record.PlotID); err != nil {
+		tx.Rollback()
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 准备SQL语句
 	stmt, err := tx.Prepare(`
 		INSERT INTO records (
@@ -52,8 +237,11 @@ func (c *SoilController) SaveSoilRecord(ctx *gin.Context) {
 			nitrogen_Basic_name, nitrogen_Basic_weight,
 			phosphorus_Basic_name, phosphorus_Basic_weight,
 			potassium_Basic_name, potassium_Basic_weight,
-			custom_ratios, user_id
-		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+			custom_ratios, user_id,
+			organic_compost_id, organic_credit_n, organic_credit_p2o5, organic_credit_k2o,
+			soil_alkali_n, soil_olsen_p, soil_available_k, target_yield, yield_increase, coefficient_id, sample_id,
+			soil_region, soil_ph, soil_organic_matter, soil_ec, soil_cec, soil_ca, soil_mg, soil_s, soil_zn, soil_b, soil_fe, soil_mn, plot_id
+		) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
 	`)
 
 	if err != nil {
@@ -63,6 +251,16 @@ func (c *SoilController) SaveSoilRecord(ctx *gin.Context) {
 	}
 	defer stmt.Close()
 
+	var alkaliN, olsenP, availableK interface{}
+	if record.SoilTest != nil {
+		alkaliN, olsenP, availableK = record.SoilTest.AlkaliN, record.SoilTest.OlsenP, record.SoilTest.AvailableK
+	}
+
+	var props models.SoilProperties
+	if record.Properties != nil {
+		props = *record.Properties
+	}
+
 	// 获取当前时间
 	now := time.Now()
 	timestamp := now.Format("2006-01-02 15:04:05")
@@ -81,6 +279,10 @@ func (c *SoilController) SaveSoilRecord(ctx *gin.Context) {
 		record.PhosphorusBasic.Name, record.PhosphorusBasic.Weight,
 		record.PotassiumBasic.Name, record.PotassiumBasic.Weight,
 		record.CustomRatios, userID,
+		nullableID(record.OrganicFertilizer.CompostID), record.OrganicCredit.N, record.OrganicCredit.P2O5, record.OrganicCredit.K2O,
+		alkaliN, olsenP, availableK, record.TargetYield, record.YieldIncrease, nullableID(record.CoefficientID), nullableID(record.SampleID),
+		record.Region, props.PH, props.OrganicMatter, props.EC, props.CEC, props.Ca, props.Mg, props.S, props.Zn, props.B, props.Fe, props.Mn,
+		nullableID(record.PlotID),
 	)
 
 	if err != nil {
@@ -121,10 +323,11 @@ func (c *SoilController) GetSoilRecords(ctx *gin.Context) {
 	endDate := ctx.Query("endDate")
 	location := ctx.Query("location")
 	crop := ctx.Query("crop")
+	plotID := ctx.Query("plotId")
 
 	// 构建基础查询
 	query := `
-		SELECT * FROM records 
+		SELECT ` + soilRecordColumns + ` FROM records
 		WHERE user_id = ?
 	`
 
@@ -146,6 +349,11 @@ func (c *SoilController) GetSoilRecords(ctx *gin.Context) {
 		queryParams = append(queryParams, "%"+crop+"%")
 	}
 
+	if plotID != "" {
+		query += " AND plot_id = ?"
+		queryParams = append(queryParams, plotID)
+	}
+
 	// 添加排序和分页
 	query += " ORDER BY timestamp DESC LIMIT ? OFFSET ?"
 	queryParams = append(queryParams, pageSize, (page-1)*pageSize)
@@ -160,28 +368,17 @@ func (c *SoilController) GetSoilRecords(ctx *gin.Context) {
 
 	var records []models.Soil
 	for rows.Next() {
-		var record models.Soil
-		err := rows.Scan(
-			&record.Id, &record.UserId, &record.AddNumber, &record.Timestamp, &record.Location, &record.Crop,
-			&record.PlotSize, &record.AverageYield,
-			&record.FertilizerDemand.N, &record.FertilizerDemand.P2O5, &record.FertilizerDemand.K2O,
-			&record.TotalSupply.N, &record.TotalSupply.P2O5, &record.TotalSupply.K2O,
-			&record.Supplement.N, &record.Supplement.P2O5, &record.Supplement.K2O,
-			&record.NitrogenReplenish.Name, &record.NitrogenReplenish.Weight,
-			&record.PhosphorusReplenish.Name, &record.PhosphorusReplenish.Weight,
-			&record.PotassiumReplenish.Name, &record.PotassiumReplenish.Weight,
-			&record.OrganicFertilizer.Name, &record.OrganicFertilizer.Amount,
-			&record.NitrogenBasic.Name, &record.NitrogenBasic.Weight,
-			&record.PhosphorusBasic.Name, &record.PhosphorusBasic.Weight,
-			&record.PotassiumBasic.Name, &record.PotassiumBasic.Weight,
-			&record.CustomRatios,
-		)
+		record, err := scanSoilRecord(rows)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 		records = append(records, record)
 	}
+	if err := interpretSoilRecords(c.DB, records); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 获取总记录数
 	var totalCount int
@@ -203,6 +400,11 @@ func (c *SoilController) GetSoilRecords(ctx *gin.Context) {
 		countParams = append(countParams, "%"+crop+"%")
 	}
 
+	if plotID != "" {
+		countQuery += " AND plot_id = ?"
+		countParams = append(countParams, plotID)
+	}
+
 	err = c.DB.QueryRow(countQuery, countParams...).Scan(&totalCount)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "获取总记录数失败"})
@@ -220,6 +422,86 @@ func (c *SoilController) GetSoilRecords(ctx *gin.Context) {
 	})
 }
 
+// ExportSoilRecords 导出测土配肥记录，筛选条件与 GetSoilRecords 相同，不分页
+func (c *SoilController) ExportSoilRecords(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	startDate := ctx.Query("startDate")
+	endDate := ctx.Query("endDate")
+	location := ctx.Query("location")
+	crop := ctx.Query("crop")
+	plotID := ctx.Query("plotId")
+
+	query := "SELECT " + soilRecordColumns + " FROM records WHERE user_id = ?"
+	params := []interface{}{userID}
+	if startDate != "" && endDate != "" {
+		query += " AND timestamp BETWEEN ? AND ?"
+		params = append(params, startDate, endDate)
+	}
+	if location != "" {
+		query += " AND location LIKE ?"
+		params = append(params, "%"+location+"%")
+	}
+	if crop != "" {
+		query += " AND crop LIKE ?"
+		params = append(params, "%"+crop+"%")
+	}
+	if plotID != "" {
+		query += " AND plot_id = ?"
+		params = append(params, plotID)
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
+
+	writeRecordExport(ctx, c.DB, "soil-records", []exportSheet{{
+		Key:   "records",
+		Title: "测土配肥记录",
+		Header: []string{
+			"记录ID", "时间", "地块ID", "地点", "作物", "面积（亩）", "平均产量", "目标产量",
+			"需肥量N", "需肥量P2O5", "需肥量K2O", "土壤供肥量N", "土壤供肥量P2O5", "土壤供肥量K2O",
+			"有机肥养分N", "有机肥养分P2O5", "有机肥养分K2O", "补充量N", "补充量P2O5", "补充量K2O",
+			"基肥氮肥", "基肥氮肥用量", "基肥磷肥", "基肥磷肥用量", "基肥钾肥", "基肥钾肥用量",
+			"补充氮肥", "补充氮肥用量", "补充磷肥", "补充磷肥用量", "补充钾肥", "补充钾肥用量",
+			"有机肥", "有机肥用量", "堆肥记录ID",
+			"碱解氮（mg/kg）", "有效磷（mg/kg）", "速效钾（mg/kg）", "分级区域", "pH", "有机质（g/kg）", "EC（dS/m）", "CEC（cmol(+)/kg）",
+			"交换性钙（mg/kg）", "交换性镁（mg/kg）", "有效硫（mg/kg）", "有效锌（mg/kg）", "有效硼（mg/kg）", "有效铁（mg/kg）", "有效锰（mg/kg）",
+		},
+		Query:  query,
+		Params: params,
+		Row: func(rows *sql.Rows) ([]interface{}, error) {
+			record, err := scanSoilRecord(rows)
+			if err != nil {
+				return nil, err
+			}
+			var alkaliN, olsenP, availableK interface{}
+			if record.SoilTest != nil {
+				alkaliN, olsenP, availableK = record.SoilTest.AlkaliN, record.SoilTest.OlsenP, record.SoilTest.AvailableK
+			}
+			var props models.SoilProperties
+			if record.Properties != nil {
+				props = *record.Properties
+			}
+			return []interface{}{
+				record.Id, exportTime(record.Timestamp), exportID(record.PlotID), record.Location, record.Crop,
+				record.PlotSize, record.AverageYield, record.TargetYield,
+				record.FertilizerDemand.N, record.FertilizerDemand.P2O5, record.FertilizerDemand.K2O,
+				record.TotalSupply.N, record.TotalSupply.P2O5, record.TotalSupply.K2O,
+				record.OrganicCredit.N, record.OrganicCredit.P2O5, record.OrganicCredit.K2O,
+				record.Supplement.N, record.Supplement.P2O5, record.Supplement.K2O,
+				record.NitrogenBasic.Name, record.NitrogenBasic.Weight,
+				record.PhosphorusBasic.Name, record.PhosphorusBasic.Weight,
+				record.PotassiumBasic.Name, record.PotassiumBasic.Weight,
+				record.NitrogenReplenish.Name, record.NitrogenReplenish.Weight,
+				record.PhosphorusReplenish.Name, record.PhosphorusReplenish.Weight,
+				record.PotassiumReplenish.Name, record.PotassiumReplenish.Weight,
+				record.OrganicFertilizer.Name, record.OrganicFertilizer.Amount, exportID(record.OrganicFertilizer.CompostID),
+				alkaliN, olsenP, availableK, record.Region,
+				exportFloat(props.PH), exportFloat(props.OrganicMatter), exportFloat(props.EC), exportFloat(props.CEC),
+				exportFloat(props.Ca), exportFloat(props.Mg), exportFloat(props.S), exportFloat(props.Zn),
+				exportFloat(props.B), exportFloat(props.Fe), exportFloat(props.Mn),
+			}, nil
+		},
+	}})
+}
+
 // GetSoilSoil 获取单个测土配肥记录
 func (c *SoilController) GetSoilRecord(ctx *gin.Context) {
 	userID := ctx.GetInt("userID")
@@ -234,23 +516,8 @@ func (c *SoilController) GetSoilRecord(ctx *gin.Context) {
 	// }
 
 	// 查询记录
-	var record models.Soil
-	query := "SELECT * FROM records WHERE id = ? AND user_id = ?"
-	err := c.DB.QueryRow(query, id, userID).Scan(
-		&record.Id, &record.UserId, &record.AddNumber, &record.Timestamp, &record.Location, &record.Crop,
-		&record.PlotSize, &record.AverageYield,
-		&record.FertilizerDemand.N, &record.FertilizerDemand.P2O5, &record.FertilizerDemand.K2O,
-		&record.TotalSupply.N, &record.TotalSupply.P2O5, &record.TotalSupply.K2O,
-		&record.Supplement.N, &record.Supplement.P2O5, &record.Supplement.K2O,
-		&record.NitrogenReplenish.Name, &record.NitrogenReplenish.Weight,
-		&record.PhosphorusReplenish.Name, &record.PhosphorusReplenish.Weight,
-		&record.PotassiumReplenish.Name, &record.PotassiumReplenish.Weight,
-		&record.OrganicFertilizer.Name, &record.OrganicFertilizer.Amount,
-		&record.NitrogenBasic.Name, &record.NitrogenBasic.Weight,
-		&record.PhosphorusBasic.Name, &record.PhosphorusBasic.Weight,
-		&record.PotassiumBasic.Name, &record.PotassiumBasic.Weight,
-		&record.CustomRatios,
-	)
+	query := "SELECT " + soilRecordColumns + " FROM records WHERE id = ? AND user_id = ?"
+	record, err := scanSoilRecord(c.DB.QueryRow(query, id, userID))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -260,10 +527,288 @@ func (c *SoilController) GetSoilRecord(ctx *gin.Context) {
 		}
 		return
 	}
+	records := []models.Soil{record}
+	if err := interpretSoilRecords(c.DB, records); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"code": 200,
 		"msg":  "ok",
-		"data": record,
+		"data": records[0],
 	})
 }
+
+// InterpretSoilProperties 按区域分级指标对土壤理化性质分级，并给出石灰、石膏与中微量元素施用建议
+func (c *SoilController) InterpretSoilProperties(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var req models.SoilInterpretRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.PlotSize < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "plotSize must not be negative"})
+		return
+	}
+
+	if req.SampleID > 0 {
+		sample, err := loadSoilSample(c.DB, userID, req.SampleID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "土壤样品不存在"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		mergeSoilProperties(&req.Properties, soilPropertiesFromSample(sample))
+	}
+	if !hasSoilProperties(req.Properties) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "at least one soil property is required"})
+		return
+	}
+	if err := validateSoilProperties(req.Properties); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := interpretSoil(c.DB, strings.TrimSpace(req.Region), req.Properties, req.PlotSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": result,
+	})
+}
+
+// CalculateSoilFertilizer 按目标产量法计算氮、磷、钾需肥量、土壤供肥量与需补充的化肥养分量
+func (c *SoilController) CalculateSoilFertilizer(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var req models.SoilCalculateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.SampleID > 0 {
+		sample, err := loadSoilSample(c.DB, userID, req.SampleID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "土壤样品不存在"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		test, err := soilTestFromSample(sample)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.SoilTest = *test
+	}
+
+	coefficient, err := findCropCoefficient(c.DB, req.Crop, req.CoefficientID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if req.CoefficientID > 0 && coefficient == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "crop coefficient not found"})
+		return
+	}
+	result, err := calculateTargetYieldFertilization(req, coefficient)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 引用堆肥记录作为有机肥时，扣除其当季养分供应
+	if req.OrganicFertilizer.CompostID > 0 {
+		var record models.Soil
+		record.OrganicFertilizer = req.OrganicFertilizer
+		record.Supplement = result.Supplement
+		if err := applyOrganicCredit(c.DB, userID, &record, result.Efficiency); err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "堆肥记录不存在"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		result.OrganicCredit = record.OrganicCredit
+		result.Supplement = record.Supplement
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": result,
+	})
+}
+
+// RecalculateSoilRecord 使用记录保存时的作物养分系数版本与土壤测试值重新计算，复现当时的推荐结果
+func (c *SoilController) RecalculateSoilRecord(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	record, err := scanSoilRecord(c.DB.QueryRow("SELECT "+soilRecordColumns+" FROM records WHERE id = ? AND user_id = ?", ctx.Query("id"), userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if record.SoilTest == nil || record.CoefficientID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "record was not calculated on the server and cannot be reproduced"})
+		return
+	}
+
+	coefficient, err := findCropCoefficient(c.DB, "", record.CoefficientID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if coefficient == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "crop coefficient not found"})
+		return
+	}
+
+	result, err := calculateTargetYieldFertilization(models.SoilCalculateRequest{
+		Crop:          record.Crop,
+		AverageYield:  record.AverageYield,
+		PlotSize:      record.PlotSize,
+		YieldIncrease: record.YieldIncrease,
+		SoilTest:      *record.SoilTest,
+	}, coefficient)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 有机肥养分按保存时的估算值扣除，不随堆肥记录后续变化
+	result.OrganicCredit = record.OrganicCredit
+	result.Supplement = deductOrganicCredit(result.Supplement, result.OrganicCredit, result.Efficiency)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": gin.H{
+			"record":      record,
+			"coefficient": coefficient,
+			"calculation": result,
+			"matches": result.FertilizerDemand == models.NutrientAmount(record.FertilizerDemand) &&
+				result.TotalSupply == models.NutrientAmount(record.TotalSupply) &&
+				result.Supplement == models.NutrientAmount(record.Supplement),
+		},
+	})
+}
+
+// OptimizeFertilizerBlend 根据补充量从化肥产品库中求解最低成本的基肥与追肥组合
+func (c *SoilController) OptimizeFertilizerBlend(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	var req models.FertilizerBlendRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 引用测土配肥记录时，以记录的补充量作为目标
+	if req.RecordID > 0 {
+		err := c.DB.QueryRow(
+			"SELECT supplement_n, supplement_p2o5, supplement_k2o FROM records WHERE id = ? AND user_id = ?",
+			req.RecordID, userID,
+		).Scan(&req.Target.N, &req.Target.P2O5, &req.Target.K2O)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+	}
+
+	req.ProductIDs = uniqueIDs(req.ProductIDs)
+	products, err := loadFertilizerProducts(c.DB, userID, req.ProductIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.ProductIDs) > 0 && len(products) < len(req.ProductIDs) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "fertilizer product not found"})
+		return
+	}
+
+	result, err := optimizeFertilizerBlend(req, products)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": result,
+	})
+}
+
+const (
+	// organicPhosphorusAvailability、organicPotassiumAvailability 有机肥中磷、钾的当季利用系数
+	organicPhosphorusAvailability = 0.6
+	organicPotassiumAvailability  = 0.9
+)
+
+// applyOrganicCredit 根据引用的堆肥记录估算有机肥当季养分供应，并按肥料利用率从补充量中扣除（最低为 0）
+func applyOrganicCredit(db *sql.DB, userID int, record *models.Soil, efficiency models.NutrientAmount) error {
+	history := models.CompostHistory{ID: record.OrganicFertilizer.CompostID}
+	err := db.QueryRow(
+		"SELECT id FROM compost_history WHERE id = ? AND user_id = ? AND deleted_at IS NULL",
+		history.ID, userID,
+	).Scan(&history.ID)
+	if err != nil {
+		return err
+	}
+	if err := loadCompostSources(db, &history); err != nil {
+		return err
+	}
+	assessment, err := loadLatestCompostAssessment(db, history.ID)
+	if err != nil {
+		return err
+	}
+	var measuredCN *float64
+	if assessment != nil {
+		measuredCN = assessment.FinalCNRatio
+	}
+
+	estimate := estimateCompostNutrients(append(append([]models.Fertilizer{}, history.NitrogenSourcesList...), history.CarbonSourcesList...), measuredCN)
+	if estimate == nil {
+		return nil
+	}
+
+	amount := record.OrganicFertilizer.Amount
+	record.OrganicCredit.N = round2(amount * estimate.N / 100 * estimate.MineralizationRate / 100)
+	record.OrganicCredit.P2O5 = round2(amount * estimate.P2O5 / 100 * organicPhosphorusAvailability)
+	record.OrganicCredit.K2O = round2(amount * estimate.K2O / 100 * organicPotassiumAvailability)
+
+	record.Supplement = deductOrganicCredit(models.NutrientAmount(record.Supplement), models.NutrientAmount(record.OrganicCredit), efficiency)
+	return nil
+}
+
+// deductOrganicCredit 从补充量中扣除有机肥养分
+//
+// 补充量已按肥料利用率折算为化肥用量，有机肥养分是直接供应给作物的量，需同样除以利用率后再扣除，
+// 即补充量 =（需养分量 − 土壤供养分量 − 有机肥养分）/ 肥料利用率，最低为 0
+func deductOrganicCredit(supplement, credit, efficiency models.NutrientAmount) models.NutrientAmount {
+	return models.NutrientAmount{
+		N:    math.Max(0, round2(supplement.N-credit.N/(efficiency.N/100))),
+		P2O5: math.Max(0, round2(supplement.P2O5-credit.P2O5/(efficiency.P2O5/100))),
+		K2O:  math.Max(0, round2(supplement.K2O-credit.K2O/(efficiency.K2O/100))),
+	}
+}
@@ -0,0 +1,319 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go-mengtuobang/models"
+)
+
+const (
+	// limeTargetPH 酸性土壤施石灰调节的目标 pH
+	limeTargetPH = 6.0
+	// limeFactor 耕层每亩每提高 1 个 pH 单位、每 1 cmol(+)/kg 阳离子交换量所需碳酸钙（kg），按缓冲容量为 CEC 的 1/4 估算
+	limeFactor = 18.75
+	// maxLimeRate 每季石灰最大用量（kg/亩），超出部分分年施用
+	maxLimeRate = 150.0
+	// gypsumTargetPH 碱化土壤施石膏改良的目标 pH
+	gypsumTargetPH = 8.2
+	// gypsumFactor 耕层每亩每降低 1 个 pH 单位、每 1 cmol(+)/kg 阳离子交换量所需石膏（kg），按需置换的交换性钠为 CEC 的 1/5 估算
+	gypsumFactor = 25.8
+	// maxGypsumRate 每季石膏最大用量（kg/亩）
+	maxGypsumRate = 300.0
+	// defaultSoilCEC 未测阳离子交换量时采用的默认值（cmol(+)/kg）
+	defaultSoilCEC = 10.0
+)
+
+// soilProperty 可分级的土壤性质，顺序即分级结果的顺序
+type soilProperty struct {
+	key   string
+	unit  string
+	value func(*models.SoilProperties) **float64
+}
+
+var soilProperties = []soilProperty{
+	{"ph", "", func(p *models.SoilProperties) **float64 { return &p.PH }},
+	{"organicMatter", "g/kg", func(p *models.SoilProperties) **float64 { return &p.OrganicMatter }},
+	{"ec", "dS/m", func(p *models.SoilProperties) **float64 { return &p.EC }},
+	{"cec", "cmol(+)/kg", func(p *models.SoilProperties) **float64 { return &p.CEC }},
+	{"ca", "mg/kg", func(p *models.SoilProperties) **float64 { return &p.Ca }},
+	{"mg", "mg/kg", func(p *models.SoilProperties) **float64 { return &p.Mg }},
+	{"s", "mg/kg", func(p *models.SoilProperties) **float64 { return &p.S }},
+	{"zn", "mg/kg", func(p *models.SoilProperties) **float64 { return &p.Zn }},
+	{"b", "mg/kg", func(p *models.SoilProperties) **float64 { return &p.B }},
+	{"fe", "mg/kg", func(p *models.SoilProperties) **float64 { return &p.Fe }},
+	{"mn", "mg/kg", func(p *models.SoilProperties) **float64 { return &p.Mn }},
+}
+
+// findSoilProperty 按名称查找可分级的土壤性质
+func findSoilProperty(key string) (soilProperty, bool) {
+	for _, property := range soilProperties {
+		if property.key == key {
+			return property, true
+		}
+	}
+	return soilProperty{}, false
+}
+
+// micronutrientAdvice 中微量元素缺乏时的基施建议，rates 依次为极低、低时的每亩用量
+type micronutrientAdvice struct {
+	product string
+	rates   [2]float64
+	note    string
+}
+
+var micronutrientAdvices = map[string]micronutrientAdvice{
+	"zn": {"硫酸锌（ZnSO4·7H2O）", [2]float64{2, 1}, "与磷肥分开施用，可连续 2～3 季后复测"},
+	"b":  {"硼砂（Na2B4O7·10H2O）", [2]float64{1, 0.5}, "与细土混匀后撒施，避免过量造成硼毒害"},
+	"mn": {"硫酸锰（MnSO4·H2O）", [2]float64{2, 1}, "宜条施或与有机肥混施"},
+	"mg": {"硫酸镁（MgSO4·H2O）", [2]float64{15, 10}, "可与钾肥同时基施"},
+	"s":  {"石膏（CaSO4·2H2O）", [2]float64{25, 15}, "也可选用硫酸铵、硫酸钾等含硫肥料替代部分氮钾肥"},
+}
+
+// soilPropertiesFromSample 取土壤样品中的理化性质
+func soilPropertiesFromSample(sample models.SoilSample) models.SoilProperties {
+	return models.SoilProperties{
+		PH: sample.PH, OrganicMatter: sample.OrganicMatter, EC: sample.EC, CEC: sample.CEC,
+		Ca: sample.Ca, Mg: sample.Mg, S: sample.S, Zn: sample.Zn, B: sample.B, Fe: sample.Fe, Mn: sample.Mn,
+	}
+}
+
+// mergeSoilProperties 用 fallback 补全 props 中未提供的性质
+func mergeSoilProperties(props *models.SoilProperties, fallback models.SoilProperties) {
+	for _, property := range soilProperties {
+		if target := property.value(props); *target == nil {
+			*target = *property.value(&fallback)
+		}
+	}
+}
+
+// hasSoilProperties 判断是否测有任一土壤性质
+func hasSoilProperties(props models.SoilProperties) bool {
+	for _, property := range soilProperties {
+		if *property.value(&props) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSoilProperties 校验土壤性质的取值范围
+func validateSoilProperties(props models.SoilProperties) error {
+	for _, property := range soilProperties {
+		if value := *property.value(&props); value != nil && *value < 0 {
+			return fmt.Errorf("%s must not be negative", property.key)
+		}
+	}
+	if props.PH != nil && *props.PH > 14 {
+		return errors.New("ph must be between 0 and 14")
+	}
+	return nil
+}
+
+// loadInterpretationBands 加载区域分级指标，区域未配置的性质使用全国默认指标
+func loadInterpretationBands(db *sql.DB, region string) (map[string]models.SoilInterpretationBand, error) {
+	rows, err := db.Query("SELECT "+soilInterpretationBandColumns+
+		" FROM soil_interpretation_bands WHERE region = '' OR region = ? ORDER BY region = '' DESC", region)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bands := map[string]models.SoilInterpretationBand{}
+	for rows.Next() {
+		band, err := scanSoilInterpretationBand(rows)
+		if err != nil {
+			return nil, err
+		}
+		bands[band.Property] = band
+	}
+	return bands, rows.Err()
+}
+
+// gradeSoilValue 按分级指标确定测试值的等级
+func gradeSoilValue(band models.SoilInterpretationBand, value float64) string {
+	switch {
+	case value < band.VeryLowMax:
+		return models.SoilGradeVeryLow
+	case value < band.LowMax:
+		return models.SoilGradeLow
+	case value < band.MediumMax:
+		return models.SoilGradeMedium
+	case value < band.HighMax:
+		return models.SoilGradeHigh
+	default:
+		return models.SoilGradeVeryHigh
+	}
+}
+
+// interpretSoilProperties 对已测的土壤性质逐项分级，没有分级指标的性质不参与分级
+func interpretSoilProperties(props models.SoilProperties, bands map[string]models.SoilInterpretationBand) []models.SoilInterpretation {
+	interpretation := []models.SoilInterpretation{}
+	for _, property := range soilProperties {
+		value := *property.value(&props)
+		band, ok := bands[property.key]
+		if value == nil || !ok {
+			continue
+		}
+		interpretation = append(interpretation, models.SoilInterpretation{
+			Property: property.key,
+			Value:    *value,
+			Unit:     property.unit,
+			Grade:    gradeSoilValue(band, *value),
+			Region:   band.Region,
+		})
+	}
+	return interpretation
+}
+
+// recommendSoilAmendments 根据分级结果给出石灰、石膏与中微量元素的施用建议
+//
+// 酸性土壤（pH 为极低或低）按 limeFactor ×（目标 pH − pH）× CEC 估算石灰用量，交换性镁缺乏时改用白云石粉；
+// 碱化土壤（pH 极高）按 gypsumFactor ×（pH − 目标 pH）× CEC 估算石膏用量；
+// 锌、硼、锰、镁、硫缺乏时按等级给出基施用量，铁缺乏与石灰性土壤的锰缺乏改为叶面喷施
+func recommendSoilAmendments(props models.SoilProperties, interpretation []models.SoilInterpretation, plotSize float64) []models.SoilAmendment {
+	grades := map[string]string{}
+	for _, item := range interpretation {
+		grades[item.Property] = item.Grade
+	}
+	deficient := func(key string) bool {
+		return grades[key] == models.SoilGradeVeryLow || grades[key] == models.SoilGradeLow
+	}
+	cec, cecNote := defaultSoilCEC, fmt.Sprintf("未测阳离子交换量，按 %s cmol(+)/kg 估算", formatFloat(defaultSoilCEC))
+	if props.CEC != nil && *props.CEC > 0 {
+		cec, cecNote = *props.CEC, ""
+	}
+	acidic := deficient("ph")
+	alkaline := grades["ph"] == models.SoilGradeHigh || grades["ph"] == models.SoilGradeVeryHigh
+
+	amendments := []models.SoilAmendment{}
+	add := func(amendment models.SoilAmendment) {
+		amendment.Rate = round2(amendment.Rate)
+		if amendment.Method != "叶面喷施" {
+			amendment.Amount = round2(amendment.Rate * plotSize)
+		}
+		amendments = append(amendments, amendment)
+	}
+	join := func(notes ...string) string {
+		joined := ""
+		for _, note := range notes {
+			if note == "" {
+				continue
+			}
+			if joined != "" {
+				joined += "；"
+			}
+			joined += note
+		}
+		return joined
+	}
+
+	gypsum := false
+	if acidic && props.PH != nil && *props.PH < limeTargetPH {
+		rate := (limeTargetPH - *props.PH) * cec * limeFactor
+		product := "石灰石粉（CaCO3）"
+		if deficient("mg") {
+			product = "白云石粉（CaCO3·MgCO3）"
+		}
+		note := fmt.Sprintf("将 pH 调至 %s，撒施后翻耕混匀，与铵态氮肥、磷肥间隔 7 天以上", formatFloat(limeTargetPH))
+		if rate > maxLimeRate {
+			note += fmt.Sprintf("，总需 %s kg/亩，本季施用 %s kg/亩，余量分年施用", formatFloat(rate), formatFloat(maxLimeRate))
+			rate = maxLimeRate
+		}
+		add(models.SoilAmendment{Type: models.SoilAmendmentLime, Property: "ph", Product: product, Rate: rate, Method: "基施", Note: join(note, cecNote)})
+	}
+	if grades["ph"] == models.SoilGradeVeryHigh && props.PH != nil && *props.PH > gypsumTargetPH {
+		rate := (*props.PH - gypsumTargetPH) * cec * gypsumFactor
+		note := fmt.Sprintf("置换交换性钠、将 pH 降至 %s 左右，施后灌水淋洗", formatFloat(gypsumTargetPH))
+		if rate > maxGypsumRate {
+			note += fmt.Sprintf("，总需 %s kg/亩，本季施用 %s kg/亩，余量分年施用", formatFloat(rate), formatFloat(maxGypsumRate))
+			rate = maxGypsumRate
+		}
+		add(models.SoilAmendment{Type: models.SoilAmendmentGypsum, Property: "ph", Product: "石膏（CaSO4·2H2O）", Rate: rate, Method: "基施", Note: join(note, cecNote)})
+		gypsum = true
+	}
+	if deficient("ca") && !acidic && !gypsum {
+		rate := 30.0
+		if grades["ca"] == models.SoilGradeVeryLow {
+			rate = 50
+		}
+		add(models.SoilAmendment{Type: models.SoilAmendmentGypsum, Property: "ca", Product: "石膏（CaSO4·2H2O）", Rate: rate, Method: "基施", Note: "补充钙，同时提供硫"})
+		gypsum = true
+	}
+	if grades["ec"] == models.SoilGradeHigh || grades["ec"] == models.SoilGradeVeryHigh {
+		add(models.SoilAmendment{
+			Type: models.SoilAmendmentSalinity, Property: "ec", Product: "淡水淋洗", Method: "灌溉",
+			Note: "土壤含盐量偏高，播前大水淋洗并完善排水，钾肥选用硫酸钾，避免施用含氯肥料",
+		})
+	}
+
+	for _, key := range []string{"zn", "b", "mn", "mg", "s"} {
+		if !deficient(key) || (key == "mg" && acidic) || (key == "s" && gypsum) {
+			continue
+		}
+		advice := micronutrientAdvices[key]
+		level := 1
+		if grades[key] == models.SoilGradeVeryLow {
+			level = 0
+		}
+		amendment := models.SoilAmendment{
+			Type: models.SoilAmendmentMicronutrient, Property: key, Product: advice.product,
+			Rate: advice.rates[level], Method: "基施", Note: advice.note,
+		}
+		if key == "mn" && alkaline {
+			amendment.Rate, amendment.Method = 0.2, "叶面喷施"
+			amendment.Note = "石灰性土壤基施锰肥易被固定，于苗期至旺长期喷施 2～3 次，间隔 7～10 天"
+		} else if key == "zn" && alkaline {
+			amendment.Note = join(amendment.Note, "石灰性土壤可加喷 0.2% 硫酸锌溶液")
+		}
+		add(amendment)
+	}
+	if deficient("fe") {
+		rate := 0.2
+		if grades["fe"] == models.SoilGradeVeryLow {
+			rate = 0.3
+		}
+		add(models.SoilAmendment{
+			Type: models.SoilAmendmentMicronutrient, Property: "fe", Product: "硫酸亚铁（FeSO4·7H2O）或螯合铁", Rate: rate, Method: "叶面喷施",
+			Note: "土施铁肥易被氧化固定，新叶出现黄化时喷施 2～3 次，间隔 7～10 天",
+		})
+	}
+	return amendments
+}
+
+// interpretSoil 对土壤性质分级并给出改良建议
+func interpretSoil(db *sql.DB, region string, props models.SoilProperties, plotSize float64) (models.SoilInterpretResult, error) {
+	bands, err := loadInterpretationBands(db, region)
+	if err != nil {
+		return models.SoilInterpretResult{}, err
+	}
+	interpretation := interpretSoilProperties(props, bands)
+	return models.SoilInterpretResult{
+		Properties:     props,
+		Interpretation: interpretation,
+		Amendments:     recommendSoilAmendments(props, interpretation, plotSize),
+	}, nil
+}
+
+// interpretSoilRecords 按各记录区域的分级指标为测有土壤性质的记录附加分级结果与改良建议
+func interpretSoilRecords(db *sql.DB, records []models.Soil) error {
+	bandsByRegion := map[string]map[string]models.SoilInterpretationBand{}
+	for i := range records {
+		record := &records[i]
+		if record.Properties == nil {
+			continue
+		}
+		bands, ok := bandsByRegion[record.Region]
+		if !ok {
+			var err error
+			if bands, err = loadInterpretationBands(db, record.Region); err != nil {
+				return err
+			}
+			bandsByRegion[record.Region] = bands
+		}
+		record.Interpretation = interpretSoilProperties(*record.Properties, bands)
+		record.Amendments = recommendSoilAmendments(*record.Properties, record.Interpretation, record.PlotSize)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+)
+
+// SoilInterpretationController 处理土壤性质分级指标相关的请求
+type SoilInterpretationController struct {
+	DB *sql.DB
+}
+
+// NewSoilInterpretationController 创建一个新的SoilInterpretationController实例
+func NewSoilInterpretationController(db *sql.DB) *SoilInterpretationController {
+	return &SoilInterpretationController{DB: db}
+}
+
+const soilInterpretationBandColumns = "id, region, property, very_low_max, low_max, medium_max, high_max, unit, updated_at"
+
+// scanSoilInterpretationBand 扫描一行分级指标
+func scanSoilInterpretationBand(scanner interface{ Scan(...interface{}) error }) (models.SoilInterpretationBand, error) {
+	var band models.SoilInterpretationBand
+	var updatedAt time.Time
+	err := scanner.Scan(&band.ID, &band.Region, &band.Property, &band.VeryLowMax, &band.LowMax, &band.MediumMax,
+		&band.HighMax, &band.Unit, &updatedAt)
+	if err != nil {
+		return band, err
+	}
+	band.UpdatedAt = updatedAt.Format(dateTimeLayout)
+	return band, nil
+}
+
+// GetInterpretationBands 获取分级指标，提供 region 时返回该区域生效的指标（区域未配置的性质取全国默认指标）
+func (c *SoilInterpretationController) GetInterpretationBands(ctx *gin.Context) {
+	var bands []models.SoilInterpretationBand
+	if region, ok := ctx.GetQuery("region"); ok {
+		effective, err := loadInterpretationBands(c.DB, strings.TrimSpace(region))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for interpretation bands"})
+			return
+		}
+		bands = []models.SoilInterpretationBand{}
+		for _, property := range soilProperties {
+			if band, ok := effective[property.key]; ok {
+				bands = append(bands, band)
+			}
+		}
+	} else {
+		rows, err := c.DB.Query("SELECT " + soilInterpretationBandColumns + " FROM soil_interpretation_bands ORDER BY region, id")
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for interpretation bands"})
+			return
+		}
+		defer rows.Close()
+
+		bands = []models.SoilInterpretationBand{}
+		for rows.Next() {
+			band, err := scanSoilInterpretationBand(rows)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning interpretation band row"})
+				return
+			}
+			bands = append(bands, band)
+		}
+		if err = rows.Err(); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating interpretation band rows"})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": bands,
+	})
+}
+
+// CreateInterpretationBand 为区域新增一项性质的分级指标（管理员功能）
+func (c *SoilInterpretationController) CreateInterpretationBand(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	if status, err := checkAdmin(c.DB, userID, "change interpretation bands"); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var band models.SoilInterpretationBand
+	if err := ctx.ShouldBindJSON(&band); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := normalizeInterpretationBand(&band); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int
+	if err := c.DB.QueryRow("SELECT COUNT(*) FROM soil_interpretation_bands WHERE region = ? AND property = ?",
+		band.Region, band.Property).Scan(&count); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if count > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "region already has bands for this property, update them instead"})
+		return
+	}
+
+	result, err := c.DB.Exec(`
+		INSERT INTO soil_interpretation_bands (region, property, very_low_max, low_max, medium_max, high_max, unit)
+		VALUES (?,?,?,?,?,?,?)
+	`, band.Region, band.Property, band.VeryLowMax, band.LowMax, band.MediumMax, band.HighMax, band.Unit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.respondInterpretationBand(ctx, http.StatusCreated, id)
+}
+
+// UpdateInterpretationBand 修改分级指标的阈值（管理员功能），区域与性质不可修改
+func (c *SoilInterpretationController) UpdateInterpretationBand(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	if status, err := checkAdmin(c.DB, userID, "change interpretation bands"); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	existing, err := scanSoilInterpretationBand(c.DB.QueryRow(
+		"SELECT "+soilInterpretationBandColumns+" FROM soil_interpretation_bands WHERE id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Interpretation band not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	var band models.SoilInterpretationBand
+	if err := ctx.ShouldBindJSON(&band); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	band.Region, band.Property = existing.Region, existing.Property
+	if err := normalizeInterpretationBand(&band); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = c.DB.Exec(`
+		UPDATE soil_interpretation_bands SET very_low_max = ?, low_max = ?, medium_max = ?, high_max = ?, unit = ?
+		WHERE id = ?
+	`, band.VeryLowMax, band.LowMax, band.MediumMax, band.HighMax, band.Unit, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.respondInterpretationBand(ctx, http.StatusOK, int64(id))
+}
+
+// DeleteInterpretationBand 删除区域分级指标（管理员功能），删除后该区域改用全国默认指标，全国默认指标不可删除
+func (c *SoilInterpretationController) DeleteInterpretationBand(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	if status, err := checkAdmin(c.DB, userID, "change interpretation bands"); err != nil {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var region string
+	err := c.DB.QueryRow("SELECT region FROM soil_interpretation_bands WHERE id = ?", ctx.Param("id")).Scan(&region)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Interpretation band not found"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if region == "" {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "national default bands cannot be deleted, update them instead"})
+		return
+	}
+
+	if _, err := c.DB.Exec("DELETE FROM soil_interpretation_bands WHERE id = ?", ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// respondInterpretationBand 返回指定的分级指标
+func (c *SoilInterpretationController) respondInterpretationBand(ctx *gin.Context, status int, id int64) {
+	band, err := scanSoilInterpretationBand(c.DB.QueryRow(
+		"SELECT "+soilInterpretationBandColumns+" FROM soil_interpretation_bands WHERE id = ?", id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(status, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": band,
+	})
+}
+
+// normalizeInterpretationBand 校验分级指标的性质与阈值顺序，并补全默认单位
+func normalizeInterpretationBand(band *models.SoilInterpretationBand) error {
+	band.Region = strings.TrimSpace(band.Region)
+	property, ok := findSoilProperty(band.Property)
+	if !ok {
+		keys := make([]string, 0, len(soilProperties))
+		for _, p := range soilProperties {
+			keys = append(keys, p.key)
+		}
+		return fmt.Errorf("property must be one of %s", strings.Join(keys, ", "))
+	}
+	if !(band.VeryLowMax < band.LowMax && band.LowMax < band.MediumMax && band.MediumMax < band.HighMax) {
+		return errors.New("thresholds must increase: veryLowMax < lowMax < mediumMax < highMax")
+	}
+	if band.VeryLowMax < 0 {
+		return errors.New("thresholds must not be negative")
+	}
+	if band.Unit == "" {
+		band.Unit = property.unit
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"go-mengtuobang/models"
+)
+
+func TestGradeSoilValue(t *testing.T) {
+	// 全国默认有效锌分级指标：0.3 / 0.5 / 1.0 / 3.0 mg/kg
+	band := models.SoilInterpretationBand{Property: "zn", VeryLowMax: 0.3, LowMax: 0.5, MediumMax: 1.0, HighMax: 3.0}
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{0, models.SoilGradeVeryLow},
+		{0.29, models.SoilGradeVeryLow},
+		{0.3, models.SoilGradeLow},
+		{0.49, models.SoilGradeLow},
+		{0.5, models.SoilGradeMedium},
+		{0.99, models.SoilGradeMedium},
+		{1.0, models.SoilGradeHigh},
+		{2.99, models.SoilGradeHigh},
+		{3.0, models.SoilGradeVeryHigh},
+		{50, models.SoilGradeVeryHigh},
+	}
+	for _, tt := range tests {
+		if got := gradeSoilValue(band, tt.value); got != tt.want {
+			t.Errorf("gradeSoilValue(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestInterpretSoilProperties(t *testing.T) {
+	float := func(v float64) *float64 { return &v }
+	bands := map[string]models.SoilInterpretationBand{
+		"ph": {Property: "ph", VeryLowMax: 4.5, LowMax: 5.5, MediumMax: 7.5, HighMax: 8.5},
+		"zn": {Region: "华北", Property: "zn", VeryLowMax: 0.3, LowMax: 0.5, MediumMax: 1.0, HighMax: 3.0},
+		"b":  {Property: "b", VeryLowMax: 0.2, LowMax: 0.5, MediumMax: 1.0, HighMax: 2.0},
+	}
+	tests := []struct {
+		name  string
+		props models.SoilProperties
+		want  []models.SoilInterpretation
+	}{
+		{"nothing measured", models.SoilProperties{}, []models.SoilInterpretation{}},
+		{
+			"measured properties in table order",
+			models.SoilProperties{Zn: float(0.4), PH: float(8.6)},
+			[]models.SoilInterpretation{
+				{Property: "ph", Value: 8.6, Grade: models.SoilGradeVeryHigh},
+				{Property: "zn", Value: 0.4, Unit: "mg/kg", Grade: models.SoilGradeLow, Region: "华北"},
+			},
+		},
+		{
+			"properties without a band are skipped",
+			models.SoilProperties{Fe: float(2), B: float(1.5)},
+			[]models.SoilInterpretation{
+				{Property: "b", Value: 1.5, Unit: "mg/kg", Grade: models.SoilGradeHigh},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := interpretSoilProperties(tt.props, bands); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("interpretSoilProperties() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
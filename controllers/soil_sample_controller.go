@@ -0,0 +1,362 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+)
+
+// SoilSampleController 处理土壤样品化验结果相关的请求
+type SoilSampleController struct {
+	DB *sql.DB
+}
+
+// NewSoilSampleController 创建一个新的SoilSampleController实例
+func NewSoilSampleController(db *sql.DB) *SoilSampleController {
+	return &SoilSampleController{DB: db}
+}
+
+const soilSampleColumns = `id, user_id, sample_code, location, sampled_at, ph, organic_matter, alkali_n, olsen_p, available_k,
+	available_fe, available_mn, available_cu, available_zn, available_b, available_mo, source_file, created_at,
+	ec, cec, exchangeable_ca, exchangeable_mg, available_s`
+
+// scanSoilSample 扫描一行土壤样品数据
+func scanSoilSample(scanner interface{ Scan(...interface{}) error }) (models.SoilSample, error) {
+	var sample models.SoilSample
+	var location, sourceFile sql.NullString
+	var sampledAt sql.NullTime
+	var createdAt time.Time
+	var ph, organicMatter, alkaliN, olsenP, availableK, fe, mn, cu, zn, b, mo sql.NullFloat64
+	var ec, cec, ca, mg, s sql.NullFloat64
+	err := scanner.Scan(&sample.ID, &sample.UserID, &sample.SampleCode, &location, &sampledAt,
+		&ph, &organicMatter, &alkaliN, &olsenP, &availableK, &fe, &mn, &cu, &zn, &b, &mo, &sourceFile, &createdAt,
+		&ec, &cec, &ca, &mg, &s)
+	if err != nil {
+		return sample, err
+	}
+	sample.Location = location.String
+	sample.SourceFile = sourceFile.String
+	if sampledAt.Valid {
+		sample.SampledAt = sampledAt.Time.Format(dateLayout)
+	}
+	sample.CreatedAt = createdAt.Format(dateTimeLayout)
+	sample.PH, sample.OrganicMatter = nullFloatPtr(ph), nullFloatPtr(organicMatter)
+	sample.AlkaliN, sample.OlsenP, sample.AvailableK = nullFloatPtr(alkaliN), nullFloatPtr(olsenP), nullFloatPtr(availableK)
+	sample.Fe, sample.Mn, sample.Cu = nullFloatPtr(fe), nullFloatPtr(mn), nullFloatPtr(cu)
+	sample.Zn, sample.B, sample.Mo = nullFloatPtr(zn), nullFloatPtr(b), nullFloatPtr(mo)
+	sample.EC, sample.CEC = nullFloatPtr(ec), nullFloatPtr(cec)
+	sample.Ca, sample.Mg, sample.S = nullFloatPtr(ca), nullFloatPtr(mg), nullFloatPtr(s)
+	return sample, nil
+}
+
+// ImportSoilSamples 批量导入化验报告中的土壤样品
+//
+// multipart 表单字段：file 为 CSV 或 XLSX 文件；sheet 为 XLSX 工作表名称，默认第一个；headerRow 为表头所在行，默认 1；
+// mapping 为字段到表头的 JSON 映射，如 {"sampleCode":"样品编号","organicMatter":"OM"}，未映射的字段按常见中英文表头自动识别；
+// units 为字段单位的 JSON 映射，如 {"organicMatter":"%","olsenP":"mg/kg P2O5"}，默认取表头括号内的单位；
+// overwrite 为 true 时覆盖同编号的已有样品，否则记为错误；dryRun 为 true 时只校验不保存。
+// 通过校验的行在同一事务中保存，未通过的行在结果的 errors 中逐行列出
+func (c *SoilSampleController) ImportSoilSamples(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSoilSampleImportSize)
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file must not exceed %d MB", maxSoilSampleImportSize>>20)})
+		} else {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		}
+		return
+	}
+	var mapping, units map[string]string
+	for field, target := range map[string]*map[string]string{"mapping": &mapping, "units": &units} {
+		if value := ctx.PostForm(field); value != "" {
+			if err := json.Unmarshal([]byte(value), target); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + field + ", expected a JSON object"})
+				return
+			}
+		}
+	}
+	headerRow := 1
+	if value := ctx.PostForm("headerRow"); value != "" {
+		if headerRow, err = strconv.Atoi(value); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid headerRow"})
+			return
+		}
+	}
+	dryRun, _ := strconv.ParseBool(ctx.PostForm("dryRun"))
+	overwrite, _ := strconv.ParseBool(ctx.PostForm("overwrite"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	fileName := filepath.Base(fileHeader.Filename)
+	rows, err := readSoilSampleFile(file, fileName, strings.TrimSpace(ctx.PostForm("sheet")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	samples, result, err := parseSoilSampleRows(rows, headerRow, mapping, units)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result.DryRun = dryRun
+
+	// 同编号的已有样品
+	existing := map[string]bool{}
+	if len(samples) > 0 {
+		query := "SELECT sample_code FROM soil_samples WHERE user_id = ? AND sample_code IN (?" + strings.Repeat(",?", len(samples)-1) + ")"
+		params := []interface{}{userID}
+		for _, item := range samples {
+			params = append(params, item.sample.SampleCode)
+		}
+		codeRows, err := c.DB.Query(query, params...)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer codeRows.Close()
+		for codeRows.Next() {
+			var code string
+			if err := codeRows.Scan(&code); err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			existing[code] = true
+		}
+		if err := codeRows.Err(); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var accepted []models.SoilSample
+	for _, item := range samples {
+		if existing[item.sample.SampleCode] {
+			if !overwrite {
+				result.Failed++
+				result.Errors = append(result.Errors, models.SoilSampleImportError{
+					Row: item.row, Field: "sampleCode", Value: item.sample.SampleCode,
+					Message: "sample ID already exists, set overwrite to replace it",
+				})
+				continue
+			}
+			result.Updated++
+		} else {
+			result.Imported++
+		}
+		accepted = append(accepted, item.sample)
+	}
+	sortSoilSampleImportErrors(result.Errors)
+
+	if !dryRun && len(accepted) > 0 {
+		tx, err := c.DB.Begin()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		stmt, err := tx.Prepare(`
+			INSERT INTO soil_samples (user_id, sample_code, location, sampled_at, ph, organic_matter, alkali_n, olsen_p, available_k,
+				available_fe, available_mn, available_cu, available_zn, available_b, available_mo, source_file,
+				ec, cec, exchangeable_ca, exchangeable_mg, available_s)
+			VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+			ON DUPLICATE KEY UPDATE location = VALUES(location), sampled_at = VALUES(sampled_at), ph = VALUES(ph),
+				organic_matter = VALUES(organic_matter), alkali_n = VALUES(alkali_n), olsen_p = VALUES(olsen_p),
+				available_k = VALUES(available_k), available_fe = VALUES(available_fe), available_mn = VALUES(available_mn),
+				available_cu = VALUES(available_cu), available_zn = VALUES(available_zn), available_b = VALUES(available_b),
+				available_mo = VALUES(available_mo), source_file = VALUES(source_file), ec = VALUES(ec), cec = VALUES(cec),
+				exchangeable_ca = VALUES(exchangeable_ca), exchangeable_mg = VALUES(exchangeable_mg), available_s = VALUES(available_s)
+		`)
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer stmt.Close()
+		for _, sample := range accepted {
+			var sampledAt interface{}
+			if sample.SampledAt != "" {
+				sampledAt = sample.SampledAt
+			}
+			_, err := stmt.Exec(userID, sample.SampleCode, sample.Location, sampledAt, sample.PH, sample.OrganicMatter,
+				sample.AlkaliN, sample.OlsenP, sample.AvailableK, sample.Fe, sample.Mn, sample.Cu, sample.Zn, sample.B, sample.Mo, fileName,
+				sample.EC, sample.CEC, sample.Ca, sample.Mg, sample.S)
+			if err != nil {
+				tx.Rollback()
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if err = tx.Commit(); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": result,
+	})
+}
+
+// GetSoilSamples 获取土壤样品列表，可按编号或地点关键字、来源文件筛选
+func (c *SoilSampleController) GetSoilSamples(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "20"))
+	keyword := ctx.Query("keyword")
+	sourceFile := ctx.Query("sourceFile")
+
+	where := " WHERE user_id = ?"
+	params := []interface{}{userID}
+	if keyword != "" {
+		where += " AND (sample_code LIKE ? OR location LIKE ?)"
+		keywordLike := "%" + keyword + "%"
+		params = append(params, keywordLike, keywordLike)
+	}
+	if sourceFile != "" {
+		where += " AND source_file = ?"
+		params = append(params, sourceFile)
+	}
+
+	var totalCount int
+	if err := c.DB.QueryRow("SELECT COUNT(*) FROM soil_samples"+where, params...).Scan(&totalCount); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting total count"})
+		return
+	}
+
+	query := "SELECT " + soilSampleColumns + " FROM soil_samples" + where + " ORDER BY sampled_at IS NULL, sampled_at DESC, id DESC LIMIT ? OFFSET ?"
+	params = append(params, pageSize, (page-1)*pageSize)
+	rows, err := c.DB.Query(query, params...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for soil samples"})
+		return
+	}
+	defer rows.Close()
+
+	samples := []models.SoilSample{}
+	for rows.Next() {
+		sample, err := scanSoilSample(rows)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error scanning soil sample row"})
+			return
+		}
+		samples = append(samples, sample)
+	}
+	if err = rows.Err(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating soil sample rows"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":        200,
+		"msg":         "ok",
+		"data":        samples,
+		"totalCount":  totalCount,
+		"currentPage": page,
+		"pageSize":    pageSize,
+	})
+}
+
+// GetSoilSample 获取单个土壤样品
+func (c *SoilSampleController) GetSoilSample(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	sample, err := loadSoilSample(c.DB, userID, ctx.Query("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Soil sample not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": sample,
+	})
+}
+
+// DeleteSoilSample 删除土壤样品
+func (c *SoilSampleController) DeleteSoilSample(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Query("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	result, err := tx.Exec("DELETE FROM soil_samples WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		tx.Rollback()
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Soil sample not found"})
+		return
+	}
+	// 引用该样品的测土配肥记录保留，解除关联
+	if _, err := tx.Exec("UPDATE records SET sample_id = NULL WHERE sample_id = ? AND user_id = ?", id, userID); err != nil {
+		tx.Rollback()
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+	})
+}
+
+// loadSoilSample 加载当前用户的土壤样品
+func loadSoilSample(db *sql.DB, userID int, id interface{}) (models.SoilSample, error) {
+	return scanSoilSample(db.QueryRow("SELECT "+soilSampleColumns+" FROM soil_samples WHERE id = ? AND user_id = ?", id, userID))
+}
+
+// soilTestFromSample 取土壤样品的碱解氮、有效磷、速效钾作为配肥计算的土壤测试值，缺测项按 0 计
+func soilTestFromSample(sample models.SoilSample) (*models.SoilTest, error) {
+	if sample.AlkaliN == nil && sample.OlsenP == nil && sample.AvailableK == nil {
+		return nil, fmt.Errorf("soil sample %s has no alkaliN, olsenP or availableK value", sample.SampleCode)
+	}
+	test := &models.SoilTest{}
+	for _, pair := range []struct {
+		value  *float64
+		target *float64
+	}{{sample.AlkaliN, &test.AlkaliN}, {sample.OlsenP, &test.OlsenP}, {sample.AvailableK, &test.AvailableK}} {
+		if pair.value != nil {
+			*pair.target = *pair.value
+		}
+	}
+	return test, nil
+}
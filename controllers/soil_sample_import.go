@@ -0,0 +1,392 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"go-mengtuobang/models"
+)
+
+const (
+	// maxSoilSampleImportRows 单次导入的最大数据行数
+	maxSoilSampleImportRows = 5000
+	// maxSoilSampleImportSize 导入请求体的最大字节数
+	maxSoilSampleImportSize = 10 << 20
+)
+
+// soilSampleField 化验报告中可导入的字段
+type soilSampleField struct {
+	key string
+	// aliases 自动识别的表头名称，已按 normalizeSoilSampleHeader 归一化
+	aliases []string
+	// unitKind 数值字段的单位类型，对应 soilSampleUnitFactors；为空时为文本字段
+	unitKind string
+	// oxide 以氧化物计时的表示方式（如 p2o5），换算为元素态时除以 oxideFactor
+	oxide       string
+	oxideFactor float64
+	// cmolFactor 以 cmol(+)/kg 计时换算为 mg/kg 的系数，仅交换性盐基使用
+	cmolFactor float64
+	// min、max 换算为标准单位后的合理范围
+	min, max float64
+	value    func(*models.SoilSample) **float64
+}
+
+// soilSampleUnitFactors 各单位换算为标准单位（有机质 g/kg，其余 mg/kg）的系数，空单位即标准单位
+var soilSampleUnitFactors = map[string]map[string]float64{
+	"ph":       {"": 1},
+	"organic":  {"": 1, "g/kg": 1, "%": 10},
+	"nutrient": {"": 1, "mg/kg": 1, "ppm": 1, "g/kg": 1000},
+	"ec":       {"": 1, "ds/m": 1, "ms/cm": 1, "ms/m": 0.01, "us/cm": 0.001, "μs/cm": 0.001},
+	"cec":      {"": 1, "cmol/kg": 1, "cmol(+)/kg": 1, "meq/100g": 1},
+}
+
+// soilSampleCmolUnits 交换性盐基以 cmol(+)/kg 计时的单位写法
+var soilSampleCmolUnits = map[string]bool{"cmol/kg": true, "cmol(+)/kg": true, "meq/100g": true}
+
+// soilSampleFields 化验报告字段定义，顺序即结果中的字段顺序
+var soilSampleFields = []soilSampleField{
+	{key: "sampleCode", aliases: []string{"sampleid", "samplecode", "sampleno", "sample", "样品编号", "样品号", "样本编号", "土样编号", "编号"}},
+	{key: "location", aliases: []string{"location", "site", "采样地点", "采样位置", "地点", "位置", "地块"}},
+	{key: "sampledAt", aliases: []string{"sampledat", "sampledate", "samplingdate", "date", "采样日期", "采样时间", "日期"}},
+	{key: "ph", aliases: []string{"ph", "ph值", "酸碱度"}, unitKind: "ph", min: 3, max: 11,
+		value: func(s *models.SoilSample) **float64 { return &s.PH }},
+	{key: "organicMatter", aliases: []string{"organicmatter", "om", "som", "有机质"}, unitKind: "organic", max: 500,
+		value: func(s *models.SoilSample) **float64 { return &s.OrganicMatter }},
+	{key: "alkaliN", aliases: []string{"alkalin", "alkalihydrolyzablen", "availablen", "n", "碱解氮", "水解性氮", "有效氮"}, unitKind: "nutrient", max: 1000,
+		value: func(s *models.SoilSample) **float64 { return &s.AlkaliN }},
+	{key: "olsenP", aliases: []string{"olsenp", "availablep", "p", "有效磷", "速效磷"}, unitKind: "nutrient", oxide: "p2o5", oxideFactor: p2o5PerP, max: 500,
+		value: func(s *models.SoilSample) **float64 { return &s.OlsenP }},
+	{key: "availableK", aliases: []string{"availablek", "k", "速效钾", "有效钾"}, unitKind: "nutrient", oxide: "k2o", oxideFactor: k2oPerK, max: 3000,
+		value: func(s *models.SoilSample) **float64 { return &s.AvailableK }},
+	{key: "ec", aliases: []string{"ec", "ece", "电导率", "含盐量"}, unitKind: "ec", max: 100,
+		value: func(s *models.SoilSample) **float64 { return &s.EC }},
+	{key: "cec", aliases: []string{"cec", "阳离子交换量"}, unitKind: "cec", max: 200,
+		value: func(s *models.SoilSample) **float64 { return &s.CEC }},
+	{key: "ca", aliases: []string{"ca", "exchangeableca", "交换性钙"}, unitKind: "nutrient", cmolFactor: 200.4, max: 20000,
+		value: func(s *models.SoilSample) **float64 { return &s.Ca }},
+	{key: "mg", aliases: []string{"mg", "exchangeablemg", "交换性镁"}, unitKind: "nutrient", cmolFactor: 121.5, max: 5000,
+		value: func(s *models.SoilSample) **float64 { return &s.Mg }},
+	{key: "s", aliases: []string{"s", "availables", "有效硫"}, unitKind: "nutrient", max: 1000,
+		value: func(s *models.SoilSample) **float64 { return &s.S }},
+	{key: "fe", aliases: []string{"fe", "availablefe", "有效铁"}, unitKind: "nutrient", max: 1000,
+		value: func(s *models.SoilSample) **float64 { return &s.Fe }},
+	{key: "mn", aliases: []string{"mn", "availablemn", "有效锰"}, unitKind: "nutrient", max: 1000,
+		value: func(s *models.SoilSample) **float64 { return &s.Mn }},
+	{key: "cu", aliases: []string{"cu", "availablecu", "有效铜"}, unitKind: "nutrient", max: 200,
+		value: func(s *models.SoilSample) **float64 { return &s.Cu }},
+	{key: "zn", aliases: []string{"zn", "availablezn", "有效锌"}, unitKind: "nutrient", max: 200,
+		value: func(s *models.SoilSample) **float64 { return &s.Zn }},
+	{key: "b", aliases: []string{"b", "availableb", "有效硼"}, unitKind: "nutrient", max: 50,
+		value: func(s *models.SoilSample) **float64 { return &s.B }},
+	{key: "mo", aliases: []string{"mo", "availablemo", "有效钼"}, unitKind: "nutrient", max: 20,
+		value: func(s *models.SoilSample) **float64 { return &s.Mo }},
+}
+
+// soilSampleDateLayouts 采样日期支持的格式
+var soilSampleDateLayouts = []string{
+	dateLayout, dateTimeLayout, "2006/01/02", "2006/1/2", "2006-1-2", "2006.01.02", "2006.1.2", "20060102", "2006年1月2日",
+}
+
+// importedSoilSample 通过校验的一行样品数据
+type importedSoilSample struct {
+	row    int
+	sample models.SoilSample
+}
+
+// readSoilSampleFile 读取 CSV 或 XLSX 化验报告的全部行，XLSX 默认读取第一个工作表
+func readSoilSampleFile(r io.Reader, fileName, sheet string) ([][]string, error) {
+	switch ext := strings.ToLower(fileName[strings.LastIndex(fileName, ".")+1:]); ext {
+	case "csv":
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		// 国内化验室导出的 CSV 多为 GBK 编码
+		if !utf8.Valid(data) {
+			if data, err = simplifiedchinese.GB18030.NewDecoder().Bytes(data); err != nil {
+				return nil, errors.New("csv file must be encoded in UTF-8 or GBK")
+			}
+		}
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv file: %s", err.Error())
+		}
+		return rows, nil
+	case "xlsx", "xlsm":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xlsx file: %s", err.Error())
+		}
+		defer f.Close()
+		if sheet == "" {
+			sheets := f.GetSheetList()
+			if len(sheets) == 0 {
+				return nil, errors.New("xlsx file has no worksheet")
+			}
+			sheet = sheets[0]
+		} else if index, err := f.GetSheetIndex(sheet); err != nil || index < 0 {
+			return nil, fmt.Errorf("worksheet %q not found", sheet)
+		}
+		// 读取原始值，避免数字按单元格格式被四舍五入，日期以序列号返回
+		return f.GetRows(sheet, excelize.Options{RawCellValue: true})
+	default:
+		return nil, fmt.Errorf("unsupported file type %q, upload a .csv or .xlsx file", ext)
+	}
+}
+
+// normalizeSoilSampleHeader 拆分表头中的名称与括号内的单位，名称转为小写并去掉空格与分隔符
+func normalizeSoilSampleHeader(header string) (name, unit string) {
+	header = strings.NewReplacer("（", "(", "）", ")").Replace(strings.TrimSpace(header))
+	if i := strings.Index(header, "("); i >= 0 {
+		unit = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(header[i+1:]), ")"))
+		header = header[:i]
+	}
+	name = strings.ToLower(strings.NewReplacer(" ", "", "_", "", "-", "", ".", "").Replace(header))
+	return name, unit
+}
+
+// soilSampleUnitFactor 计算字段从给定单位换算为标准单位的系数
+func soilSampleUnitFactor(field soilSampleField, unit string) (float64, error) {
+	normalized := strings.ToLower(strings.NewReplacer(" ", "", ",", "", "，", "").Replace(unit))
+	if field.cmolFactor > 0 && soilSampleCmolUnits[normalized] {
+		return field.cmolFactor, nil
+	}
+	factor := 1.0
+	if field.oxide != "" && strings.Contains(normalized, field.oxide) {
+		normalized = strings.Replace(normalized, field.oxide, "", 1)
+		factor /= field.oxideFactor
+	}
+	unitFactor, ok := soilSampleUnitFactors[field.unitKind][normalized]
+	if !ok {
+		return 0, fmt.Errorf("unsupported unit %q for %s", unit, field.key)
+	}
+	return factor * unitFactor, nil
+}
+
+// parseSoilSampleDate 解析采样日期，兼容常见日期格式与 Excel 日期序列号
+func parseSoilSampleDate(value string) (string, error) {
+	for _, layout := range soilSampleDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date.Format(dateLayout), nil
+		}
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial >= 1 && serial < 100000 {
+		if date, err := excelize.ExcelDateToTime(serial, false); err == nil {
+			return date.Format(dateLayout), nil
+		}
+	}
+	return "", errors.New("invalid date, expected YYYY-MM-DD")
+}
+
+// parseSoilSampleRows 按字段映射解析化验报告，逐行换算单位并校验范围
+//
+// mapping 指定字段对应的表头，未指定的字段按别名自动识别；units 指定字段的单位，未指定时取表头括号内的单位。
+// 返回通过校验的样品与导入结果，单行的错误记录在结果中，表头无法识别等整体错误通过 error 返回
+func parseSoilSampleRows(rows [][]string, headerRow int, mapping, units map[string]string) ([]importedSoilSample, models.SoilSampleImportResult, error) {
+	result := models.SoilSampleImportResult{
+		Columns: map[string]string{},
+		Units:   map[string]string{},
+		Errors:  []models.SoilSampleImportError{},
+	}
+	fields := map[string]soilSampleField{}
+	for _, field := range soilSampleFields {
+		fields[field.key] = field
+	}
+	for key := range mapping {
+		if _, ok := fields[key]; !ok {
+			return nil, result, fmt.Errorf("unknown field %q in mapping", key)
+		}
+	}
+	for key := range units {
+		if field, ok := fields[key]; !ok || field.unitKind == "" {
+			return nil, result, fmt.Errorf("unknown numeric field %q in units", key)
+		}
+	}
+	if headerRow < 1 || headerRow > len(rows) {
+		return nil, result, fmt.Errorf("header row %d not found in file", headerRow)
+	}
+
+	// 识别各字段所在的列，显式映射优先于别名
+	header := rows[headerRow-1]
+	columns := map[string]int{}
+	factors := map[string]float64{}
+	for _, field := range soilSampleFields {
+		index := -1
+		if target, ok := mapping[field.key]; ok {
+			targetName, _ := normalizeSoilSampleHeader(target)
+			for i, cell := range header {
+				name, _ := normalizeSoilSampleHeader(cell)
+				if strings.TrimSpace(cell) == strings.TrimSpace(target) || name == targetName {
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				return nil, result, fmt.Errorf("column %q mapped to %s not found in header", target, field.key)
+			}
+		} else {
+			for i, cell := range header {
+				name, _ := normalizeSoilSampleHeader(cell)
+				for _, alias := range field.aliases {
+					if name == alias {
+						index = i
+						break
+					}
+				}
+				if index >= 0 {
+					break
+				}
+			}
+		}
+		if index < 0 {
+			continue
+		}
+		columns[field.key] = index
+		result.Columns[field.key] = strings.TrimSpace(header[index])
+
+		if field.unitKind != "" {
+			_, unit := normalizeSoilSampleHeader(header[index])
+			if override, ok := units[field.key]; ok {
+				unit = override
+			}
+			factor, err := soilSampleUnitFactor(field, unit)
+			if err != nil {
+				return nil, result, err
+			}
+			factors[field.key] = factor
+			if unit != "" {
+				result.Units[field.key] = unit
+			}
+		}
+	}
+	if _, ok := columns["sampleCode"]; !ok {
+		return nil, result, errors.New("sample ID column not found, map sampleCode to the column header")
+	}
+	if len(factors) == 0 {
+		return nil, result, errors.New("no measurement column found, map at least one soil property such as ph, organicMatter, alkaliN, olsenP or availableK")
+	}
+	if len(rows)-headerRow > maxSoilSampleImportRows {
+		return nil, result, fmt.Errorf("file has more than %d data rows, split it into smaller files", maxSoilSampleImportRows)
+	}
+
+	var samples []importedSoilSample
+	seen := map[string]int{}
+	for i, cells := range rows[headerRow:] {
+		rowNumber := headerRow + i + 1
+		cell := func(key string) string {
+			index, ok := columns[key]
+			if !ok || index >= len(cells) {
+				return ""
+			}
+			return strings.TrimSpace(cells[index])
+		}
+		blank := true
+		for _, index := range columns {
+			if index < len(cells) && strings.TrimSpace(cells[index]) != "" {
+				blank = false
+				break
+			}
+		}
+		if blank {
+			continue
+		}
+		result.Total++
+
+		var rowErrors []models.SoilSampleImportError
+		addError := func(field, value, message string) {
+			rowErrors = append(rowErrors, models.SoilSampleImportError{Row: rowNumber, Field: field, Value: value, Message: message})
+		}
+
+		sample := models.SoilSample{SampleCode: cell("sampleCode"), Location: cell("location")}
+		switch {
+		case sample.SampleCode == "":
+			addError("sampleCode", "", "sample ID is required")
+		case utf8.RuneCountInString(sample.SampleCode) > 100:
+			addError("sampleCode", sample.SampleCode, "sample ID must not exceed 100 characters")
+		case seen[sample.SampleCode] > 0:
+			addError("sampleCode", sample.SampleCode, fmt.Sprintf("duplicate sample ID, already used in row %d", seen[sample.SampleCode]))
+		default:
+			seen[sample.SampleCode] = rowNumber
+		}
+		if utf8.RuneCountInString(sample.Location) > 255 {
+			addError("location", sample.Location, "location must not exceed 255 characters")
+		}
+		if value := cell("sampledAt"); value != "" {
+			date, err := parseSoilSampleDate(value)
+			if err != nil {
+				addError("sampledAt", value, err.Error())
+			}
+			sample.SampledAt = date
+		}
+
+		measured := false
+		for _, field := range soilSampleFields {
+			factor, ok := factors[field.key]
+			if !ok {
+				continue
+			}
+			raw := cell(field.key)
+			// 空值与未检出视为缺测
+			switch strings.ToLower(raw) {
+			case "", "-", "--", "/", "—", "nd", "n/a", "未检出", "未测":
+				continue
+			}
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				addError(field.key, raw, "value must be a number")
+				continue
+			}
+			value *= factor
+			if value < field.min || value > field.max {
+				unit := " mg/kg"
+				switch field.unitKind {
+				case "ph":
+					unit = ""
+				case "organic":
+					unit = " g/kg"
+				case "ec":
+					unit = " dS/m"
+				case "cec":
+					unit = " cmol(+)/kg"
+				}
+				addError(field.key, raw, fmt.Sprintf("value %s%s is outside the valid range %s-%s%s, check the unit",
+					formatFloat(value), unit, formatFloat(field.min), formatFloat(field.max), unit))
+				continue
+			}
+			value = math.Round(value*1000) / 1000
+			*field.value(&sample) = &value
+			measured = true
+		}
+		if !measured && len(rowErrors) == 0 {
+			addError("", "", "row has no measurement value")
+		}
+
+		if len(rowErrors) > 0 {
+			result.Failed++
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+		samples = append(samples, importedSoilSample{row: rowNumber, sample: sample})
+	}
+	return samples, result, nil
+}
+
+// sortSoilSampleImportErrors 按行号排序导入错误，同一行保持字段顺序
+func sortSoilSampleImportErrors(errs []models.SoilSampleImportError) {
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Row < errs[j].Row })
+}
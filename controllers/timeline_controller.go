@@ -0,0 +1,417 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+)
+
+// TimelineController 地块时间线，合并测土配肥、灌溉与堆肥记录
+type TimelineController struct {
+	DB *sql.DB
+}
+
+// NewTimelineController 创建一个新的TimelineController实例
+func NewTimelineController(db *sql.DB) *TimelineController {
+	return &TimelineController{DB: db}
+}
+
+// 时间线事件类型
+const (
+	timelineSoil       = "soil"
+	timelineIrrigation = "irrigation"
+	timelineCompost    = "compost"
+)
+
+// timelineSource 时间线事件来源表
+type timelineSource struct {
+	Type       string
+	Table      string
+	TimeColumn string
+	Condition  string
+}
+
+var timelineSources = []timelineSource{
+	{Type: timelineSoil, Table: "records", TimeColumn: "timestamp"},
+	{Type: timelineIrrigation, Table: "water_records", TimeColumn: "created_at"},
+	{Type: timelineCompost, Table: "compost_history", TimeColumn: "created_at", Condition: " AND deleted_at IS NULL"},
+}
+
+const (
+	defaultTimelinePageSize = 20
+	maxTimelinePageSize     = 100
+)
+
+// timelineQuery 时间线查询条件
+type timelineQuery struct {
+	UserID    int
+	PlotID    int // 为 0 时查询用户的全部记录
+	Types     []string
+	StartDate string
+	EndDate   string
+	PageSize  int
+	Cursor    *timelineCursor
+}
+
+// includes 判断是否查询指定类型的事件，未指定类型时查询全部
+func (q timelineQuery) includes(eventType string) bool {
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, t := range q.Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// timelineCursor 分页游标，指向上一页最后一个事件，下一页从其之后开始
+type timelineCursor struct {
+	OccurredAt string
+	Type       string
+	ID         int
+}
+
+// encode 将游标编码为不透明字符串
+func (c timelineCursor) encode() string {
+	raw := c.OccurredAt + "|" + c.Type + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeTimelineCursor 解析分页游标
+func decodeTimelineCursor(value string) (*timelineCursor, error) {
+	invalid := errors.New("invalid cursor")
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, invalid
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return nil, invalid
+	}
+	if _, err := time.Parse(dateTimeLayout, parts[0]); err != nil {
+		return nil, invalid
+	}
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, invalid
+	}
+	for _, source := range timelineSources {
+		if source.Type == parts[1] {
+			return &timelineCursor{OccurredAt: parts[0], Type: parts[1], ID: id}, nil
+		}
+	}
+	return nil, invalid
+}
+
+// GetTimeline 获取当前用户全部记录的时间线，适用于尚未建立地块的用户
+func (c *TimelineController) GetTimeline(ctx *gin.Context) {
+	c.respondTimeline(ctx, 0)
+}
+
+// GetPlotTimeline 获取地块的时间线
+func (c *TimelineController) GetPlotTimeline(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	if _, err := loadPlot(c.DB, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Plot not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.respondTimeline(ctx, id)
+}
+
+// respondTimeline 解析查询参数并返回一页时间线事件
+//
+// 事件按发生时间倒序排列，types 以逗号分隔筛选事件类型，cursor 为上一页返回的 nextCursor
+func (c *TimelineController) respondTimeline(ctx *gin.Context, plotID int) {
+	query := timelineQuery{
+		UserID:    ctx.GetInt("userID"),
+		PlotID:    plotID,
+		StartDate: ctx.Query("startDate"),
+		EndDate:   ctx.Query("endDate"),
+		PageSize:  defaultTimelinePageSize,
+	}
+
+	if value := ctx.Query("pageSize"); value != "" {
+		pageSize, err := strconv.Atoi(value)
+		if err != nil || pageSize < 1 || pageSize > maxTimelinePageSize {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "pageSize must be between 1 and " + strconv.Itoa(maxTimelinePageSize)})
+			return
+		}
+		query.PageSize = pageSize
+	}
+
+	if value := ctx.Query("types"); value != "" {
+		for _, eventType := range strings.Split(value, ",") {
+			eventType = strings.TrimSpace(eventType)
+			known := false
+			for _, source := range timelineSources {
+				known = known || source.Type == eventType
+			}
+			if !known {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "unknown timeline type: " + eventType})
+				return
+			}
+			query.Types = append(query.Types, eventType)
+		}
+	}
+
+	if value := ctx.Query("cursor"); value != "" {
+		cursor, err := decodeTimelineCursor(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query.Cursor = cursor
+	}
+
+	events, nextCursor, err := loadTimeline(c.DB, query)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":       200,
+		"msg":        "ok",
+		"data":       events,
+		"nextCursor": nextCursor,
+		"pageSize":   query.PageSize,
+	})
+}
+
+// loadTimeline 以 UNION ALL 合并各来源表查询一页事件，再按类型批量加载记录详情，
+// 返回的 nextCursor 为空表示没有更多事件
+//
+// 每个分支各自按时间倒序取 pageSize+1 条，使其可以利用 (user_id, plot_id, 时间) 或 (user_id, 时间) 索引，
+// 不必在合并前读出地块或用户的全部记录
+func loadTimeline(db *sql.DB, query timelineQuery) ([]models.TimelineEvent, string, error) {
+	var branches []string
+	var params []interface{}
+	for _, source := range timelineSources {
+		if !query.includes(source.Type) {
+			continue
+		}
+		branch := "SELECT '" + source.Type + "' AS type, id, plot_id, " + source.TimeColumn + " AS occurred_at FROM " +
+			source.Table + " WHERE user_id = ?" + source.Condition
+		params = append(params, query.UserID)
+		if query.PlotID > 0 {
+			branch += " AND plot_id = ?"
+			params = append(params, query.PlotID)
+		}
+		if query.StartDate != "" && query.EndDate != "" {
+			branch += " AND " + source.TimeColumn + " BETWEEN ? AND ?"
+			params = append(params, query.StartDate, query.EndDate)
+		}
+		// 排序键为（时间, 类型, ID）倒序，分支内类型固定，游标条件可化简为按时间与 ID 比较
+		if cursor := query.Cursor; cursor != nil {
+			switch {
+			case source.Type < cursor.Type:
+				branch += " AND " + source.TimeColumn + " <= ?"
+				params = append(params, cursor.OccurredAt)
+			case source.Type > cursor.Type:
+				branch += " AND " + source.TimeColumn + " < ?"
+				params = append(params, cursor.OccurredAt)
+			default:
+				branch += " AND (" + source.TimeColumn + " < ? OR (" + source.TimeColumn + " = ? AND id < ?))"
+				params = append(params, cursor.OccurredAt, cursor.OccurredAt, cursor.ID)
+			}
+		}
+		// 多取一条判断是否还有下一页
+		branch += " ORDER BY " + source.TimeColumn + " DESC, id DESC LIMIT ?"
+		params = append(params, query.PageSize+1)
+		branches = append(branches, "("+branch+")")
+	}
+
+	sqlQuery := "SELECT type, id, plot_id, occurred_at FROM (" + strings.Join(branches, " UNION ALL ") + ") t" +
+		" ORDER BY occurred_at DESC, type DESC, id DESC LIMIT ?"
+	params = append(params, query.PageSize+1)
+
+	rows, err := db.Query(sqlQuery, params...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	events := []models.TimelineEvent{}
+	for rows.Next() {
+		var event models.TimelineEvent
+		var plotID sql.NullInt64
+		var occurredAt time.Time
+		if err := rows.Scan(&event.Type, &event.ID, &plotID, &occurredAt); err != nil {
+			return nil, "", err
+		}
+		event.PlotID = int(plotID.Int64)
+		event.OccurredAt = occurredAt.Format(dateTimeLayout)
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(events) > query.PageSize {
+		events = events[:query.PageSize]
+		last := events[len(events)-1]
+		nextCursor = timelineCursor{OccurredAt: last.OccurredAt, Type: last.Type, ID: last.ID}.encode()
+	}
+
+	if err := attachTimelineDetails(db, query.UserID, events); err != nil {
+		return nil, "", err
+	}
+	return events, nextCursor, nil
+}
+
+// attachTimelineDetails 按事件类型各用一次查询加载记录详情，避免逐条查询
+func attachTimelineDetails(db *sql.DB, userID int, events []models.TimelineEvent) error {
+	ids := map[string][]interface{}{}
+	for _, event := range events {
+		ids[event.Type] = append(ids[event.Type], event.ID)
+	}
+
+	soils, err := loadTimelineSoilRecords(db, userID, ids[timelineSoil])
+	if err != nil {
+		return err
+	}
+	waterRecords, err := loadTimelineWaterRecords(db, userID, ids[timelineIrrigation])
+	if err != nil {
+		return err
+	}
+	histories, err := loadTimelineCompostHistories(db, userID, ids[timelineCompost])
+	if err != nil {
+		return err
+	}
+
+	for i := range events {
+		switch events[i].Type {
+		case timelineSoil:
+			events[i].Soil = soils[events[i].ID]
+		case timelineIrrigation:
+			events[i].Irrigation = waterRecords[events[i].ID]
+		case timelineCompost:
+			events[i].Compost = histories[events[i].ID]
+		}
+	}
+	return nil
+}
+
+// loadTimelineSoilRecords 批量查询测土配肥记录
+func loadTimelineSoilRecords(db *sql.DB, userID int, ids []interface{}) (map[int]*models.Soil, error) {
+	result := map[int]*models.Soil{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	rows, err := db.Query("SELECT "+soilRecordColumns+" FROM records WHERE user_id = ? AND id IN (?"+strings.Repeat(",?", len(ids)-1)+")",
+		append([]interface{}{userID}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scanSoilRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		result[record.Id] = &record
+	}
+	return result, rows.Err()
+}
+
+// loadTimelineWaterRecords 批量查询灌溉记录及其区域数据
+func loadTimelineWaterRecords(db *sql.DB, userID int, ids []interface{}) (map[int]*models.WaterRecord, error) {
+	result := map[int]*models.WaterRecord{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	placeholders := "?" + strings.Repeat(",?", len(ids)-1)
+	rows, err := db.Query(`
+		SELECT
+			id, user_id, irrigation_mode, efficiency, crop_type,
+			depth, optimal_moisture, soil_type, field_capacity, soil_density, plot_id, created_at
+		FROM water_records
+		WHERE user_id = ? AND id IN (`+placeholders+`)
+	`, append([]interface{}{userID}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record models.WaterRecord
+		var plotID sql.NullInt64
+		err := rows.Scan(
+			&record.ID, &record.UserID, &record.IrrigationMode, &record.Efficiency,
+			&record.CropType, &record.Depth, &record.OptimalMoisture, &record.SoilType,
+			&record.FieldCapacity, &record.SoilDensity, &plotID, &record.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		record.PlotID = int(plotID.Int64)
+		result[record.ID] = &record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	areas, err := loadWaterAreas(db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for id, record := range result {
+		record.Areas = areas[id]
+	}
+	return result, nil
+}
+
+// loadTimelineCompostHistories 批量查询堆肥记录及其氮源、碳源
+func loadTimelineCompostHistories(db *sql.DB, userID int, ids []interface{}) (map[int]*models.CompostHistory, error) {
+	result := map[int]*models.CompostHistory{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	placeholders := "?" + strings.Repeat(",?", len(ids)-1)
+	rows, err := db.Query(`
+		SELECT id, all_volume, cn_ratio, density, water_add, target_moisture, plot_id, created_at
+		FROM compost_history
+		WHERE user_id = ? AND id IN (`+placeholders+`)
+	`, append([]interface{}{userID}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		history := models.CompostHistory{UserID: userID}
+		var plotID sql.NullInt64
+		err := rows.Scan(&history.ID, &history.AllVolume, &history.CNRatio, &history.Density, &history.WaterAdd,
+			&history.TargetMoisture, &plotID, &history.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		history.PlotID = int(plotID.Int64)
+		result[history.ID] = &history
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, attachCompostSources(db, result)
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestTimelineCursorRoundTrip(t *testing.T) {
+	tests := []timelineCursor{
+		{OccurredAt: "2024-03-01 08:30:00", Type: timelineSoil, ID: 1},
+		{OccurredAt: "2024-12-31 23:59:59", Type: timelineIrrigation, ID: 42},
+		{OccurredAt: "2025-01-01 00:00:00", Type: timelineCompost, ID: 1234567},
+	}
+	for _, want := range tests {
+		t.Run(want.Type, func(t *testing.T) {
+			got, err := decodeTimelineCursor(want.encode())
+			if err != nil {
+				t.Fatalf("decodeTimelineCursor() error = %v", err)
+			}
+			if *got != want {
+				t.Errorf("decodeTimelineCursor() = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeTimelineCursorInvalid(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"empty", ""},
+		{"not base64", "not a cursor!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte("2024-03-01 08:30:00|soil|1"))},
+		{"too few parts", encode("2024-03-01 08:30:00|soil")},
+		{"too many parts", encode("2024-03-01 08:30:00|soil|1|2")},
+		{"bad time", encode("2024-03-01T08:30:00Z|soil|1")},
+		{"date only", encode("2024-03-01|soil|1")},
+		{"bad id", encode("2024-03-01 08:30:00|soil|abc")},
+		{"unknown type", encode("2024-03-01 08:30:00|harvest|1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cursor, err := decodeTimelineCursor(tt.value); err == nil {
+				t.Errorf("decodeTimelineCursor(%q) = %+v, want error", tt.value, *cursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/models"
+	"go-mengtuobang/utils"
+)
+
+// WeatherController 处理逐日气象数据相关的请求
+type WeatherController struct {
+	DB *sql.DB
+}
+
+// NewWeatherController 创建一个新的WeatherController实例
+func NewWeatherController(db *sql.DB) *WeatherController {
+	return &WeatherController{DB: db}
+}
+
+// UploadWeather 上传逐日气象数据并计算 ET0，同一气象站同一天的数据会被覆盖
+//
+// 支持 JSON 请求体，或 multipart 表单（station、latitude、altitude、windHeight 字段与 CSV 文件 file），
+// CSV 首行为表头：date,tmax,tmin,rhmax,rhmin,rhmean,wind,radiation,sunshine,rainfall，除 date、tmax、tmin 外均可省略
+func (c *WeatherController) UploadWeather(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+
+	var req models.WeatherUploadRequest
+	if strings.HasPrefix(ctx.ContentType(), "multipart/form-data") {
+		parsed, err := parseWeatherForm(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req = parsed
+	} else if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Days) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "at least one day of weather data is required"})
+		return
+	}
+
+	// 逐日计算 ET0
+	for i := range req.Days {
+		day := &req.Days[i]
+		date, err := time.Parse(dateLayout, day.Date)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid date %q, expected YYYY-MM-DD", day.Date)})
+			return
+		}
+		et0, err := utils.PenmanMonteithET0(utils.ET0Input{
+			DayOfYear:     date.YearDay(),
+			Latitude:      req.Latitude,
+			Altitude:      req.Altitude,
+			TMax:          day.TMax,
+			TMin:          day.TMin,
+			RHMax:         day.RHMax,
+			RHMin:         day.RHMin,
+			RHMean:        day.RHMean,
+			WindSpeed:     day.WindSpeed,
+			WindHeight:    req.WindHeight,
+			Radiation:     day.Radiation,
+			SunshineHours: day.SunshineHours,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %s", day.Date, err.Error())})
+			return
+		}
+		day.Station = req.Station
+		day.ET0 = round2(et0)
+	}
+
+	tx, err := c.DB.Begin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	upsertSQL := `
+		INSERT INTO weather_daily (user_id, station, date, latitude, altitude, t_max, t_min, rh_max, rh_min, rh_mean,
+			wind_speed, radiation, sunshine_hours, rainfall, et0)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+		ON DUPLICATE KEY UPDATE latitude = VALUES(latitude), altitude = VALUES(altitude), t_max = VALUES(t_max),
+			t_min = VALUES(t_min), rh_max = VALUES(rh_max), rh_min = VALUES(rh_min), rh_mean = VALUES(rh_mean),
+			wind_speed = VALUES(wind_speed), radiation = VALUES(radiation), sunshine_hours = VALUES(sunshine_hours),
+			rainfall = VALUES(rainfall), et0 = VALUES(et0)
+	`
+	for _, day := range req.Days {
+		_, err := tx.Exec(upsertSQL, userID, req.Station, day.Date, req.Latitude, req.Altitude, day.TMax, day.TMin,
+			day.RHMax, day.RHMin, day.RHMean, day.WindSpeed, day.Radiation, day.SunshineHours, day.Rainfall, day.ET0)
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": req.Days,
+	})
+}
+
+// GetWeather 查询气象站的逐日气象数据
+func (c *WeatherController) GetWeather(ctx *gin.Context) {
+	userID := ctx.GetInt("userID")
+	station := ctx.Query("station")
+	if station == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "station is required"})
+		return
+	}
+
+	days, err := loadWeatherDays(c.DB, userID, station, ctx.Query("startDate"), ctx.Query("endDate"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying database for weather data"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "ok",
+		"data": days,
+	})
+}
+
+// loadWeatherDays 按日期升序查询气象站的逐日气象数据，startDate、endDate 为空时不限制
+func loadWeatherDays(db *sql.DB, userID int, station, startDate, endDate string) ([]models.WeatherDay, error) {
+	query := `
+		SELECT id, station, date, t_max, t_min, rh_max, rh_min, rh_mean, wind_speed, radiation, sunshine_hours, rainfall, et0
+		FROM weather_daily WHERE user_id = ? AND station = ?
+	`
+	params := []interface{}{userID, station}
+	if startDate != "" {
+		query += " AND date >= ?"
+		params = append(params, startDate)
+	}
+	if endDate != "" {
+		query += " AND date <= ?"
+		params = append(params, endDate)
+	}
+	query += " ORDER BY date"
+
+	rows, err := db.Query(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []models.WeatherDay{}
+	for rows.Next() {
+		var day models.WeatherDay
+		var date time.Time
+		var rhMax, rhMin, rhMean, wind, radiation, sunshine sql.NullFloat64
+		err := rows.Scan(&day.ID, &day.Station, &date, &day.TMax, &day.TMin, &rhMax, &rhMin, &rhMean,
+			&wind, &radiation, &sunshine, &day.Rainfall, &day.ET0)
+		if err != nil {
+			return nil, err
+		}
+		day.Date = date.Format(dateLayout)
+		day.RHMax, day.RHMin, day.RHMean = nullFloatPtr(rhMax), nullFloatPtr(rhMin), nullFloatPtr(rhMean)
+		day.WindSpeed, day.Radiation, day.SunshineHours = nullFloatPtr(wind), nullFloatPtr(radiation), nullFloatPtr(sunshine)
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+// parseWeatherForm 解析 multipart 表单中的气象站信息与 CSV 文件
+func parseWeatherForm(ctx *gin.Context) (models.WeatherUploadRequest, error) {
+	req := models.WeatherUploadRequest{Station: strings.TrimSpace(ctx.PostForm("station"))}
+	if req.Station == "" {
+		return req, errors.New("station is required")
+	}
+	for field, target := range map[string]*float64{"latitude": &req.Latitude, "altitude": &req.Altitude, "windHeight": &req.WindHeight} {
+		if value := ctx.PostForm(field); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return req, fmt.Errorf("invalid %s", field)
+			}
+			*target = parsed
+		}
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return req, errors.New("file is required")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return req, err
+	}
+	defer file.Close()
+
+	req.Days, err = parseWeatherCSV(file)
+	return req, err
+}
+
+// parseWeatherCSV 解析逐日气象 CSV，列顺序不限，按表头名称识别
+func parseWeatherCSV(r io.Reader) ([]models.WeatherDay, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("weather file is empty")
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"date", "tmax", "tmin"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("weather file is missing column %q", required)
+		}
+	}
+
+	var days []models.WeatherDay
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err.Error())
+		}
+
+		// optional 读取可选列，空值返回 nil
+		optional := func(name string) (*float64, error) {
+			i, ok := columns[name]
+			if !ok || i >= len(record) || strings.TrimSpace(record[i]) == "" {
+				return nil, nil
+			}
+			value, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s", line, name)
+			}
+			return &value, nil
+		}
+
+		day := models.WeatherDay{Date: strings.TrimSpace(record[columns["date"]])}
+		values := map[string]**float64{
+			"rhmax": &day.RHMax, "rhmin": &day.RHMin, "rhmean": &day.RHMean,
+			"wind": &day.WindSpeed, "radiation": &day.Radiation, "sunshine": &day.SunshineHours,
+		}
+		for name, target := range values {
+			if *target, err = optional(name); err != nil {
+				return nil, err
+			}
+		}
+		for name, target := range map[string]*float64{"tmax": &day.TMax, "tmin": &day.TMin, "rainfall": &day.Rainfall} {
+			value, err := optional(name)
+			if err != nil {
+				return nil, err
+			}
+			if value == nil {
+				if name == "rainfall" {
+					continue
+				}
+				return nil, fmt.Errorf("line %d: %s is required", line, name)
+			}
+			*target = *value
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
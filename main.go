@@ -2,8 +2,10 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"go-mengtuobang/config"
+	"go-mengtuobang/controllers"
 	"go-mengtuobang/routes"
 )
 
@@ -11,6 +13,9 @@ func main() {
 	// 初始化数据库连接
 	config.InitDB()
 
+	// 启动无数据告警的定时检查
+	controllers.StartNoDataAlertChecker(config.DB, 10*time.Minute)
+
 	// 设置路由
 	r := routes.SetupRouter(config.DB)
 
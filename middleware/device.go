@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+
+	"go-mengtuobang/utils"
+)
+
+// MachineCodeHeader 设备请求携带机器码的请求头
+const MachineCodeHeader = "X-Machine-Code"
+
+// DeviceAuthMiddleware 验证设备机器码的中间件，机器码须已激活并绑定用户，
+// 验证通过后在上下文中设置绑定用户的 userID 与机器码 machineCodeID
+func DeviceAuthMiddleware(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code := c.GetHeader(MachineCodeHeader)
+		if code == "" {
+			utils.Unauthorized(c, MachineCodeHeader+" header required")
+			c.Abort()
+			return
+		}
+
+		var machineCodeID int
+		var userID sql.NullInt64
+		var isActive bool
+		err := db.QueryRow("SELECT id, user_id, is_active FROM machine_codes WHERE code = ?", code).Scan(&machineCodeID, &userID, &isActive)
+		// 早期解绑将 user_id 写为空字符串，MySQL 中存为 0，同样视为未绑定
+		if err != nil || !isActive || !userID.Valid || userID.Int64 <= 0 {
+			utils.Unauthorized(c, "Invalid, inactive or unbound machine code")
+			c.Abort()
+			return
+		}
+
+		c.Set("userID", int(userID.Int64))
+		c.Set("machineCodeID", machineCodeID)
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+// 告警规则类型
+const (
+	AlertBelowRefill        = "below_refill"         // 含水量低于补水点
+	AlertAboveFieldCapacity = "above_field_capacity" // 含水量高于田间持水量
+	AlertNoData             = "no_data"              // 超过指定小时数无数据
+)
+
+// 告警事件状态
+const (
+	AlertStatusOpen     = "open"
+	AlertStatusResolved = "resolved"
+)
+
+// 告警通知渠道
+const (
+	AlertChannelLog     = "log"
+	AlertChannelWebhook = "webhook"
+	AlertChannelSMS     = "sms"
+)
+
+// AlertRule 区域告警规则，Threshold 对含水量规则为含水量（%），对无数据规则为小时数
+type AlertRule struct {
+	ID              int      `json:"id"`
+	UserID          int      `json:"user_id"`
+	Name            string   `json:"name"`
+	AreaID          int      `json:"areaId"`
+	RuleType        string   `json:"ruleType" binding:"required,oneof=below_refill above_field_capacity no_data"`
+	Threshold       float64  `json:"threshold" binding:"gt=0"`
+	Channels        []string `json:"channels" binding:"dive,oneof=log webhook sms"`
+	WebhookURL      string   `json:"webhookUrl"`
+	Phone           string   `json:"phone"`
+	CooldownMinutes int      `json:"cooldownMinutes"` // 同一告警重复通知的最短间隔，0 表示仅在首次触发时通知
+	IsActive        bool     `json:"isActive"`
+	CreatedAt       string   `json:"created_at"`
+}
+
+// AlertEvent 告警事件，同一规则未恢复前的重复触发合并为一条事件
+type AlertEvent struct {
+	ID               int      `json:"id"`
+	RuleID           int      `json:"ruleId"`
+	RuleName         string   `json:"ruleName"`
+	AreaID           int      `json:"areaId"`
+	RuleType         string   `json:"ruleType"`
+	Value            *float64 `json:"value"`
+	Message          string   `json:"message"`
+	Status           string   `json:"status"`
+	IsRead           bool     `json:"isRead"`
+	Occurrences      int      `json:"occurrences"`
+	FirstTriggeredAt string   `json:"firstTriggeredAt"`
+	LastTriggeredAt  string   `json:"lastTriggeredAt"`
+	ResolvedAt       string   `json:"resolvedAt,omitempty"`
+}
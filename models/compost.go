@@ -8,6 +8,11 @@ type Fertilizer struct {
 	N        float64 `json:"n"`
 	Moisture float64 `json:"moisture"`
 	C_N      float64 `json:"c_n"`
+	// P2O5、K2O 干基磷、钾含量（%），用于估算成品养分
+	P2O5 float64 `json:"p2o5,omitempty"`
+	K2O  float64 `json:"k2o,omitempty"`
+	// MaterialID 引用物料库中的物料，设置后碳、氮、含水率以物料库为准
+	MaterialID int `json:"materialId,omitempty"`
 }
 
 // CompostHistory 堆肥历史记录模型
@@ -19,8 +24,15 @@ type CompostHistory struct {
 	CNRatio             string       `json:"cNRatio"`
 	Density             string       `json:"density"`
 	WaterAdd            string       `json:"waterAdd"`
+	TargetMoisture      float64      `json:"targetMoisture"`
 	CreatedAt           string       `json:"created_at"`
 	UserID              int          `json:"user_id"`
+	// PlotID 关联的地块（堆肥场地或施用地块）
+	PlotID int `json:"plotId"`
+	// Assessment 最近一次腐熟度评价，仅在查询单条记录时返回
+	Assessment *CompostAssessment `json:"assessment,omitempty"`
+	// NutrientEstimate 成品养分估算，仅在查询单条记录时返回
+	NutrientEstimate *CompostNutrientEstimate `json:"nutrientEstimate,omitempty"`
 }
 
 // Source 源结构体，数值字段为 string 类型
@@ -37,4 +49,77 @@ type Result struct {
 	CNRatio         string `json:"cn_ratio"`
 	Density         string `json:"density"`
 	MoistureContent string `json:"moisture_content"`
-}
\ No newline at end of file
+	WaterAdd        string `json:"water_add"`
+}
+
+// CompostCalculateRequest 堆肥配比计算请求
+type CompostCalculateRequest struct {
+	NitrogenSourcesList []Fertilizer `json:"nitrogenSourcesList"`
+	CarbonSourcesList   []Fertilizer `json:"carbonSourcesList"`
+	AllVolume           float64      `json:"allVolume"`
+	TargetMoisture      float64      `json:"targetMoisture"`
+}
+
+// CompostSolveSource 配方求解的可用物料
+type CompostSolveSource struct {
+	Fertilizer
+	// MaxWeight 最大可用量（kg），0 表示不限
+	MaxWeight float64 `json:"maxWeight,omitempty"`
+	// Density 容重（kg/m³），引用物料库时以物料库为准
+	Density float64 `json:"density,omitempty"`
+}
+
+// CompostSolveRequest 堆肥配方求解请求
+type CompostSolveRequest struct {
+	Sources       []CompostSolveSource `json:"sources"`
+	TargetCNRatio float64              `json:"targetCNRatio"`
+	MinMoisture   float64              `json:"minMoisture"`
+	MaxMoisture   float64              `json:"maxMoisture"`
+	AllVolume     float64              `json:"allVolume"`
+}
+
+// CompostSolveResult 堆肥配方求解结果
+type CompostSolveResult struct {
+	Feasible    bool         `json:"feasible"`
+	Sources     []Fertilizer `json:"sources"`
+	WaterAdd    float64      `json:"waterAdd"`
+	TotalWeight float64      `json:"totalWeight"`
+	CNRatio     float64      `json:"cNRatio"`
+	Moisture    float64      `json:"moisture"`
+	Reasons     []string     `json:"reasons,omitempty"`
+}
+
+// 物料类别
+const (
+	MaterialCategoryNitrogen = "nitrogen" // 氮源
+	MaterialCategoryCarbon   = "carbon"   // 碳源
+)
+
+// CompostMaterial 堆肥物料库模型，UserID 为空表示系统默认物料
+type CompostMaterial struct {
+	ID          int     `json:"id"`
+	UserID      *int    `json:"user_id"`
+	Name        string  `json:"name" binding:"required"`
+	Category    string  `json:"category" binding:"required,oneof=nitrogen carbon"`
+	C           float64 `json:"c"`
+	N           float64 `json:"n"`
+	P2O5        float64 `json:"p2o5"`
+	K2O         float64 `json:"k2o"`
+	Moisture    float64 `json:"moisture"`
+	Density     float64 `json:"density"`
+	Description string  `json:"description"`
+	IsSystem    bool    `json:"isSystem"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+// CompostNutrientEstimate 成品堆肥养分估算，养分含量按鲜基计
+type CompostNutrientEstimate struct {
+	ProductWeight      float64 `json:"productWeight"`      // 成品鲜重（kg）
+	Moisture           float64 `json:"moisture"`           // 成品含水率（%）
+	N                  float64 `json:"n"`                  // 全氮 N（%）
+	P2O5               float64 `json:"p2o5"`               // 五氧化二磷 P2O5（%）
+	K2O                float64 `json:"k2o"`                // 氧化钾 K2O（%）
+	TotalNutrient      float64 `json:"totalNutrient"`      // 总养分 N+P2O5+K2O（%）
+	FinalCNRatio       float64 `json:"finalCNRatio"`       // 成品碳氮比
+	MineralizationRate float64 `json:"mineralizationRate"` // 当季氮素矿化率（%）
+}
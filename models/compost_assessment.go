@@ -0,0 +1,53 @@
+package models
+
+// 腐熟度等级
+const (
+	GradeMature       = "mature"       // 腐熟
+	GradeSemiMature   = "semi-mature"  // 基本腐熟
+	GradeImmature     = "immature"     // 未腐熟
+	GradeUndetermined = "undetermined" // 指标不全，无法判定
+)
+
+// MaturityStandard 腐熟度评价标准，阈值为空表示该标准不考核此项
+type MaturityStandard struct {
+	ID                       int      `json:"id"`
+	Code                     string   `json:"code" binding:"required"`
+	Name                     string   `json:"name" binding:"required"`
+	MaxCNRatio               *float64 `json:"maxCNRatio"`
+	MinPH                    *float64 `json:"minPH"`
+	MaxPH                    *float64 `json:"maxPH"`
+	MaxEC                    *float64 `json:"maxEC"`
+	MinGerminationIndex      *float64 `json:"minGerminationIndex"`
+	MaxMoisture              *float64 `json:"maxMoisture"`
+	MinOrganicMatter         *float64 `json:"minOrganicMatter"`
+	MinTotalNutrient         *float64 `json:"minTotalNutrient"`
+	RequireTemperatureStable bool     `json:"requireTemperatureStable"`
+	Description              string   `json:"description"`
+}
+
+// CompostAssessment 堆肥腐熟度评价，测定值为空表示未测定
+type CompostAssessment struct {
+	ID                int             `json:"id"`
+	CompostHistoryID  int             `json:"compostHistoryId" binding:"required"`
+	StandardCode      string          `json:"standardCode" binding:"required"`
+	FinalCNRatio      *float64        `json:"finalCNRatio"`
+	PH                *float64        `json:"ph"`
+	EC                *float64        `json:"ec"`                // 电导率（mS/cm）
+	GerminationIndex  *float64        `json:"germinationIndex"`  // 种子发芽指数（%）
+	TemperatureStable *bool           `json:"temperatureStable"` // 堆温是否已接近环境温度并保持稳定
+	Moisture          *float64        `json:"moisture"`          // 水分（%）
+	OrganicMatter     *float64        `json:"organicMatter"`     // 有机质（干基，%）
+	TotalNutrient     *float64        `json:"totalNutrient"`     // 总养分 N+P2O5+K2O（干基，%）
+	Grade             string          `json:"grade"`
+	Passed            bool            `json:"passed"`
+	Checks            []MaturityCheck `json:"checks"`
+	CreatedAt         string          `json:"created_at"`
+}
+
+// MaturityCheck 单项指标的评价结果
+type MaturityCheck struct {
+	Item        string   `json:"item"`
+	Value       *float64 `json:"value"`
+	Requirement string   `json:"requirement"`
+	Status      string   `json:"status"` // pass / fail / missing
+}
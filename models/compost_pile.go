@@ -0,0 +1,60 @@
+package models
+
+// 堆体状态
+const (
+	PileStatusActive   = "active"   // 堆制中
+	PileStatusFinished = "finished" // 已完成
+)
+
+// 堆体日志类型
+const (
+	PileLogReading = "reading" // 温度、含水率读数
+	PileLogTurning = "turning" // 翻堆
+	PileLogNote    = "note"    // 备注
+)
+
+// 堆肥阶段
+const (
+	PhaseMesophilic   = "mesophilic"   // 升温期
+	PhaseThermophilic = "thermophilic" // 高温期
+	PhaseCuring       = "curing"       // 降温腐熟期
+)
+
+// CompostPile 堆体模型，关联一条堆肥配比记录
+type CompostPile struct {
+	ID               int                 `json:"id"`
+	UserID           int                 `json:"user_id"`
+	CompostHistoryID int                 `json:"compostHistoryId" binding:"required"`
+	Name             string              `json:"name"`
+	StartDate        string              `json:"startDate" binding:"required"`
+	Status           string              `json:"status"`
+	Notes            string              `json:"notes"`
+	CreatedAt        string              `json:"created_at"`
+	Logs             []CompostPileLog    `json:"logs,omitempty"`
+	Summary          *CompostPileSummary `json:"summary,omitempty"`
+}
+
+// CompostPileLog 堆体日志（温度/含水率读数、翻堆、备注）
+type CompostPileLog struct {
+	ID          int      `json:"id"`
+	PileID      int      `json:"pileId"`
+	LogType     string   `json:"logType" binding:"required,oneof=reading turning note"`
+	LoggedAt    string   `json:"loggedAt"`
+	Temperature *float64 `json:"temperature"`
+	Moisture    *float64 `json:"moisture"`
+	Note        string   `json:"note"`
+}
+
+// CompostPileSummary 根据日志计算的堆体状态
+type CompostPileSummary struct {
+	Phase                 string   `json:"phase"`
+	DaysElapsed           int      `json:"daysElapsed"`
+	PeakTemperature       *float64 `json:"peakTemperature"`
+	LatestTemperature     *float64 `json:"latestTemperature"`
+	LatestMoisture        *float64 `json:"latestMoisture"`
+	DaysAbove55           int      `json:"daysAbove55"`
+	PathogenKillReached   bool     `json:"pathogenKillReached"`
+	TurningCount          int      `json:"turningCount"`
+	LastTurnedAt          string   `json:"lastTurnedAt,omitempty"`
+	EstimatedMaturityDate string   `json:"estimatedMaturityDate"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// CropNutrientCoefficient 作物养分系数，按作物编码分版本保存，修改时新增版本，旧版本保留用于复现历史推荐
+type CropNutrientCoefficient struct {
+	ID       int    `json:"id"`
+	CropCode string `json:"cropCode" binding:"required"`
+	CropName string `json:"cropName" binding:"required"`
+	Version  int    `json:"version"`
+	// Uptake 每 100 kg 经济产量的养分吸收量（kg）
+	Uptake NutrientAmount `json:"uptake"`
+	// Efficiency 化肥当季利用率（%）
+	Efficiency NutrientAmount `json:"efficiency"`
+	// Correction 土壤有效养分校正系数
+	Correction NutrientAmount `json:"correction"`
+	IsCurrent  bool           `json:"isCurrent"`
+	Note       string         `json:"note"`
+	CreatedBy  *int           `json:"createdBy"`
+	CreatedAt  string         `json:"created_at"`
+}
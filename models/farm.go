@@ -0,0 +1,72 @@
+package models
+
+import "encoding/json"
+
+// Farm 农场，地块按农场归类
+type Farm struct {
+	ID        int     `json:"id"`
+	UserID    int     `json:"userId"`
+	Name      string  `json:"name" binding:"required"`
+	Location  string  `json:"location"`
+	Area      float64 `json:"area" binding:"gte=0"` // 面积（亩）
+	Notes     string  `json:"notes"`
+	PlotCount int     `json:"plotCount"`
+	CreatedAt string  `json:"createdAt"`
+	UpdatedAt string  `json:"updatedAt"`
+	// Plots 农场的地块，仅在查询单个农场时返回
+	Plots []Plot `json:"plots,omitempty"`
+}
+
+// Plot 地块，测土配肥、灌溉、堆肥记录与施肥计划通过 plotId 关联到地块
+type Plot struct {
+	ID       int     `json:"id"`
+	UserID   int     `json:"userId"`
+	FarmID   int     `json:"farmId" binding:"required"`
+	FarmName string  `json:"farmName"`
+	Name     string  `json:"name" binding:"required"`
+	Area     float64 `json:"area" binding:"gte=0"` // 面积（亩）
+	// Boundary 地块边界，GeoJSON Polygon、MultiPolygon 或包含二者的 Feature，服务端统一保存为 WGS84 坐标；
+	// 提供边界时 Area 由边界计算，忽略请求中的面积
+	Boundary json.RawMessage `json:"boundary,omitempty"`
+	// CoordinateSystem 边界坐标系 wgs84（默认）或 gcj02（高德、腾讯等国内地图）
+	CoordinateSystem string    `json:"coordinateSystem,omitempty" binding:"omitempty,oneof=wgs84 gcj02"`
+	AreaHectares     float64   `json:"areaHectares"` // 面积（公顷）
+	Centroid         *GeoPoint `json:"centroid,omitempty"`
+	SoilType         string    `json:"soilType"`    // 土壤质地，与灌溉土壤参考表的名称一致
+	CurrentCrop      string    `json:"currentCrop"` // 当前种植作物
+	Notes            string    `json:"notes"`
+	CreatedAt        string    `json:"createdAt"`
+	UpdatedAt        string    `json:"updatedAt"`
+}
+
+// GeoPoint 经纬度坐标（°）
+type GeoPoint struct {
+	Lng float64 `json:"lng"`
+	Lat float64 `json:"lat"`
+}
+
+// PlotSoilSummary 地块最近一次测土配肥记录摘要
+type PlotSoilSummary struct {
+	RecordID      int            `json:"recordId"`
+	Timestamp     string         `json:"timestamp"`
+	Crop          string         `json:"crop"`
+	Supplement    NutrientAmount `json:"supplement"`
+	PH            *float64       `json:"ph"`
+	OrganicMatter *float64       `json:"organicMatter"`
+}
+
+// PlotIrrigationSummary 地块最近一次灌溉记录摘要
+type PlotIrrigationSummary struct {
+	RecordID       int     `json:"recordId"`
+	CreatedAt      string  `json:"createdAt"`
+	IrrigationMode string  `json:"irrigationMode"`
+	CropType       string  `json:"cropType"`
+	WaterAmount    float64 `json:"waterAmount"` // 各区域灌水量合计（m³）
+}
+
+// PlotMapItem 地图范围查询返回的地块及其最近的测土、灌溉摘要
+type PlotMapItem struct {
+	Plot
+	LatestSoil       *PlotSoilSummary       `json:"latestSoil"`
+	LatestIrrigation *PlotIrrigationSummary `json:"latestIrrigation"`
+}
@@ -0,0 +1,119 @@
+package models
+
+// 施肥计划状态
+const (
+	PlanStatusActive    = "active"    // 执行中
+	PlanStatusCompleted = "completed" // 已完成
+)
+
+// 计划施肥次的执行状态
+const (
+	PlanItemPlanned = "planned" // 待施用
+	PlanItemApplied = "applied" // 已施用
+	PlanItemOverdue = "overdue" // 已过计划日期未施用
+)
+
+// CropGrowthStage 作物施肥时期，Share 为该时期施用的养分占全季化肥养分的比例（%）
+type CropGrowthStage struct {
+	ID              int            `json:"id"`
+	CropCode        string         `json:"cropCode"`
+	StageCode       string         `json:"stageCode" binding:"required"`
+	StageName       string         `json:"stageName" binding:"required"`
+	Sequence        int            `json:"sequence"`
+	DaysAfterSowing int            `json:"daysAfterSowing" binding:"gte=0"` // 距播种（定植）的天数
+	Basal           bool           `json:"basal"`                           // 是否为基肥
+	Share           NutrientAmount `json:"share"`
+}
+
+// FertilizationPlanRequest 生成施肥计划的请求
+type FertilizationPlanRequest struct {
+	// RecordID 测土配肥记录，设置后默认以记录的作物、面积与补充量生成计划
+	RecordID   int     `json:"recordId"`
+	Name       string  `json:"name"`
+	Crop       string  `json:"crop"`
+	Season     string  `json:"season"`
+	SowingDate string  `json:"sowingDate" binding:"required"` // 播种（定植）日期 YYYY-MM-DD
+	PlotSize   float64 `json:"plotSize"`
+	// PlotID 关联的地块，未填写的作物与面积取地块信息；引用的记录已关联地块时默认沿用
+	PlotID int `json:"plotId"`
+	// Target 全季需由化肥补充的纯养分量（kg），默认取测土配肥记录的补充量
+	Target *NutrientAmount `json:"target"`
+	// ProductIDs 可选用的化肥产品，为空时使用全部产品
+	ProductIDs []int `json:"productIds"`
+	// Stages 自定义施肥时期，为空时使用作物默认施肥时期
+	Stages []CropGrowthStage `json:"stages" binding:"dive"`
+	Notes  string            `json:"notes"`
+}
+
+// FertilizationPlan 一季作物的施肥计划
+type FertilizationPlan struct {
+	ID           int                        `json:"id"`
+	UserID       int                        `json:"userId"`
+	RecordID     int                        `json:"recordId"`
+	PlotID       int                        `json:"plotId"`
+	Name         string                     `json:"name"`
+	Crop         string                     `json:"crop"`
+	Season       string                     `json:"season"`
+	SowingDate   string                     `json:"sowingDate"`
+	PlotSize     float64                    `json:"plotSize"`
+	Target       NutrientAmount             `json:"target"`
+	Status       string                     `json:"status"`
+	Notes        string                     `json:"notes"`
+	CreatedAt    string                     `json:"createdAt"`
+	Items        []FertilizationPlanItem    `json:"items,omitempty"`
+	Applications []FertilizationApplication `json:"applications,omitempty"`
+	Budget       *FertilizationBudget       `json:"budget,omitempty"`
+	// Warnings 生成计划时未能配出产品的时期等提示
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// FertilizationPlanItem 计划中的一次施肥，未选定产品时 ProductID 为 0，只给出纯养分量
+type FertilizationPlanItem struct {
+	ID          int            `json:"id"`
+	PlanID      int            `json:"planId"`
+	StageCode   string         `json:"stageCode"`
+	StageName   string         `json:"stageName"`
+	Sequence    int            `json:"sequence"`
+	PlannedDate string         `json:"plannedDate"`
+	ProductID   int            `json:"productId"`
+	ProductName string         `json:"productName"`
+	Dose        float64        `json:"dose"` // 整个地块用量（kg）
+	Nutrients   NutrientAmount `json:"nutrients"`
+	Status      string         `json:"status"`
+	Applied     NutrientAmount `json:"applied"` // 关联的实际施用养分量
+}
+
+// FertilizationPlanItemUpdate 调整计划施肥次的日期、产品与用量
+type FertilizationPlanItemUpdate struct {
+	PlannedDate string  `json:"plannedDate" binding:"required"`
+	ProductID   int     `json:"productId"`
+	Dose        float64 `json:"dose" binding:"gte=0"`
+	// Nutrients 未选定产品时的纯养分量（kg）
+	Nutrients NutrientAmount `json:"nutrients"`
+}
+
+// FertilizationApplication 实际施肥记录，ItemID 为 0 表示计划外施用
+type FertilizationApplication struct {
+	ID          int     `json:"id"`
+	PlanID      int     `json:"planId"`
+	ItemID      int     `json:"itemId"`
+	AppliedAt   string  `json:"appliedAt"` // 施用日期 YYYY-MM-DD，默认当天
+	ProductID   int     `json:"productId"`
+	ProductName string  `json:"productName"`
+	Dose        float64 `json:"dose" binding:"gt=0"` // 整个地块用量（kg）
+	// Content 未引用化肥产品时的养分含量（%）
+	Content   *NutrientAmount `json:"content,omitempty"`
+	Nutrients NutrientAmount  `json:"nutrients"`
+	Note      string          `json:"note"`
+	CreatedAt string          `json:"createdAt"`
+}
+
+// FertilizationBudget 全季养分预算，Remaining 为目标减去已施用量，超施时为负
+type FertilizationBudget struct {
+	Target    NutrientAmount `json:"target"`
+	Planned   NutrientAmount `json:"planned"`
+	Applied   NutrientAmount `json:"applied"`
+	Remaining NutrientAmount `json:"remaining"`
+	// Progress 已施用量占目标的比例（%），目标为 0 的养分为 0
+	Progress NutrientAmount `json:"progress"`
+}
@@ -0,0 +1,58 @@
+package models
+
+// 化肥产品施用方式
+const (
+	FertilizerUsageBasal       = "basal"       // 仅作基肥
+	FertilizerUsageTopdressing = "topdressing" // 仅作追肥
+	FertilizerUsageBoth        = "both"        // 基肥、追肥均可
+)
+
+// FertilizerProduct 化肥产品库模型，养分含量为百分比，UserID 为空表示系统默认产品
+type FertilizerProduct struct {
+	ID          int     `json:"id"`
+	UserID      *int    `json:"userId"`
+	Name        string  `json:"name" binding:"required"`
+	N           float64 `json:"n"`
+	P2O5        float64 `json:"p2o5"`
+	K2O         float64 `json:"k2o"`
+	PackageSize float64 `json:"packageSize"` // 每袋净含量（kg）
+	Price       float64 `json:"price"`       // 每袋价格（元）
+	Usage       string  `json:"usage" binding:"omitempty,oneof=basal topdressing both"`
+	Description string  `json:"description"`
+	IsSystem    bool    `json:"isSystem"`
+	CreatedAt   string  `json:"createdAt"`
+}
+
+// FertilizerBlendRequest 化肥最低成本配方请求，Target 为需补充的纯养分量（kg）
+type FertilizerBlendRequest struct {
+	// RecordID 测土配肥记录，设置后以记录的补充量作为目标
+	RecordID int            `json:"recordId"`
+	Target   NutrientAmount `json:"target"`
+	// ProductIDs 可选用的产品，为空时使用全部产品
+	ProductIDs []int `json:"productIds"`
+	// Tolerance 养分总量允许超出目标的比例（%），默认 10
+	Tolerance float64 `json:"tolerance"`
+	// BasalShare 各养分作基肥施用的比例（%），默认 N 40、P2O5 100、K2O 60，其余作追肥
+	BasalShare *NutrientAmount `json:"basalShare"`
+}
+
+// FertilizerDose 单个产品的施用量
+type FertilizerDose struct {
+	ProductID int            `json:"productId"`
+	Name      string         `json:"name"`
+	Weight    float64        `json:"weight"` // 用量（kg）
+	Bags      float64        `json:"bags"`   // 折合袋数
+	Cost      float64        `json:"cost"`   // 费用（元）
+	Nutrients NutrientAmount `json:"nutrients"`
+}
+
+// FertilizerBlendResult 化肥最低成本配方结果
+type FertilizerBlendResult struct {
+	Feasible    bool             `json:"feasible"`
+	Target      NutrientAmount   `json:"target"`
+	Supplied    NutrientAmount   `json:"supplied"`
+	TotalCost   float64          `json:"totalCost"`
+	Basal       []FertilizerDose `json:"basal"`
+	Topdressing []FertilizerDose `json:"topdressing"`
+	Reasons     []string         `json:"reasons,omitempty"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 // IrrigationData 灌溉数据记录模型
 type IrrigationData struct {
 	ID                  int64   `json:"id"`
@@ -40,6 +42,8 @@ type AreaData struct {
 	MoisturePoints      []MoisturePoint `json:"moisturePoints"`
 	PlotSize            float64         `json:"plotSize"`
 	FlowRate            float64         `json:"flowRate"`
+	// FertilizerAmount 施肥罐中的肥料用量（kg），用于浓度校验
+	FertilizerAmount float64 `json:"fertilizerAmount,omitempty"`
 }
 
 // WaterRecord 水记录模型
@@ -54,6 +58,7 @@ type WaterRecord struct {
 	SoilType        string      `json:"soilType"`
 	FieldCapacity   float64     `json:"fieldCapacity"`
 	SoilDensity     float64     `json:"soilDensity"`
+	PlotID          int         `json:"plotId"`
 	CreatedAt       string      `json:"created_at"`
 	Areas           []WaterArea `json:"areas"`
 }
@@ -79,3 +84,111 @@ type WaterArea struct {
 type MoisturePoint struct {
 	Value float64 `json:"value"`
 }
+
+// IrrigationRequest 灌溉计算与保存请求
+type IrrigationRequest struct {
+	AreaNum         int        `json:"areaNum"`
+	IrrigationMode  string     `json:"irrigationMode"`
+	Efficiency      float64    `json:"efficiency"`
+	CropType        string     `json:"cropType"`
+	Depth           float64    `json:"depth"`
+	OptimalMoisture float64    `json:"optimalMoisture"`
+	SoilType        string     `json:"soilType"`
+	FieldCapacity   float64    `json:"fieldCapacity"`
+	SoilDensity     float64    `json:"soilDensity"`
+	Areas           []AreaData `json:"areas"`
+	// GrowthStage 作物生育阶段，用于从作物参考表取计划湿润层深度，默认生育中期
+	GrowthStage string `json:"growthStage,omitempty"`
+	// PlotID 关联的地块，设置后未填写的作物与土壤质地取地块信息
+	PlotID int `json:"plotId"`
+	// MachineCodeID 田间传感器设备，设置后未填写水分点的区域取该设备同一区域的最新读数
+	MachineCodeID int `json:"machineCodeId"`
+}
+
+// IrrigationAreaResult 单个区域的灌溉需水量计算结果
+type IrrigationAreaResult struct {
+	AreaId          int     `json:"areaId"`
+	AverageMoisture float64 `json:"averageMoisture"` // 各水分点平均含水量（%）
+	TargetMoisture  float64 `json:"targetMoisture"`  // 目标含水量（%），田间持水量 × 适宜含水率
+	Deficit         float64 `json:"deficit"`         // 含水量亏缺（%），为负表示土壤偏湿
+	WaterAmount     float64 `json:"waterAmount"`     // 灌水量（m³）
+	IrrigationTime  string  `json:"irrigationTime"`  // 灌溉时长（h）
+	Negative        bool    `json:"negative"`        // 土壤含水量已超过目标，无需灌溉
+}
+
+// FertigationRequest 水肥一体化注肥计划请求，区域未提供灌溉时长时按土壤参数计算
+type FertigationRequest struct {
+	IrrigationRequest
+	MaxPPM float64 `json:"maxPpm"` // 允许的最高肥液浓度（mg/L），默认 3000
+	MaxEC  float64 `json:"maxEc"`  // 允许的最高电导率（mS/cm），默认 3.0
+}
+
+// FertigationPlan 单个区域的注肥计划，时间均为自灌溉开始起算的小时数
+type FertigationPlan struct {
+	AreaId              int      `json:"areaId"`
+	IrrigationTime      float64  `json:"irrigationTime"`      // 灌溉总时长（h）
+	FertilizerStartTime float64  `json:"fertilizerStartTime"` // 注肥开始时间（h），此前为清水湿润
+	FertilizerEndTime   float64  `json:"fertilizerEndTime"`   // 注肥窗口结束时间（h），此后为清水冲洗
+	FertilizerTotalTime float64  `json:"fertilizerTotalTime"` // 排空施肥罐所需时长（h）
+	WindowTime          float64  `json:"windowTime"`          // 注肥窗口时长（h）
+	PPM                 float64  `json:"ppm"`                 // 肥液浓度（mg/L）
+	EC                  float64  `json:"ec"`                  // 估算电导率（mS/cm）
+	TankEmptied         bool     `json:"tankEmptied"`         // 施肥罐能否在注肥窗口内排空
+	ConcentrationOK     bool     `json:"concentrationOk"`     // 浓度是否在允许范围内
+	Warnings            []string `json:"warnings,omitempty"`
+}
+
+// RotationRequest 多区域轮灌排程请求，区域未提供灌水量时按土壤参数计算
+type RotationRequest struct {
+	IrrigationRequest
+	PumpCapacity float64 `json:"pumpCapacity" binding:"required,gt=0"` // 水泵（首部）最大流量（m³/h）
+	StartDate    string  `json:"startDate"`                            // 排程开始日期 YYYY-MM-DD，默认当天
+	WindowStart  string  `json:"windowStart"`                          // 每日灌溉窗口开始时间 HH:MM，默认 06:00
+	WindowEnd    string  `json:"windowEnd"`                            // 每日灌溉窗口结束时间 HH:MM，默认 18:00，早于开始时间表示跨夜
+}
+
+// RotationSegment 区域一段连续的灌溉时间
+type RotationSegment struct {
+	StartTime string  `json:"startTime"`
+	EndTime   string  `json:"endTime"`
+	Duration  float64 `json:"duration"` // 时长（h）
+}
+
+// RotationArea 单个区域的轮灌安排，灌溉跨越多个窗口时分为多段
+type RotationArea struct {
+	AreaId         int               `json:"areaId"`
+	WaterAmount    float64           `json:"waterAmount"`    // 灌水量（m³）
+	FlowRate       float64           `json:"flowRate"`       // 区域流量（m³/h）
+	IrrigationTime float64           `json:"irrigationTime"` // 灌溉时长（h）
+	StartTime      string            `json:"startTime,omitempty"`
+	EndTime        string            `json:"endTime,omitempty"`
+	Segments       []RotationSegment `json:"segments"`
+}
+
+// RotationPlan 轮灌排程结果
+type RotationPlan struct {
+	PumpCapacity float64        `json:"pumpCapacity"`
+	StartTime    string         `json:"startTime"`
+	EndTime      string         `json:"endTime"`
+	CycleLength  float64        `json:"cycleLength"` // 轮灌周期总长（h），自首个区域开始至最后一个区域结束
+	PumpHours    float64        `json:"pumpHours"`   // 水泵实际运行时长（h）
+	PeakFlow     float64        `json:"peakFlow"`    // 同时运行区域的最大总流量（m³/h）
+	Days         int            `json:"days"`        // 占用的灌溉窗口数
+	Areas        []RotationArea `json:"areas"`
+}
+
+// 灌溉记录编辑历史操作类型
+const (
+	WaterRecordActionUpdate = "update"
+	WaterRecordActionDelete = "delete"
+)
+
+// WaterRecordHistory 灌溉记录编辑历史，Snapshot 为修改或删除前的完整记录
+type WaterRecordHistory struct {
+	ID        int             `json:"id"`
+	RecordID  int             `json:"recordId"`
+	Action    string          `json:"action"`
+	Snapshot  json.RawMessage `json:"snapshot"`
+	Changes   []string        `json:"changes"`
+	CreatedAt string          `json:"created_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+// 作物生育阶段
+const (
+	GrowthStageInitial     = "initial"     // 初始期
+	GrowthStageDevelopment = "development" // 快速发育期
+	GrowthStageMid         = "mid"         // 生育中期
+	GrowthStageLate        = "late"        // 成熟期
+)
+
+// SoilTexture 土壤质地参考参数，含水量均为质量含水量（%）
+type SoilTexture struct {
+	ID               int     `json:"id"`
+	Code             string  `json:"code"`
+	Name             string  `json:"name"`
+	FieldCapacity    float64 `json:"fieldCapacity"`
+	WiltingPoint     float64 `json:"wiltingPoint"`
+	BulkDensity      float64 `json:"bulkDensity"`      // 容重（g/cm³）
+	InfiltrationRate float64 `json:"infiltrationRate"` // 稳定入渗率（mm/h）
+	Description      string  `json:"description"`
+}
+
+// Crop 作物灌溉参考参数
+type Crop struct {
+	ID                   int     `json:"id"`
+	Code                 string  `json:"code"`
+	Name                 string  `json:"name"`
+	RootDepthInitial     float64 `json:"rootDepthInitial"` // 各生育阶段计划湿润层深度（cm）
+	RootDepthDevelopment float64 `json:"rootDepthDevelopment"`
+	RootDepthMid         float64 `json:"rootDepthMid"`
+	RootDepthLate        float64 `json:"rootDepthLate"`
+	OptimalMoistureMin   float64 `json:"optimalMoistureMin"` // 适宜含水率下限（占田间持水量 %）
+	OptimalMoistureMax   float64 `json:"optimalMoistureMax"` // 适宜含水率上限（占田间持水量 %）
+	KcInitial            float64 `json:"kcInitial"`          // FAO-56 初始期、生育中期、生育末期作物系数
+	KcMid                float64 `json:"kcMid"`
+	KcEnd                float64 `json:"kcEnd"`
+	StageInitialDays     int     `json:"stageInitialDays"` // 初始期、快速发育期、生育中期、成熟期的天数
+	StageDevelopmentDays int     `json:"stageDevelopmentDays"`
+	StageMidDays         int     `json:"stageMidDays"`
+	StageLateDays        int     `json:"stageLateDays"`
+	Description          string  `json:"description"`
+}
+
+// RootDepth 指定生育阶段的计划湿润层深度，未知阶段按生育中期计
+func (c Crop) RootDepth(stage string) float64 {
+	switch stage {
+	case GrowthStageInitial:
+		return c.RootDepthInitial
+	case GrowthStageDevelopment:
+		return c.RootDepthDevelopment
+	case GrowthStageLate:
+		return c.RootDepthLate
+	default:
+		return c.RootDepthMid
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+// 传感器数据聚合粒度
+const (
+	SensorGranularityRaw  = "raw"
+	SensorGranularityHour = "hour"
+	SensorGranularityDay  = "day"
+)
+
+// SensorReading 土壤水分传感器读数
+type SensorReading struct {
+	ID            int64    `json:"id"`
+	MachineCodeID int      `json:"machineCodeId"`
+	AreaID        int      `json:"areaId"`
+	Depth         float64  `json:"depth"`                       // 埋设深度（cm）
+	Moisture      *float64 `json:"moisture" binding:"required"` // 土壤含水量（%）
+	Temperature   *float64 `json:"temperature"`                 // 土壤温度（℃）
+	RecordedAt    string   `json:"recordedAt"`                  // 采集时间，为空时取服务器接收时间
+}
+
+// SensorIngestRequest 设备上报读数请求
+type SensorIngestRequest struct {
+	Readings []SensorReading `json:"readings" binding:"required,min=1,dive"`
+}
+
+// SensorSeriesPoint 传感器时间序列数据点，原始数据的最小、最大值与平均值相同
+type SensorSeriesPoint struct {
+	Time        string  `json:"time"`
+	Moisture    float64 `json:"moisture"`
+	MinMoisture float64 `json:"minMoisture"`
+	MaxMoisture float64 `json:"maxMoisture"`
+	SampleCount int     `json:"sampleCount"`
+}
+
+// SensorSeries 单个区域、深度的传感器时间序列
+type SensorSeries struct {
+	MachineCodeID int                 `json:"machineCodeId"`
+	AreaID        int                 `json:"areaId"`
+	Depth         float64             `json:"depth"`
+	Granularity   string              `json:"granularity"`
+	Points        []SensorSeriesPoint `json:"points"`
+}
@@ -13,7 +13,15 @@ type Soil struct {
 	OrganicFertilizer struct {
 		Name   string  `json:"name"`
 		Amount float64 `json:"amount"`
+		// CompostID 引用的堆肥记录，设置后按成品养分估算扣减补充量
+		CompostID int `json:"compostId"`
 	} `json:"organicFertilizer"`
+	// OrganicCredit 有机肥当季可提供的养分，已从 Supplement 中扣除
+	OrganicCredit struct {
+		N    float64 `json:"n"`
+		P2O5 float64 `json:"p2o5"`
+		K2O  float64 `json:"k2o"`
+	} `json:"organicCredit"`
 	FertilizerDemand struct {
 		N    float64 `json:"n"`
 		P2O5 float64 `json:"p2o5"`
@@ -54,4 +62,74 @@ type Soil struct {
 		Weight float64 `json:"weight"`
 	} `json:"potassiumBasic"`
 	CustomRatios string `json:"customRatios"`
+	// SoilTest 土壤养分测试值，提交时由服务端按目标产量法重新计算需肥量、供肥量与补充量
+	SoilTest      *SoilTest `json:"soilTest,omitempty"`
+	TargetYield   float64   `json:"targetYield"`
+	YieldIncrease *float64  `json:"yieldIncrease,omitempty"`
+	// CoefficientID 计算时使用的作物养分系数版本
+	CoefficientID int `json:"coefficientId"`
+	// SampleID 引用的土壤样品，未提供 SoilTest、Properties 时取样品的化验值
+	SampleID int `json:"sampleId"`
+	// PlotID 关联的地块，设置后未填写的地点、作物与面积取地块信息
+	PlotID int `json:"plotId"`
+	// Properties 土壤理化性质实测值，Region 为分级指标所属区域，为空时使用全国默认指标
+	Properties *SoilProperties `json:"properties,omitempty"`
+	Region     string          `json:"region"`
+	// Interpretation、Amendments 按当前分级指标给出的分级结果与改良建议，随记录返回，不保存
+	Interpretation []SoilInterpretation `json:"interpretation,omitempty"`
+	Amendments     []SoilAmendment      `json:"amendments,omitempty"`
+}
+
+// NutrientAmount 氮、磷、钾养分量
+type NutrientAmount struct {
+	N    float64 `json:"n"`
+	P2O5 float64 `json:"p2o5"`
+	K2O  float64 `json:"k2o"`
+}
+
+// SoilTest 土壤养分测试值（mg/kg）
+type SoilTest struct {
+	AlkaliN    float64 `json:"alkaliN" binding:"gte=0"`    // 碱解氮
+	OlsenP     float64 `json:"olsenP" binding:"gte=0"`     // 有效磷（Olsen 法）
+	AvailableK float64 `json:"availableK" binding:"gte=0"` // 速效钾
+}
+
+// SoilCalculateRequest 目标产量法（养分平衡法）施肥量计算请求
+type SoilCalculateRequest struct {
+	Crop              string   `json:"crop" binding:"required"`
+	AverageYield      float64  `json:"averageYield" binding:"required,gt=0"` // 前三年平均亩产（kg/亩）
+	PlotSize          float64  `json:"plotSize" binding:"required,gt=0"`     // 面积（亩）
+	YieldIncrease     *float64 `json:"yieldIncrease"`                        // 目标产量较平均亩产的增幅（%），默认 10
+	SoilTest          SoilTest `json:"soilTest"`
+	OrganicFertilizer struct {
+		Name   string  `json:"name"`
+		Amount float64 `json:"amount"`
+		// CompostID 引用的堆肥记录，设置后按成品养分估算扣减补充量
+		CompostID int `json:"compostId"`
+	} `json:"organicFertilizer"`
+	// CoefficientID 指定作物养分系数版本，默认使用作物的当前版本
+	CoefficientID int `json:"coefficientId"`
+	// SampleID 引用的土壤样品，设置后以样品的化验值作为 SoilTest
+	SampleID int `json:"sampleId"`
+	// Uptake 每 100 kg 经济产量的养分吸收量（kg），默认取作物养分系数
+	Uptake *NutrientAmount `json:"uptake"`
+	// Efficiency 肥料当季利用率（%），默认取作物养分系数，无系数时为 N 35、P2O5 20、K2O 45
+	Efficiency *NutrientAmount `json:"efficiency"`
+	// Correction 土壤有效养分校正系数，默认取作物养分系数，无系数时为 N 0.6、P2O5 0.5、K2O 0.5
+	Correction *NutrientAmount `json:"correction"`
+}
+
+// SoilCalculation 目标产量法施肥量计算结果，养分量为整个地块的纯养分量（kg）
+type SoilCalculation struct {
+	TargetYield float64 `json:"targetYield"` // 目标亩产（kg/亩）
+	// CoefficientID、CoefficientVersion 使用的作物养分系数版本，自定义吸收量时为 0
+	CoefficientID      int            `json:"coefficientId"`
+	CoefficientVersion int            `json:"coefficientVersion"`
+	Uptake             NutrientAmount `json:"uptake"`
+	Efficiency         NutrientAmount `json:"efficiency"`
+	Correction         NutrientAmount `json:"correction"`
+	FertilizerDemand   NutrientAmount `json:"fertilizerDemand"` // 目标产量需养分量
+	TotalSupply        NutrientAmount `json:"totalSupply"`      // 土壤供养分量
+	OrganicCredit      NutrientAmount `json:"organicCredit"`    // 有机肥当季供养分量
+	Supplement         NutrientAmount `json:"supplement"`       // 需由化肥补充的养分量
 }
@@ -0,0 +1,85 @@
+package models
+
+// 土壤测试值分级
+const (
+	SoilGradeVeryLow  = "very_low"
+	SoilGradeLow      = "low"
+	SoilGradeMedium   = "medium"
+	SoilGradeHigh     = "high"
+	SoilGradeVeryHigh = "very_high"
+)
+
+// 土壤改良建议类型
+const (
+	SoilAmendmentLime          = "lime"
+	SoilAmendmentGypsum        = "gypsum"
+	SoilAmendmentMicronutrient = "micronutrient"
+	SoilAmendmentSalinity      = "salinity"
+)
+
+// SoilProperties 土壤理化性质实测值，未测项为空
+type SoilProperties struct {
+	PH            *float64 `json:"ph"`
+	OrganicMatter *float64 `json:"organicMatter"` // 有机质（g/kg）
+	EC            *float64 `json:"ec"`            // 饱和泥浆浸提液电导率（dS/m）
+	CEC           *float64 `json:"cec"`           // 阳离子交换量（cmol(+)/kg）
+	Ca            *float64 `json:"ca"`            // 交换性钙（mg/kg）
+	Mg            *float64 `json:"mg"`            // 交换性镁（mg/kg）
+	S             *float64 `json:"s"`             // 有效硫（mg/kg）
+	Zn            *float64 `json:"zn"`            // 有效锌（mg/kg）
+	B             *float64 `json:"b"`             // 有效硼（mg/kg）
+	Fe            *float64 `json:"fe"`            // 有效铁（mg/kg）
+	Mn            *float64 `json:"mn"`            // 有效锰（mg/kg）
+}
+
+// SoilInterpretationBand 土壤性质分级指标，按区域配置，区域为空的为全国默认指标
+//
+// 测试值低于 VeryLowMax 为极低，低于 LowMax 为低，低于 MediumMax 为中，低于 HighMax 为高，其余为极高
+type SoilInterpretationBand struct {
+	ID         int     `json:"id"`
+	Region     string  `json:"region"`
+	Property   string  `json:"property" binding:"required"`
+	VeryLowMax float64 `json:"veryLowMax"`
+	LowMax     float64 `json:"lowMax"`
+	MediumMax  float64 `json:"mediumMax"`
+	HighMax    float64 `json:"highMax"`
+	Unit       string  `json:"unit"`
+	UpdatedAt  string  `json:"updatedAt"`
+}
+
+// SoilInterpretation 单项土壤性质的分级结果
+type SoilInterpretation struct {
+	Property string  `json:"property"`
+	Value    float64 `json:"value"`
+	Unit     string  `json:"unit"`
+	Grade    string  `json:"grade"`
+	// Region 使用的分级指标所属区域，为空时为全国默认指标
+	Region string `json:"region"`
+}
+
+// SoilAmendment 中微量元素与酸碱改良建议
+type SoilAmendment struct {
+	Type     string  `json:"type"`
+	Property string  `json:"property"`
+	Product  string  `json:"product"`
+	Rate     float64 `json:"rate"`   // 每亩用量（kg/亩），叶面喷施时为喷施浓度（%）
+	Amount   float64 `json:"amount"` // 整个地块用量（kg），叶面喷施时为 0
+	Method   string  `json:"method"`
+	Note     string  `json:"note"`
+}
+
+// SoilInterpretRequest 土壤性质分级与改良建议请求
+type SoilInterpretRequest struct {
+	Region     string         `json:"region"`
+	PlotSize   float64        `json:"plotSize"` // 面积（亩），为 0 时只给出每亩用量
+	Properties SoilProperties `json:"properties"`
+	// SampleID 引用的土壤样品，设置后以样品的化验值补全未提供的性质
+	SampleID int `json:"sampleId"`
+}
+
+// SoilInterpretResult 土壤性质分级与改良建议
+type SoilInterpretResult struct {
+	Properties     SoilProperties       `json:"properties"`
+	Interpretation []SoilInterpretation `json:"interpretation"`
+	Amendments     []SoilAmendment      `json:"amendments"`
+}
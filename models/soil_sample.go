@@ -0,0 +1,49 @@
+package models
+
+// SoilSample 土壤样品化验结果，统一换算为有机质 g/kg、电导率 dS/m、阳离子交换量 cmol(+)/kg、其余 mg/kg
+type SoilSample struct {
+	ID            int      `json:"id"`
+	UserID        int      `json:"userId"`
+	SampleCode    string   `json:"sampleCode"` // 样品编号，同一用户内唯一
+	Location      string   `json:"location"`
+	SampledAt     string   `json:"sampledAt"` // 采样日期 YYYY-MM-DD
+	PH            *float64 `json:"ph"`
+	OrganicMatter *float64 `json:"organicMatter"` // 有机质（g/kg）
+	AlkaliN       *float64 `json:"alkaliN"`       // 碱解氮
+	OlsenP        *float64 `json:"olsenP"`        // 有效磷（以 P 计）
+	AvailableK    *float64 `json:"availableK"`    // 速效钾（以 K 计）
+	EC            *float64 `json:"ec"`            // 电导率（dS/m）
+	CEC           *float64 `json:"cec"`           // 阳离子交换量（cmol(+)/kg）
+	Ca            *float64 `json:"ca"`            // 交换性钙
+	Mg            *float64 `json:"mg"`            // 交换性镁
+	S             *float64 `json:"s"`             // 有效硫
+	Fe            *float64 `json:"fe"`            // 有效铁
+	Mn            *float64 `json:"mn"`            // 有效锰
+	Cu            *float64 `json:"cu"`            // 有效铜
+	Zn            *float64 `json:"zn"`            // 有效锌
+	B             *float64 `json:"b"`             // 有效硼
+	Mo            *float64 `json:"mo"`            // 有效钼
+	SourceFile    string   `json:"sourceFile"`    // 导入来源文件名
+	CreatedAt     string   `json:"createdAt"`
+}
+
+// SoilSampleImportError 导入时单行单列的校验错误，Row 为文件中的行号（从 1 开始）
+type SoilSampleImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+// SoilSampleImportResult 化验报告导入结果
+type SoilSampleImportResult struct {
+	DryRun   bool `json:"dryRun"`
+	Total    int  `json:"total"`    // 数据行数
+	Imported int  `json:"imported"` // 新增的样品数
+	Updated  int  `json:"updated"`  // 覆盖的已有样品数
+	Failed   int  `json:"failed"`   // 校验失败的行数
+	// Columns 各字段实际对应的表头，Units 各字段使用的单位
+	Columns map[string]string       `json:"columns"`
+	Units   map[string]string       `json:"units"`
+	Errors  []SoilSampleImportError `json:"errors"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// TimelineEvent 地块时间线事件，按 Type 只填充对应的记录
+type TimelineEvent struct {
+	Type       string          `json:"type"` // soil（测土配肥）、irrigation（灌溉）、compost（堆肥）
+	ID         int             `json:"id"`
+	PlotID     int             `json:"plotId"`
+	OccurredAt string          `json:"occurredAt"`
+	Soil       *Soil           `json:"soil,omitempty"`
+	Irrigation *WaterRecord    `json:"irrigation,omitempty"`
+	Compost    *CompostHistory `json:"compost,omitempty"`
+}
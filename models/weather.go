@@ -0,0 +1,75 @@
+package models
+
+// WeatherDay 逐日气象数据，可选观测项为空时按 FAO-56 推荐方法估算
+type WeatherDay struct {
+	ID            int      `json:"id"`
+	Station       string   `json:"station"`
+	Date          string   `json:"date" binding:"required"`
+	TMax          float64  `json:"tMax"`
+	TMin          float64  `json:"tMin"`
+	RHMax         *float64 `json:"rhMax"`
+	RHMin         *float64 `json:"rhMin"`
+	RHMean        *float64 `json:"rhMean"`
+	WindSpeed     *float64 `json:"windSpeed"`
+	Radiation     *float64 `json:"radiation"`
+	SunshineHours *float64 `json:"sunshineHours"`
+	Rainfall      float64  `json:"rainfall"`
+	ET0           float64  `json:"et0"`
+}
+
+// WeatherUploadRequest 气象数据上传请求
+type WeatherUploadRequest struct {
+	Station    string       `json:"station" binding:"required"`
+	Latitude   float64      `json:"latitude"`
+	Altitude   float64      `json:"altitude"`
+	WindHeight float64      `json:"windHeight"` // 风速观测高度（m），默认 2 m
+	Days       []WeatherDay `json:"days" binding:"required,dive"`
+}
+
+// IrrigationScheduleRequest 基于 ET0 的灌溉排程请求
+type IrrigationScheduleRequest struct {
+	RecordID     int    `json:"recordId" binding:"required"`
+	Station      string `json:"station" binding:"required"`
+	PlantingDate string `json:"plantingDate" binding:"required"`
+	StartDate    string `json:"startDate"`
+	EndDate      string `json:"endDate"`
+	CropType     string `json:"cropType"` // 为空时使用灌溉记录的作物
+}
+
+// WaterBalanceDay 逐日土壤水量平衡，水量单位均为 mm
+type WaterBalanceDay struct {
+	Date          string  `json:"date"`
+	ET0           float64 `json:"et0"`
+	Kc            float64 `json:"kc"`
+	ETc           float64 `json:"etc"`
+	Rainfall      float64 `json:"rainfall"`
+	EffectiveRain float64 `json:"effectiveRain"`
+	Irrigation    float64 `json:"irrigation"`
+	Depletion     float64 `json:"depletion"` // 根区土壤水分亏缺量
+}
+
+// IrrigationEvent 推荐的灌溉日期与灌水量
+type IrrigationEvent struct {
+	Date           string  `json:"date"`
+	NetDepth       float64 `json:"netDepth"`       // 净灌水深度（mm）
+	GrossDepth     float64 `json:"grossDepth"`     // 毛灌水深度（mm），已计入灌溉水利用系数
+	WaterAmount    float64 `json:"waterAmount"`    // 灌水量（m³）
+	IrrigationTime string  `json:"irrigationTime"` // 灌溉时长（h）
+}
+
+// PlotSchedule 单个区域的灌溉排程
+type PlotSchedule struct {
+	AreaId           int               `json:"areaId"`
+	PlotSize         float64           `json:"plotSize"`
+	ReadilyAvailable float64           `json:"readilyAvailable"` // 易利用水量（mm），亏缺达到该值时灌溉
+	Days             []WaterBalanceDay `json:"days"`
+	Events           []IrrigationEvent `json:"events"`
+}
+
+// IrrigationSchedule 灌溉排程结果
+type IrrigationSchedule struct {
+	RecordID int            `json:"recordId"`
+	CropType string         `json:"cropType"`
+	Station  string         `json:"station"`
+	Plots    []PlotSchedule `json:"plots"`
+}
@@ -16,10 +16,24 @@ func SetupRouter(db *sql.DB) *gin.Engine {
 	// 创建控制器实例
 	compostController := controllers.NewCompostController(db)
 	irrigationController := controllers.NewIrrigationController(db)
+	weatherController := controllers.NewWeatherController(db)
+	irrigationReferenceController := controllers.NewIrrigationReferenceController(db)
+	sensorController := controllers.NewSensorController(db)
+	alertController := controllers.NewAlertController(db)
 	soilController := controllers.NewSoilController(db)
 	authController := controllers.NewAuthController(db)
+	compostMaterialController := controllers.NewCompostMaterialController(db)
+	compostPileController := controllers.NewCompostPileController(db)
+	fertilizerProductController := controllers.NewFertilizerProductController(db)
+	cropCoefficientController := controllers.NewCropCoefficientController(db)
+	soilSampleController := controllers.NewSoilSampleController(db)
+	soilInterpretationController := controllers.NewSoilInterpretationController(db)
+	compostAssessmentController := controllers.NewCompostAssessmentController(db)
+	fertilizationPlanController := controllers.NewFertilizationPlanController(db)
+	farmController := controllers.NewFarmController(db)
+	timelineController := controllers.NewTimelineController(db)
 	// 机器码相关路由
-	machineController := &controllers.MachineController{db}
+	machineController := &controllers.MachineController{DB: db}
 
 	// 公共路由
 	public := r.Group("/")
@@ -34,6 +48,13 @@ func SetupRouter(db *sql.DB) *gin.Engine {
 		public.POST("/password/reset", authController.ResetPassword)
 	}
 
+	// 设备上报路由，通过机器码认证
+	device := r.Group("/device")
+	device.Use(middleware.DeviceAuthMiddleware(db))
+	{
+		device.POST("/readings", sensorController.IngestReadings)
+	}
+
 	// 需要认证的路由
 	protected := r.Group("/")
 	protected.Use(middleware.AuthMiddleware())
@@ -43,19 +64,130 @@ func SetupRouter(db *sql.DB) *gin.Engine {
 		protected.POST("/user/bind-phone", authController.BindPhone)
 
 		// 堆肥相关路由
+		protected.POST("/compost/calculate", compostController.CalculateCompost)
+		protected.POST("/compost/solve", compostController.SolveCompostRecipe)
 		protected.POST("/compost/save", compostController.SaveCompostRecord)
 		protected.GET("/compost/records", compostController.GetCompostRecords)
+		protected.GET("/compost/records/export", compostController.ExportCompostRecords)
 		protected.GET("/compost/record", compostController.GetCompostRecord)
+		protected.PUT("/compost/record", compostController.UpdateCompostRecord)
+		protected.DELETE("/compost/record", compostController.DeleteCompostRecord)
+		protected.POST("/compost/record/restore", compostController.RestoreCompostRecord)
+
+		// 堆肥物料库
+		protected.GET("/compost/materials", compostMaterialController.GetCompostMaterials)
+		protected.POST("/compost/materials", compostMaterialController.CreateCompostMaterial)
+		protected.GET("/compost/materials/:id", compostMaterialController.GetCompostMaterial)
+		protected.PUT("/compost/materials/:id", compostMaterialController.UpdateCompostMaterial)
+		protected.DELETE("/compost/materials/:id", compostMaterialController.DeleteCompostMaterial)
+
+		// 堆体全过程跟踪
+		protected.GET("/compost/piles", compostPileController.GetCompostPiles)
+		protected.POST("/compost/piles", compostPileController.CreateCompostPile)
+		protected.GET("/compost/piles/:id", compostPileController.GetCompostPile)
+		protected.PUT("/compost/piles/:id", compostPileController.UpdateCompostPile)
+		protected.DELETE("/compost/piles/:id", compostPileController.DeleteCompostPile)
+		protected.POST("/compost/piles/:id/logs", compostPileController.AddCompostPileLog)
+
+		// 腐熟度评价
+		protected.GET("/compost/maturity/standards", compostAssessmentController.GetMaturityStandards)
+		protected.POST("/compost/maturity/standards", compostAssessmentController.SaveMaturityStandard)
+		protected.POST("/compost/assessment", compostAssessmentController.CreateCompostAssessment)
 
 		// 灌溉相关路由
+		protected.POST("/irrigation/calculate", irrigationController.CalculateIrrigation)
+		protected.POST("/irrigation/fertigation", irrigationController.PlanFertigation)
+		protected.POST("/irrigation/rotation", irrigationController.PlanRotation)
 		protected.POST("/irrigation/save", irrigationController.SaveIrrigationRecord)
 		protected.GET("/irrigation/records", irrigationController.GetIrrigationRecords)
+		protected.GET("/irrigation/records/export", irrigationController.ExportIrrigationRecords)
 		protected.GET("/irrigation/record", irrigationController.GetIrrigationRecord)
+		protected.PUT("/irrigation/record", irrigationController.UpdateIrrigationRecord)
+		protected.DELETE("/irrigation/record", irrigationController.DeleteIrrigationRecord)
+		protected.GET("/irrigation/record/history", irrigationController.GetIrrigationRecordHistory)
+		protected.POST("/irrigation/schedule", irrigationController.ScheduleIrrigation)
+
+		// 土壤质地与作物参考参数
+		protected.GET("/irrigation/soil-textures", irrigationReferenceController.GetSoilTextures)
+		protected.GET("/irrigation/crops", irrigationReferenceController.GetCrops)
+
+		// 土壤水分传感器数据
+		protected.GET("/sensor/series", sensorController.GetSensorSeries)
+		protected.GET("/sensor/latest", sensorController.GetLatestMoisture)
+
+		// 灌溉告警规则与告警收件箱
+		protected.GET("/alerts/rules", alertController.GetAlertRules)
+		protected.POST("/alerts/rules", alertController.CreateAlertRule)
+		protected.PUT("/alerts/rules/:id", alertController.UpdateAlertRule)
+		protected.DELETE("/alerts/rules/:id", alertController.DeleteAlertRule)
+		protected.GET("/alerts/inbox", alertController.GetAlertInbox)
+		protected.POST("/alerts/inbox/read", alertController.MarkAlertsRead)
+
+		// 气象数据
+		protected.POST("/irrigation/weather", weatherController.UploadWeather)
+		protected.GET("/irrigation/weather", weatherController.GetWeather)
 
 		// 测土配肥相关路由
+		protected.POST("/soil/calculate", soilController.CalculateSoilFertilizer)
+		protected.POST("/soil/optimize", soilController.OptimizeFertilizerBlend)
+		protected.POST("/soil/interpret", soilController.InterpretSoilProperties)
 		protected.POST("/soil/save", soilController.SaveSoilRecord)
 		protected.GET("/soil/records", soilController.GetSoilRecords)
+		protected.GET("/soil/records/export", soilController.ExportSoilRecords)
 		protected.GET("/soil/record", soilController.GetSoilRecord)
+		protected.GET("/soil/record/recalculate", soilController.RecalculateSoilRecord)
+
+		// 土壤样品化验结果
+		protected.POST("/soil/samples/import", soilSampleController.ImportSoilSamples)
+		protected.GET("/soil/samples", soilSampleController.GetSoilSamples)
+		protected.GET("/soil/sample", soilSampleController.GetSoilSample)
+		protected.DELETE("/soil/sample", soilSampleController.DeleteSoilSample)
+
+		// 土壤性质分级指标（修改为管理员功能）
+		protected.GET("/soil/interpretation-bands", soilInterpretationController.GetInterpretationBands)
+		protected.POST("/soil/interpretation-bands", soilInterpretationController.CreateInterpretationBand)
+		protected.PUT("/soil/interpretation-bands/:id", soilInterpretationController.UpdateInterpretationBand)
+		protected.DELETE("/soil/interpretation-bands/:id", soilInterpretationController.DeleteInterpretationBand)
+
+		// 作物养分系数（修改为管理员功能）
+		protected.GET("/soil/crop-coefficients", cropCoefficientController.GetCropCoefficients)
+		protected.GET("/soil/crop-coefficients/:id", cropCoefficientController.GetCropCoefficient)
+		protected.POST("/soil/crop-coefficients", cropCoefficientController.CreateCropCoefficient)
+		protected.PUT("/soil/crop-coefficients/:id", cropCoefficientController.UpdateCropCoefficient)
+		protected.DELETE("/soil/crop-coefficients/:id", cropCoefficientController.DeleteCropCoefficient)
+
+		// 化肥产品库
+		protected.GET("/soil/products", fertilizerProductController.GetFertilizerProducts)
+		protected.POST("/soil/products", fertilizerProductController.CreateFertilizerProduct)
+		protected.GET("/soil/products/:id", fertilizerProductController.GetFertilizerProduct)
+		protected.PUT("/soil/products/:id", fertilizerProductController.UpdateFertilizerProduct)
+		protected.DELETE("/soil/products/:id", fertilizerProductController.DeleteFertilizerProduct)
+
+		// 季节施肥计划
+		protected.GET("/soil/crop-stages", fertilizationPlanController.GetCropGrowthStages)
+		protected.GET("/soil/plans", fertilizationPlanController.GetFertilizationPlans)
+		protected.POST("/soil/plans", fertilizationPlanController.CreateFertilizationPlan)
+		protected.GET("/soil/plans/:id", fertilizationPlanController.GetFertilizationPlan)
+		protected.PUT("/soil/plans/:id", fertilizationPlanController.UpdateFertilizationPlan)
+		protected.DELETE("/soil/plans/:id", fertilizationPlanController.DeleteFertilizationPlan)
+		protected.PUT("/soil/plans/:id/items/:itemId", fertilizationPlanController.UpdateFertilizationPlanItem)
+		protected.POST("/soil/plans/:id/applications", fertilizationPlanController.AddFertilizationApplication)
+		protected.DELETE("/soil/plans/:id/applications/:applicationId", fertilizationPlanController.DeleteFertilizationApplication)
+
+		// 农场与地块
+		protected.GET("/farms", farmController.GetFarms)
+		protected.POST("/farms", farmController.CreateFarm)
+		protected.GET("/farms/:id", farmController.GetFarm)
+		protected.PUT("/farms/:id", farmController.UpdateFarm)
+		protected.DELETE("/farms/:id", farmController.DeleteFarm)
+		protected.GET("/plots", farmController.GetPlots)
+		protected.POST("/plots", farmController.CreatePlot)
+		protected.GET("/plots/map", farmController.GetPlotMap)
+		protected.GET("/plots/:id", farmController.GetPlot)
+		protected.PUT("/plots/:id", farmController.UpdatePlot)
+		protected.DELETE("/plots/:id", farmController.DeletePlot)
+		protected.GET("/plots/:id/timeline", timelineController.GetPlotTimeline)
+		protected.GET("/timeline", timelineController.GetTimeline)
 
 		//机器码
 		protected.POST("/machine/create", machineController.CreateMachineCode)
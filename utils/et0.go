@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"errors"
+	"math"
+)
+
+// ET0Input 计算参考作物蒸散量所需的逐日气象数据
+type ET0Input struct {
+	DayOfYear     int      // 日序数（1~366）
+	Latitude      float64  // 纬度（°），南纬为负
+	Altitude      float64  // 海拔（m）
+	TMax          float64  // 日最高气温（℃）
+	TMin          float64  // 日最低气温（℃）
+	RHMax         *float64 // 日最大相对湿度（%）
+	RHMin         *float64 // 日最小相对湿度（%）
+	RHMean        *float64 // 日平均相对湿度（%），缺少最大、最小值时使用
+	WindSpeed     *float64 // 风速（m/s）
+	WindHeight    float64  // 风速观测高度（m），默认 2 m
+	Radiation     *float64 // 太阳辐射（MJ/m²/d）
+	SunshineHours *float64 // 日照时数（h），缺少太阳辐射时使用
+}
+
+const (
+	// solarConstant 太阳常数（MJ/m²/min）
+	solarConstant = 0.0820
+	// stefanBoltzmann 斯蒂芬-玻尔兹曼常数（MJ/K⁴/m²/d）
+	stefanBoltzmann = 4.903e-9
+	// defaultWindSpeed 缺少风速观测时采用的全球平均风速（m/s）
+	defaultWindSpeed = 2.0
+)
+
+// saturationVaporPressure 饱和水汽压（kPa）
+func saturationVaporPressure(t float64) float64 {
+	return 0.6108 * math.Exp(17.27*t/(t+237.3))
+}
+
+// ExtraterrestrialRadiation 天顶辐射 Ra（MJ/m²/d）与最大日照时数 N（h）
+func ExtraterrestrialRadiation(dayOfYear int, latitude float64) (float64, float64) {
+	phi := latitude * math.Pi / 180
+	j := float64(dayOfYear)
+	dr := 1 + 0.033*math.Cos(2*math.Pi*j/365)
+	delta := 0.409 * math.Sin(2*math.Pi*j/365-1.39)
+	ws := math.Acos(math.Max(-1, math.Min(1, -math.Tan(phi)*math.Tan(delta))))
+	ra := 24 * 60 / math.Pi * solarConstant * dr *
+		(ws*math.Sin(phi)*math.Sin(delta) + math.Cos(phi)*math.Cos(delta)*math.Sin(ws))
+	return ra, 24 / math.Pi * ws
+}
+
+// PenmanMonteithET0 按 FAO-56 Penman-Monteith 公式计算逐日参考作物蒸散量 ET0（mm/d），
+// 缺少太阳辐射时依次使用日照时数或 Hargreaves 温差法估算，缺少湿度时以最低气温代替露点温度，土壤热通量按 0 计
+func PenmanMonteithET0(in ET0Input) (float64, error) {
+	if in.DayOfYear < 1 || in.DayOfYear > 366 {
+		return 0, errors.New("day of year must be between 1 and 366")
+	}
+	if in.Latitude < -90 || in.Latitude > 90 {
+		return 0, errors.New("latitude must be between -90 and 90")
+	}
+	if in.TMax < in.TMin {
+		return 0, errors.New("maximum temperature must not be lower than minimum temperature")
+	}
+
+	tMean := (in.TMax + in.TMin) / 2
+	pressure := 101.3 * math.Pow((293-0.0065*in.Altitude)/293, 5.26)
+	gamma := 0.665e-3 * pressure
+	slope := 4098 * saturationVaporPressure(tMean) / math.Pow(tMean+237.3, 2)
+
+	// 饱和水汽压与实际水汽压
+	esMax, esMin := saturationVaporPressure(in.TMax), saturationVaporPressure(in.TMin)
+	es := (esMax + esMin) / 2
+	var ea float64
+	switch {
+	case in.RHMax != nil && in.RHMin != nil:
+		ea = (esMin**in.RHMax/100 + esMax**in.RHMin/100) / 2
+	case in.RHMean != nil:
+		ea = *in.RHMean / 100 * es
+	default:
+		ea = esMin
+	}
+
+	// 净辐射
+	ra, maxSunshine := ExtraterrestrialRadiation(in.DayOfYear, in.Latitude)
+	var rs float64
+	switch {
+	case in.Radiation != nil:
+		rs = *in.Radiation
+	case in.SunshineHours != nil && maxSunshine > 0:
+		rs = (0.25 + 0.5*math.Min(*in.SunshineHours/maxSunshine, 1)) * ra
+	default:
+		rs = 0.16 * math.Sqrt(in.TMax-in.TMin) * ra
+	}
+	rso := (0.75 + 2e-5*in.Altitude) * ra
+	rns := (1 - 0.23) * rs
+	relative := 1.0
+	if rso > 0 {
+		relative = math.Min(rs/rso, 1)
+	}
+	tMaxK, tMinK := in.TMax+273.16, in.TMin+273.16
+	rnl := stefanBoltzmann * (math.Pow(tMaxK, 4) + math.Pow(tMinK, 4)) / 2 *
+		(0.34 - 0.14*math.Sqrt(math.Max(ea, 0))) * (1.35*relative - 0.35)
+	rn := rns - rnl
+
+	// 换算为 2 m 高度风速
+	u2 := defaultWindSpeed
+	if in.WindSpeed != nil {
+		u2 = *in.WindSpeed
+		if in.WindHeight > 0 && in.WindHeight != 2 {
+			u2 = u2 * 4.87 / math.Log(67.8*in.WindHeight-5.42)
+		}
+	}
+
+	et0 := (0.408*slope*rn + gamma*900/(tMean+273)*u2*(es-ea)) / (slope + gamma*(1+0.34*u2))
+	return math.Max(et0, 0), nil
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestExtraterrestrialRadiation(t *testing.T) {
+	// FAO-56 例 8、例 9 与例 18 的天顶辐射与最大日照时数
+	tests := []struct {
+		name      string
+		dayOfYear int
+		latitude  float64
+		ra, n     float64
+	}{
+		{"example 8 and 9, 3 September at 20°S", 246, -20, 32.2, 11.7},
+		{"example 18, 6 July at Brussels", 187, 50.8, 41.09, 16.1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ra, n := ExtraterrestrialRadiation(tt.dayOfYear, tt.latitude)
+			if math.Abs(ra-tt.ra) > 0.1 {
+				t.Errorf("Ra = %.2f, want %.2f", ra, tt.ra)
+			}
+			if math.Abs(n-tt.n) > 0.1 {
+				t.Errorf("N = %.2f, want %.2f", n, tt.n)
+			}
+		})
+	}
+}
+
+func TestPenmanMonteithET0(t *testing.T) {
+	// FAO-56 例 18：布鲁塞尔 7 月 6 日，10 m 高风速 10 km/h，日照 9.25 h，ET0 = 3.9 mm/d
+	brussels := ET0Input{
+		DayOfYear: 187, Latitude: 50.8, Altitude: 100, TMax: 21.5, TMin: 12.3,
+		RHMax: float(84), RHMin: float(63), WindSpeed: float(10 / 3.6), WindHeight: 10, SunshineHours: float(9.25),
+	}
+	withRadiation := brussels
+	withRadiation.SunshineHours, withRadiation.Radiation = nil, float(22.07)
+	windAt2m := brussels
+	windAt2m.WindSpeed, windAt2m.WindHeight = float(2.078), 2
+
+	tests := []struct {
+		name      string
+		in        ET0Input
+		want      float64
+		tolerance float64
+	}{
+		{"example 18 with sunshine hours", brussels, 3.9, 0.05},
+		{"example 18 with measured radiation", withRadiation, 3.9, 0.05},
+		{"example 18 with wind already at 2 m", windAt2m, 3.9, 0.05},
+		// 缺少湿度、风速与辐射时按 Tmin 估算露点、取 2 m/s 风速并用 Hargreaves 温差法估算辐射，结果应与实测值同一量级
+		{"missing humidity, wind and radiation", ET0Input{DayOfYear: 187, Latitude: 50.8, Altitude: 100, TMax: 21.5, TMin: 12.3}, 3.9, 0.8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PenmanMonteithET0(tt.in)
+			if err != nil {
+				t.Fatalf("PenmanMonteithET0() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > tt.tolerance {
+				t.Errorf("PenmanMonteithET0() = %.3f, want %.1f ± %v", got, tt.want, tt.tolerance)
+			}
+		})
+	}
+}
+
+func TestPenmanMonteithET0Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   ET0Input
+	}{
+		{"day of year zero", ET0Input{DayOfYear: 0, TMax: 20, TMin: 10}},
+		{"day of year too large", ET0Input{DayOfYear: 367, TMax: 20, TMin: 10}},
+		{"latitude out of range", ET0Input{DayOfYear: 100, Latitude: 91, TMax: 20, TMin: 10}},
+		{"inverted temperatures", ET0Input{DayOfYear: 100, TMax: 10, TMin: 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := PenmanMonteithET0(tt.in); err == nil {
+				t.Errorf("PenmanMonteithET0() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package utils
+
+import "math"
+
+// LngLat 经纬度坐标（°），顺序与 GeoJSON 一致：经度在前，纬度在后
+type LngLat [2]float64
+
+// Polygon 多边形，第一个环为外边界，其余为内部空洞，每个环首尾坐标相同
+type Polygon [][]LngLat
+
+const (
+	// wgs84A、wgs84F WGS84 椭球长半轴（m）与扁率
+	wgs84A = 6378137.0
+	wgs84F = 1 / 298.257223563
+	// krasovskyA、krasovskyEE GCJ-02 偏移算法使用的克拉索夫斯基椭球长半轴与第一偏心率平方
+	krasovskyA  = 6378245.0
+	krasovskyEE = 0.00669342162296594323
+)
+
+// OutOfChina 判断坐标是否在中国范围之外，范围之外的坐标 GCJ-02 与 WGS84 相同
+func OutOfChina(lng, lat float64) bool {
+	return lng < 72.004 || lng > 137.8347 || lat < 0.8293 || lat > 55.8271
+}
+
+// gcj02Offset 计算 WGS84 坐标加密为 GCJ-02 时的经纬度偏移量
+func gcj02Offset(lng, lat float64) (float64, float64) {
+	x, y := lng-105, lat-35
+	dLat := -100 + 2*x + 3*y + 0.2*y*y + 0.1*x*y + 0.2*math.Sqrt(math.Abs(x))
+	dLat += (20*math.Sin(6*x*math.Pi) + 20*math.Sin(2*x*math.Pi)) * 2 / 3
+	dLat += (20*math.Sin(y*math.Pi) + 40*math.Sin(y/3*math.Pi)) * 2 / 3
+	dLat += (160*math.Sin(y/12*math.Pi) + 320*math.Sin(y*math.Pi/30)) * 2 / 3
+	dLng := 300 + x + 2*y + 0.1*x*x + 0.1*x*y + 0.1*math.Sqrt(math.Abs(x))
+	dLng += (20*math.Sin(6*x*math.Pi) + 20*math.Sin(2*x*math.Pi)) * 2 / 3
+	dLng += (20*math.Sin(x*math.Pi) + 40*math.Sin(x/3*math.Pi)) * 2 / 3
+	dLng += (150*math.Sin(x/12*math.Pi) + 300*math.Sin(x/30*math.Pi)) * 2 / 3
+
+	radLat := lat / 180 * math.Pi
+	magic := 1 - krasovskyEE*math.Sin(radLat)*math.Sin(radLat)
+	sqrtMagic := math.Sqrt(magic)
+	dLat = dLat * 180 / ((krasovskyA * (1 - krasovskyEE)) / (magic * sqrtMagic) * math.Pi)
+	dLng = dLng * 180 / (krasovskyA / sqrtMagic * math.Cos(radLat) * math.Pi)
+	return dLng, dLat
+}
+
+// WGS84ToGCJ02 将 WGS84 坐标转换为高德、腾讯等国内地图使用的 GCJ-02 坐标
+func WGS84ToGCJ02(lng, lat float64) (float64, float64) {
+	if OutOfChina(lng, lat) {
+		return lng, lat
+	}
+	dLng, dLat := gcj02Offset(lng, lat)
+	return lng + dLng, lat + dLat
+}
+
+// GCJ02ToWGS84 将 GCJ-02 坐标转换为 WGS84 坐标，迭代求逆，精度优于 1e-7°（约 1 cm）
+func GCJ02ToWGS84(lng, lat float64) (float64, float64) {
+	if OutOfChina(lng, lat) {
+		return lng, lat
+	}
+	wgsLng, wgsLat := lng, lat
+	for i := 0; i < 30; i++ {
+		gcjLng, gcjLat := WGS84ToGCJ02(wgsLng, wgsLat)
+		dLng, dLat := gcjLng-lng, gcjLat-lat
+		wgsLng -= dLng
+		wgsLat -= dLat
+		if math.Abs(dLng) < 1e-9 && math.Abs(dLat) < 1e-9 {
+			break
+		}
+	}
+	return wgsLng, wgsLat
+}
+
+// authalicY 纬度在椭球圆柱等积投影中的纵坐标（m）
+func authalicY(lat float64) float64 {
+	e2 := wgs84F * (2 - wgs84F)
+	e := math.Sqrt(e2)
+	sinLat := math.Sin(lat * math.Pi / 180)
+	q := (1 - e2) * (sinLat/(1-e2*sinLat*sinLat) - math.Log((1-e*sinLat)/(1+e*sinLat))/(2*e))
+	return wgs84A * q / 2
+}
+
+// ringSignedArea 环在等积投影中的有向面积（m²），经度相对首个顶点展开，可跨越 180° 经线
+func ringSignedArea(ring []LngLat) float64 {
+	if len(ring) < 3 {
+		return 0
+	}
+	lng0 := ring[0][0]
+	project := func(p LngLat) (float64, float64) {
+		dLng := math.Mod(p[0]-lng0+540, 360) - 180
+		return wgs84A * dLng * math.Pi / 180, authalicY(p[1])
+	}
+	var sum float64
+	for i := 0; i < len(ring)-1; i++ {
+		x1, y1 := project(ring[i])
+		x2, y2 := project(ring[i+1])
+		sum += x1*y2 - x2*y1
+	}
+	return sum / 2
+}
+
+// PolygonArea 计算 WGS84 椭球面上多边形的面积（m²），外边界面积减去空洞面积
+//
+// 顶点经椭球圆柱等积投影后按鞋带公式求面积，投影保持椭球面面积不变，
+// 边在投影中视为直线，对田块尺度的多边形与大地线面积的差异可忽略
+func PolygonArea(polygons []Polygon) float64 {
+	var area float64
+	for _, polygon := range polygons {
+		for i, ring := range polygon {
+			a := math.Abs(ringSignedArea(ring))
+			if i == 0 {
+				area += a
+			} else {
+				area -= a
+			}
+		}
+	}
+	return math.Max(area, 0)
+}
+
+// PolygonCentroid 计算多边形的面积加权质心，按以首个顶点为原点的局部平面近似计算
+func PolygonCentroid(polygons []Polygon) LngLat {
+	if len(polygons) == 0 || len(polygons[0]) == 0 || len(polygons[0][0]) == 0 {
+		return LngLat{}
+	}
+	origin := polygons[0][0][0]
+	scale := math.Cos(origin[1] * math.Pi / 180)
+	local := func(p LngLat) (float64, float64) {
+		return (math.Mod(p[0]-origin[0]+540, 360) - 180) * scale, p[1] - origin[1]
+	}
+
+	var weight, sumX, sumY float64
+	for _, polygon := range polygons {
+		for i, ring := range polygon {
+			var a, cx, cy float64
+			for j := 0; j < len(ring)-1; j++ {
+				x1, y1 := local(ring[j])
+				x2, y2 := local(ring[j+1])
+				cross := x1*y2 - x2*y1
+				a += cross
+				cx += (x1 + x2) * cross
+				cy += (y1 + y2) * cross
+			}
+			if a == 0 {
+				continue
+			}
+			cx, cy = cx/(3*a), cy/(3*a)
+			w := math.Abs(a)
+			if i > 0 {
+				w = -w
+			}
+			weight += w
+			sumX += w * cx
+			sumY += w * cy
+		}
+	}
+	if weight == 0 {
+		return origin
+	}
+	lng := origin[0] + sumX/weight/scale
+	if lng > 180 {
+		lng -= 360
+	} else if lng < -180 {
+		lng += 360
+	}
+	return LngLat{lng, origin[1] + sumY/weight}
+}
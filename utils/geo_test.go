@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+// square 以 (lng, lat) 为西南角、边长 size（°）的逆时针闭合环
+func square(lng, lat, size float64) []LngLat {
+	return []LngLat{{lng, lat}, {lng + size, lat}, {lng + size, lat + size}, {lng, lat + size}, {lng, lat}}
+}
+
+func TestPolygonArea(t *testing.T) {
+	tests := []struct {
+		name     string
+		polygons []Polygon
+		want     float64 // m²
+	}{
+		{"equator square", []Polygon{{square(0, 0, 0.01)}}, 1230900},
+		{"square at 40N", []Polygon{{square(116, 40, 0.01)}}, 948170},
+		{"square with hole", []Polygon{{square(0, 0, 0.01), square(0.0025, 0.0025, 0.005)}}, 1230900 * 0.75},
+		{"across antimeridian", []Polygon{{square(179.995, 0, 0.01)}}, 1230900},
+		{"multipolygon", []Polygon{{square(0, 0, 0.01)}, {square(1, 0, 0.01)}}, 1230900 * 2},
+		{"degenerate ring", []Polygon{{{{0, 0}, {0.01, 0}}}}, 0},
+		{"empty", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PolygonArea(tt.polygons)
+			if math.Abs(got-tt.want) > tt.want*0.002 {
+				t.Errorf("PolygonArea() = %.0f, want %.0f ± 0.2%%", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolygonCentroid(t *testing.T) {
+	tests := []struct {
+		name     string
+		polygons []Polygon
+		want     LngLat
+	}{
+		{"rectangle", []Polygon{{{{116, 40}, {116.02, 40}, {116.02, 40.01}, {116, 40.01}, {116, 40}}}}, LngLat{116.01, 40.005}},
+		{"hole shifts centroid", []Polygon{{square(0, 0, 0.02), square(0, 0, 0.01)}}, LngLat{0.011667, 0.011667}},
+		{"across antimeridian", []Polygon{{square(179.99, 0, 0.04)}}, LngLat{-179.99, 0.02}},
+		{"empty", nil, LngLat{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PolygonCentroid(tt.polygons)
+			if math.Abs(got[0]-tt.want[0]) > 1e-4 || math.Abs(got[1]-tt.want[1]) > 1e-4 {
+				t.Errorf("PolygonCentroid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGCJ02Conversion(t *testing.T) {
+	tests := []struct {
+		name    string
+		lng     float64
+		lat     float64
+		inChina bool
+	}{
+		{"beijing", 116.397128, 39.916527, true},
+		{"urumqi", 87.617733, 43.792818, true},
+		{"sanya", 109.511909, 18.252847, true},
+		{"harbin", 126.534967, 45.803775, true},
+		{"tokyo", 139.691706, 35.689487, false},
+		{"london", -0.127758, 51.507351, false},
+		{"southern hemisphere", 116.397128, -33.8688, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := !OutOfChina(tt.lng, tt.lat); got != tt.inChina {
+				t.Fatalf("OutOfChina() = %v, want %v", !got, !tt.inChina)
+			}
+			gcjLng, gcjLat := WGS84ToGCJ02(tt.lng, tt.lat)
+			if !tt.inChina {
+				if gcjLng != tt.lng || gcjLat != tt.lat {
+					t.Errorf("WGS84ToGCJ02() = (%v, %v), want unchanged", gcjLng, gcjLat)
+				}
+				return
+			}
+			// 国内 GCJ-02 偏移量在数百米量级
+			if offset := math.Hypot(gcjLng-tt.lng, gcjLat-tt.lat); offset < 1e-4 || offset > 1e-2 {
+				t.Errorf("WGS84ToGCJ02() offset = %v°, want between 1e-4° and 1e-2°", offset)
+			}
+			wgsLng, wgsLat := GCJ02ToWGS84(gcjLng, gcjLat)
+			if math.Abs(wgsLng-tt.lng) > 1e-7 || math.Abs(wgsLat-tt.lat) > 1e-7 {
+				t.Errorf("GCJ02ToWGS84(WGS84ToGCJ02()) = (%v, %v), want (%v, %v)", wgsLng, wgsLat, tt.lng, tt.lat)
+			}
+		})
+	}
+}
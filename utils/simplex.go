@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"errors"
+	"math"
+)
+
+// LPOp 线性约束的关系类型
+type LPOp int
+
+const (
+	LPLessEq    LPOp = iota // ≤
+	LPGreaterEq             // ≥
+	LPEqual                 // =
+)
+
+// LPConstraint 线性约束：Coeffs·x (Op) RHS
+type LPConstraint struct {
+	Coeffs []float64
+	Op     LPOp
+	RHS    float64
+}
+
+var (
+	// ErrLPInfeasible 线性规划无可行解
+	ErrLPInfeasible = errors.New("linear program is infeasible")
+	// ErrLPUnbounded 线性规划目标函数无界
+	ErrLPUnbounded = errors.New("linear program is unbounded")
+)
+
+const (
+	lpEpsilon       = 1e-9
+	lpMaxIterations = 10000
+)
+
+// SolveLP 使用两阶段单纯形法求解 min c·x，约束为 constraints 且 x ≥ 0
+// 返回最优解与最优目标值
+func SolveLP(c []float64, constraints []LPConstraint) ([]float64, float64, error) {
+	n := len(c)
+	m := len(constraints)
+
+	// 统计松弛变量与人工变量数量
+	numSlack, numArt := 0, 0
+	rows := make([]LPConstraint, m)
+	for i, con := range constraints {
+		if len(con.Coeffs) != n {
+			return nil, 0, errors.New("constraint size does not match objective")
+		}
+		row := LPConstraint{Coeffs: append([]float64(nil), con.Coeffs...), Op: con.Op, RHS: con.RHS}
+		// 保证右端项非负
+		if row.RHS < 0 {
+			for j := range row.Coeffs {
+				row.Coeffs[j] = -row.Coeffs[j]
+			}
+			row.RHS = -row.RHS
+			switch row.Op {
+			case LPLessEq:
+				row.Op = LPGreaterEq
+			case LPGreaterEq:
+				row.Op = LPLessEq
+			}
+		}
+		switch row.Op {
+		case LPLessEq:
+			numSlack++
+		case LPGreaterEq:
+			numSlack++
+			numArt++
+		case LPEqual:
+			numArt++
+		}
+		rows[i] = row
+	}
+
+	cols := n + numSlack + numArt
+	artStart := n + numSlack
+	tableau := make([][]float64, m)
+	basis := make([]int, m)
+	slackIdx, artIdx := n, artStart
+	for i, row := range rows {
+		tableau[i] = make([]float64, cols+1)
+		copy(tableau[i], row.Coeffs)
+		tableau[i][cols] = row.RHS
+		switch row.Op {
+		case LPLessEq:
+			tableau[i][slackIdx] = 1
+			basis[i] = slackIdx
+			slackIdx++
+		case LPGreaterEq:
+			tableau[i][slackIdx] = -1
+			slackIdx++
+			tableau[i][artIdx] = 1
+			basis[i] = artIdx
+			artIdx++
+		case LPEqual:
+			tableau[i][artIdx] = 1
+			basis[i] = artIdx
+			artIdx++
+		}
+	}
+
+	// 第一阶段：最小化人工变量之和
+	if numArt > 0 {
+		phase1 := make([]float64, cols)
+		for j := artStart; j < cols; j++ {
+			phase1[j] = 1
+		}
+		if err := runSimplex(tableau, basis, phase1, cols); err != nil {
+			return nil, 0, err
+		}
+		if objectiveValue(tableau, basis, phase1, cols) > 1e-7 {
+			return nil, 0, ErrLPInfeasible
+		}
+		// 将仍在基中的人工变量换出
+		for i := range basis {
+			if basis[i] < artStart {
+				continue
+			}
+			for j := 0; j < artStart; j++ {
+				if math.Abs(tableau[i][j]) > lpEpsilon {
+					pivot(tableau, basis, i, j)
+					break
+				}
+			}
+		}
+	}
+
+	// 第二阶段：求解原目标，人工变量不再入基
+	phase2 := make([]float64, cols)
+	copy(phase2, c)
+	if err := runSimplex(tableau, basis, phase2, artStart); err != nil {
+		return nil, 0, err
+	}
+
+	x := make([]float64, n)
+	for i, b := range basis {
+		if b < n {
+			x[b] = tableau[i][cols]
+		}
+	}
+	return x, objectiveValue(tableau, basis, phase2, cols), nil
+}
+
+// runSimplex 在当前可行基上迭代，仅允许下标小于 limit 的变量入基（Bland 规则防止循环）
+func runSimplex(tableau [][]float64, basis []int, cost []float64, limit int) error {
+	// 没有约束时可行域为 x ≥ 0，任一目标系数为负即无界，否则 x = 0 最优
+	if len(tableau) == 0 {
+		for j := 0; j < limit; j++ {
+			if cost[j] < -lpEpsilon {
+				return ErrLPUnbounded
+			}
+		}
+		return nil
+	}
+	rhs := len(tableau[0]) - 1
+	for iter := 0; iter < lpMaxIterations; iter++ {
+		entering := -1
+		for j := 0; j < limit; j++ {
+			reduced := cost[j]
+			for i, b := range basis {
+				reduced -= cost[b] * tableau[i][j]
+			}
+			if reduced < -lpEpsilon {
+				entering = j
+				break
+			}
+		}
+		if entering == -1 {
+			return nil
+		}
+
+		leaving := -1
+		bestRatio := math.Inf(1)
+		for i := range tableau {
+			if tableau[i][entering] <= lpEpsilon {
+				continue
+			}
+			ratio := tableau[i][rhs] / tableau[i][entering]
+			if ratio < bestRatio-lpEpsilon || (math.Abs(ratio-bestRatio) <= lpEpsilon && basis[i] < basis[leaving]) {
+				bestRatio = ratio
+				leaving = i
+			}
+		}
+		if leaving == -1 {
+			return ErrLPUnbounded
+		}
+		pivot(tableau, basis, leaving, entering)
+	}
+	return errors.New("linear program did not converge")
+}
+
+// pivot 以 tableau[row][col] 为主元进行换基
+func pivot(tableau [][]float64, basis []int, row, col int) {
+	p := tableau[row][col]
+	for j := range tableau[row] {
+		tableau[row][j] /= p
+	}
+	for i := range tableau {
+		if i == row || tableau[i][col] == 0 {
+			continue
+		}
+		f := tableau[i][col]
+		for j := range tableau[i] {
+			tableau[i][j] -= f * tableau[row][j]
+		}
+	}
+	basis[row] = col
+}
+
+// objectiveValue 计算当前基可行解的目标值
+func objectiveValue(tableau [][]float64, basis []int, cost []float64, cols int) float64 {
+	value := 0.0
+	for i, b := range basis {
+		value += cost[b] * tableau[i][cols]
+	}
+	return value
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSolveLP(t *testing.T) {
+	tests := []struct {
+		name        string
+		c           []float64
+		constraints []LPConstraint
+		x           []float64
+		value       float64
+	}{
+		{
+			"maximize with less-equal constraints",
+			[]float64{-1, -1},
+			[]LPConstraint{
+				{Coeffs: []float64{1, 2}, Op: LPLessEq, RHS: 4},
+				{Coeffs: []float64{3, 1}, Op: LPLessEq, RHS: 6},
+			},
+			[]float64{1.6, 1.2}, -2.8,
+		},
+		{
+			"greater-equal and equality need phase one",
+			[]float64{2, 3},
+			[]LPConstraint{
+				{Coeffs: []float64{1, 1}, Op: LPGreaterEq, RHS: 4},
+				{Coeffs: []float64{1, -1}, Op: LPEqual, RHS: 1},
+			},
+			[]float64{2.5, 1.5}, 9.5,
+		},
+		{
+			"negative right-hand side",
+			[]float64{1},
+			[]LPConstraint{{Coeffs: []float64{-1}, Op: LPLessEq, RHS: -3}},
+			[]float64{3}, 3,
+		},
+		{
+			"redundant equality",
+			[]float64{1, 1},
+			[]LPConstraint{
+				{Coeffs: []float64{1, 0}, Op: LPEqual, RHS: 2},
+				{Coeffs: []float64{2, 0}, Op: LPEqual, RHS: 4},
+				{Coeffs: []float64{0, 1}, Op: LPGreaterEq, RHS: 1},
+			},
+			[]float64{2, 1}, 3,
+		},
+		{
+			"no constraints",
+			[]float64{1, 2},
+			nil,
+			[]float64{0, 0}, 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, value, err := SolveLP(tt.c, tt.constraints)
+			if err != nil {
+				t.Fatalf("SolveLP() error = %v", err)
+			}
+			for j := range tt.x {
+				if math.Abs(x[j]-tt.x[j]) > 1e-6 {
+					t.Errorf("SolveLP() x = %v, want %v", x, tt.x)
+					break
+				}
+			}
+			if math.Abs(value-tt.value) > 1e-6 {
+				t.Errorf("SolveLP() value = %v, want %v", value, tt.value)
+			}
+		})
+	}
+}
+
+func TestSolveLPErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		c           []float64
+		constraints []LPConstraint
+		want        error
+	}{
+		{
+			"infeasible bounds",
+			[]float64{1},
+			[]LPConstraint{
+				{Coeffs: []float64{1}, Op: LPLessEq, RHS: 1},
+				{Coeffs: []float64{1}, Op: LPGreaterEq, RHS: 2},
+			},
+			ErrLPInfeasible,
+		},
+		{
+			"infeasible equality",
+			[]float64{1, 1},
+			[]LPConstraint{{Coeffs: []float64{1, 1}, Op: LPEqual, RHS: -1}},
+			ErrLPInfeasible,
+		},
+		{
+			"unbounded direction",
+			[]float64{-1, 0},
+			[]LPConstraint{{Coeffs: []float64{1, -1}, Op: LPLessEq, RHS: 1}},
+			ErrLPUnbounded,
+		},
+		{
+			"unbounded without constraints",
+			[]float64{0, -1},
+			nil,
+			ErrLPUnbounded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := SolveLP(tt.c, tt.constraints); err != tt.want {
+				t.Errorf("SolveLP() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolveLPSizeMismatch(t *testing.T) {
+	_, _, err := SolveLP([]float64{1, 1}, []LPConstraint{{Coeffs: []float64{1}, Op: LPLessEq, RHS: 1}})
+	if err == nil || err == ErrLPInfeasible || err == ErrLPUnbounded {
+		t.Errorf("SolveLP() error = %v, want size mismatch error", err)
+	}
+}